@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"io"
+	"os"
+	"path"
+	"time"
+)
+
+func main() {
+	log.Namespace = "collection-conflicts"
+
+	zebRoot := flag.String("zeb_root", "", "The root zebedee directory")
+	collection := flag.String("collection", "", "Only report the conflicts of this collection")
+	format := flag.String("format", "csv", "The report format: csv or json")
+	out := flag.String("out", "", "The file to write the report to, defaults to stdout")
+	flag.Parse()
+
+	if *zebRoot == "" {
+		logAndExit(errs.New("missing flag", nil, log.Data{"var": "zeb_root"}))
+	}
+
+	if *format != "csv" && *format != "json" {
+		logAndExit(errs.New("invalid flag value expected csv or json", nil, log.Data{"var": "format", "value": *format}))
+	}
+
+	cols, err := collections.GetCollections(path.Join(*zebRoot, "collections"))
+	if err != nil {
+		logAndExit(err)
+	}
+
+	if *collection != "" {
+		if _, err := cols.GetByName(*collection); err != nil {
+			logAndExit(err)
+		}
+	}
+
+	conflicts, err := collections.FindConflicts(cols)
+	if err != nil {
+		logAndExit(err)
+	}
+
+	if *collection != "" {
+		conflicts = involving(conflicts, *collection)
+	}
+
+	if err := writeReport(*format, *out, conflicts); err != nil {
+		logAndExit(err)
+	}
+
+	log.Event(nil, "collection conflict report completed", log.Data{
+		"collections": len(cols.Collections),
+		"conflicts":   len(conflicts),
+	})
+
+	if len(conflicts) > 0 {
+		os.Exit(1)
+	}
+}
+
+// involving returns the conflicts that the collection is part of.
+func involving(conflicts []collections.Conflict, name string) []collections.Conflict {
+	filtered := make([]collections.Conflict, 0)
+	for _, c := range conflicts {
+		for _, cp := range c.Copies {
+			if cp.Collection == name {
+				filtered = append(filtered, c)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// writeReport writes the conflicts, as a CSV row for each copy of each uri or as JSON.
+func writeReport(format string, out string, conflicts []collections.Conflict) error {
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return errs.New("failed to create report file", err, log.Data{"out": out})
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(conflicts)
+	}
+
+	csvW := csv.NewWriter(w)
+	if err := csvW.Write([]string{"uri", "collection", "state", "modified", "approval_status", "type", "publish_date"}); err != nil {
+		return err
+	}
+
+	for _, c := range conflicts {
+		for _, cp := range c.Copies {
+			row := []string{c.URI, cp.Collection, cp.State, cp.Modified.Format(time.RFC3339), cp.ApprovalStatus, cp.Type, cp.PublishDate}
+			if err := csvW.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	csvW.Flush()
+	return csvW.Error()
+}
+
+func logAndExit(err error) {
+	if colErr, ok := err.(errs.Error); ok {
+		if colErr.OriginalErr != nil {
+			log.Event(nil, colErr.Message, log.Error(colErr.OriginalErr), colErr.Data)
+		} else {
+			log.Event(nil, colErr.Message, colErr.Data)
+		}
+	} else {
+		log.Event(nil, "unknown error", log.Error(err))
+	}
+	os.Exit(1)
+}
@@ -3,25 +3,15 @@ package main
 import (
 	"flag"
 	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/dp-zebedee-utils/findreplace"
+	"github.com/ONSdigital/dp-zebedee-utils/linkindex"
 	"github.com/ONSdigital/log.go/log"
 	"github.com/pkg/errors"
-	"io/ioutil"
 	"os"
-	"path/filepath"
-	"strings"
+	"time"
 )
 
-const (
-	oldEmail = "@ons.gsi.gov.uk"
-	newEmail = "@ons.gov.uk"
-)
-
-type Tracker struct {
-	total   int
-	blocked []string
-	fixed   int
-}
-
 type Err struct {
 	Data log.Data
 	Err  error
@@ -31,26 +21,60 @@ func (e Err) Error() string {
 	return e.Err.Error()
 }
 
+type config struct {
+	master         string
+	collectionsDir string
+	rulesFile      string
+	collectionName string
+	create         bool
+	blocked        string
+	deferredFile   string
+	replayFile     string
+	indexFile      string
+}
+
 func main() {
 	log.Namespace = "fi-xxx-er"
-	master, collectionsDir := getConfig()
+	cfg := getConfig()
+
+	rules, err := findreplace.LoadRules(cfg.rulesFile)
+	if err != nil {
+		errExit(err)
+	}
 
-	t, err := findAndReplace(master, collectionsDir)
+	// record every change so the run can be reverted.
+	collections.StartJournal(cfg.collectionsDir, "fixxxer")
+
+	t, err := findAndReplace(cfg, rules)
 	if err != nil {
 		errExit(err)
 	}
 
-	log.Event(nil, "blocked", log.Data{
-		"total_found":           t.total,
-		"fixes_applied":         t.fixed,
-		"blocked_by_collection": len(t.blocked),
-		"outstanding":           t.total - t.fixed,
+	if cfg.blocked == findreplace.BlockedDefer {
+		list := &findreplace.DeferredList{CreatedAt: time.Now(), Collection: cfg.collectionName, Pages: t.Deferred}
+		if err := findreplace.SaveDeferred(cfg.deferredFile, list); err != nil {
+			errExit(err)
+		}
+	}
+
+	log.Event(nil, "find and replace completed", log.Data{
+		"collection": cfg.collectionName,
+		"blocked":    cfg.blocked,
+		"rules":      t.Reports,
+		"deferred":   len(t.Deferred),
 	})
 }
 
-func getConfig() (string, string) {
+func getConfig() *config {
 	master := flag.String("master", "", "the zebedee master dir")
 	collectionsDir := flag.String("collections", "", "the zebedee collections dir")
+	rulesFile := flag.String("rules", "", "a json file of the find and replace rules to apply")
+	collectionName := flag.String("collection", "", "the name of the collection to add the changed files to")
+	create := flag.Bool("create", false, "true to create the collection, false to load the collection specified")
+	blocked := flag.String("blocked", findreplace.BlockedSkip, "what to do with pages in another collection: skip, defer or overflow")
+	deferredFile := flag.String("deferred", "", "the file to write the list of deferred pages to, required if blocked is defer")
+	replayFile := flag.String("replay", "", "a deferred list to replay instead of scanning master")
+	indexFile := flag.String("index", "", "a link index file used to find the pages with links matching the rules instead of scanning master")
 	flag.Parse()
 
 	if *master == "" {
@@ -69,79 +93,82 @@ func getConfig() (string, string) {
 		errExit(Err{Err: errors.New("collections dir does not exist"), Data: log.Data{"collectionsDir": *collectionsDir}})
 	}
 
-	return *master, *collectionsDir
+	if *rulesFile == "" {
+		errExit(errors.New("rules file not specified"))
+	}
+
+	if *collectionName == "" {
+		errExit(errors.New("collection not specified"))
+	}
+
+	switch *blocked {
+	case findreplace.BlockedSkip, findreplace.BlockedDefer, findreplace.BlockedOverflow:
+	default:
+		errExit(Err{Err: errors.New("invalid blocked policy expected skip, defer or overflow"), Data: log.Data{"blocked": *blocked}})
+	}
+
+	if *blocked == findreplace.BlockedDefer && *deferredFile == "" {
+		errExit(errors.New("deferred file not specified"))
+	}
+
+	return &config{
+		master:         *master,
+		collectionsDir: *collectionsDir,
+		rulesFile:      *rulesFile,
+		collectionName: *collectionName,
+		create:         *create,
+		blocked:        *blocked,
+		deferredFile:   *deferredFile,
+		replayFile:     *replayFile,
+		indexFile:      *indexFile,
+	}
 }
 
-func findAndReplace(masterDir string, collectionsDir string) (*Tracker, error) {
-	cols, err := collections.GetCollections(collectionsDir)
+func findAndReplace(cfg *config, rules []*findreplace.Rule) (*findreplace.Tracker, error) {
+	if cfg.create {
+		if err := collections.Save(collections.New(cfg.collectionsDir, cfg.collectionName)); err != nil {
+			return nil, err
+		}
+	}
+
+	fixes, err := collections.GetCollection(cfg.collectionsDir, cfg.collectionName)
 	if err != nil {
 		return nil, err
 	}
+	if fixes == nil {
+		return nil, Err{Err: errors.New("collection not found"), Data: log.Data{"collection": cfg.collectionName}}
+	}
 
-	fixes := collections.New(collectionsDir, "GSIEmailFixes")
-	if err := collections.Save(fixes); err != nil {
-		return nil, err
+	runCfg := findreplace.Config{
+		MasterDir:      cfg.master,
+		CollectionsDir: cfg.collectionsDir,
+		Target:         fixes,
+		Rules:          rules,
+		Blocked:        cfg.blocked,
 	}
 
-	log.Event(nil, "scanner master dir for uses of target value", log.Data{"target_value": oldEmail})
+	if cfg.replayFile != "" {
+		list, err := findreplace.LoadDeferred(cfg.replayFile)
+		if err != nil {
+			return nil, err
+		}
 
-	t := &Tracker{
-		blocked: make([]string, 0),
-		fixed:   0,
-		total:   0,
+		log.Event(nil, "replaying deferred pages", log.Data{"deferred": cfg.replayFile, "pages": len(list.Pages)})
+		return findreplace.Replay(runCfg, list.Pages)
 	}
-	err = filepath.Walk(masterDir, fileWalker(cols, masterDir, t, fixes))
-	return t, err
-}
 
-func fileWalker(cols *collections.Collections, masterDir string, t *Tracker, fixes *collections.Collection) func(path string, info os.FileInfo, err error) error {
-	return func(path string, info os.FileInfo, err error) error {
-		if info.IsDir() {
-			return nil
+	if cfg.indexFile != "" {
+		idx, err := linkindex.Open(cfg.indexFile, cfg.master)
+		if err != nil {
+			return nil, err
 		}
 
-		if ext := filepath.Ext(info.Name()); ext == ".json" {
-
-			b, err := ioutil.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			raw := string(b)
-
-			if strings.Contains(raw, oldEmail) {
-
-				if strings.Contains(path, "/previous/") {
-					return nil
-				}
-
-				t.total++
-
-				if strings.Contains(path, "/datasets/") || strings.Contains(path, "/timeseries/") {
-					return nil
-				}
-
-				uri, err := filepath.Rel(masterDir, path)
-				if err != nil {
-					return err
-				}
-
-				uri = "/" + uri
-				for _, c := range cols.Collections {
-					if c.Contains(uri) {
-						t.blocked = append(t.blocked, uri)
-						break
-					}
-				}
-
-				raw = strings.Replace(raw, oldEmail, newEmail, -1)
-				if err := fixes.AddContent(uri, []byte(raw)); err != nil {
-					return err
-				}
-				t.fixed++
-			}
-		}
-		return nil
+		log.Event(nil, "querying link index for uses of rule patterns", log.Data{"rules": len(rules), "index": cfg.indexFile})
+		return findreplace.RunIndexed(runCfg, idx)
 	}
+
+	log.Event(nil, "scanning master dir for uses of rule patterns", log.Data{"rules": len(rules)})
+	return findreplace.Run(runCfg)
 }
 
 func Exists(filePath string) bool {
@@ -156,9 +183,12 @@ func Exists(filePath string) bool {
 }
 
 func errExit(err error) {
-	appErr, ok := err.(Err)
-	if ok {
+	if appErr, ok := err.(Err); ok {
 		log.Event(nil, "app error", log.Error(appErr.Err), appErr.Data)
+	} else if colErr, ok := err.(errs.Error); ok && colErr.OriginalErr != nil {
+		log.Event(nil, colErr.Message, log.Error(colErr.OriginalErr), colErr.Data)
+	} else if ok {
+		log.Event(nil, colErr.Message, colErr.Data)
 	} else {
 		log.Event(nil, "app error", log.Error(err))
 	}
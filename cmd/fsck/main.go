@@ -0,0 +1,398 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/content/cms"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Problem kinds.
+const (
+	kindMissingDir            = "missing-dir"
+	kindCollectionWithoutJSON = "collection-without-json"
+	kindJSONWithoutCollection = "json-without-collection"
+	kindInvalidJSON           = "invalid-json"
+	kindIDMismatch            = "id-mismatch"
+	kindEmptyInProgress       = "empty-inprogress"
+	kindDuplicateURI          = "duplicate-uri"
+)
+
+// Repair actions.
+const (
+	actionCreated     = "created"
+	actionQuarantined = "quarantined"
+	actionRemoved     = "removed"
+)
+
+var filenameRegex = regexp.MustCompile(`[^a-z0-9]`)
+
+// Problem is an inconsistency found in a zebedee root. Action is what -repair did about it, empty if nothing.
+type Problem struct {
+	Kind       string `json:"kind"`
+	Path       string `json:"path"`
+	Collection string `json:"collection"`
+	Message    string `json:"message"`
+	Action     string `json:"action"`
+}
+
+// checker finds problems in a zebedee root, repairing them if repair is set.
+type checker struct {
+	zebRoot    string
+	quarantine string
+	repair     bool
+	problems   []Problem
+}
+
+func main() {
+	log.Namespace = "zebedee-fsck"
+
+	zebRoot := flag.String("zeb_root", "", "The root zebedee directory")
+	repair := flag.Bool("repair", false, "Create missing dirs, quarantine broken collections and remove empty inprogress dirs")
+	quarantine := flag.String("quarantine", "", "The dir broken collections are moved to, defaults to a timestamped dir beside zeb_root")
+	format := flag.String("format", "csv", "The report format: csv or json")
+	out := flag.String("out", "", "The file to write the report to, defaults to stdout")
+	flag.Parse()
+
+	if *zebRoot == "" {
+		logAndExit(errs.New("missing flag", nil, log.Data{"var": "zeb_root"}))
+	}
+
+	if *format != "csv" && *format != "json" {
+		logAndExit(errs.New("invalid flag value expected csv or json", nil, log.Data{"var": "format", "value": *format}))
+	}
+
+	c := &checker{
+		zebRoot:    filepath.Clean(*zebRoot),
+		quarantine: *quarantine,
+		repair:     *repair,
+		problems:   make([]Problem, 0),
+	}
+	if c.quarantine == "" {
+		c.quarantine = fmt.Sprintf("%s-quarantine/%s", c.zebRoot, time.Now().UTC().Format("20060102-150405"))
+	}
+
+	if err := c.check(); err != nil {
+		logAndExit(err)
+	}
+
+	if err := writeReport(*format, *out, c.problems); err != nil {
+		logAndExit(err)
+	}
+
+	unrepaired := 0
+	for _, p := range c.problems {
+		if p.Action == "" {
+			unrepaired++
+		}
+	}
+
+	log.Event(nil, "fsck completed", log.Data{
+		"problems":   len(c.problems),
+		"repaired":   len(c.problems) - unrepaired,
+		"unrepaired": unrepaired,
+		"repair":     c.repair,
+	})
+
+	if unrepaired > 0 {
+		os.Exit(1)
+	}
+}
+
+func (c *checker) check() error {
+	if err := c.checkDirs(); err != nil {
+		return err
+	}
+
+	collectionsDir := path.Join(c.zebRoot, cms.Collections)
+	if !collections.Exists(collectionsDir) {
+		return nil
+	}
+
+	names, err := c.checkCollectionFiles(collectionsDir)
+	if err != nil {
+		return err
+	}
+
+	cols := &collections.Collections{Collections: make([]*collections.Collection, 0)}
+	for _, name := range names {
+		col, err := collections.GetCollection(collectionsDir, name)
+		if err != nil || col == nil {
+			continue
+		}
+
+		c.checkID(col)
+
+		if err := c.checkInProgress(col); err != nil {
+			return err
+		}
+
+		cols.Add(col)
+	}
+
+	return c.checkDuplicateURIs(cols)
+}
+
+// checkDirs finds the top level dirs missing from the zebedee root.
+func (c *checker) checkDirs() error {
+	for _, dir := range cms.Dirs(c.zebRoot) {
+		if collections.Exists(dir) {
+			continue
+		}
+
+		p := Problem{Kind: kindMissingDir, Path: dir, Message: "zebedee directory does not exist"}
+		if c.repair {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return errs.New("failed to create missing dir", err, log.Data{"dir": dir})
+			}
+			p.Action = actionCreated
+		}
+		c.add(p)
+	}
+	return nil
+}
+
+// checkCollectionFiles finds collection dirs without a json file, json files without a dir and json files that can't be
+// read. Returns the names of the collections that have both.
+func (c *checker) checkCollectionFiles(collectionsDir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(collectionsDir)
+	if err != nil {
+		return nil, errs.New("failed to read collections dir", err, nil)
+	}
+
+	dirs := make(map[string]bool)
+	jsonFiles := make(map[string]bool)
+	for _, info := range infos {
+		if info.IsDir() {
+			dirs[info.Name()] = true
+		} else if filepath.Ext(info.Name()) == ".json" {
+			jsonFiles[strings.TrimSuffix(info.Name(), ".json")] = true
+		}
+	}
+
+	names := make([]string, 0)
+	for _, info := range infos {
+		name := strings.TrimSuffix(info.Name(), ".json")
+		metadata := collections.NewMetadata(collectionsDir, name)
+
+		switch {
+		case info.IsDir() && !jsonFiles[name]:
+			if err := c.quarantineItem(Problem{Kind: kindCollectionWithoutJSON, Path: metadata.CollectionRoot, Collection: name, Message: "collection dir has no collection json, Zebedee ignores it"}, metadata.CollectionRoot); err != nil {
+				return nil, err
+			}
+		case !info.IsDir() && jsonFiles[name] && !dirs[name]:
+			if err := c.quarantineItem(Problem{Kind: kindJSONWithoutCollection, Path: metadata.CollectionJSON, Collection: name, Message: "collection json has no collection dir"}, metadata.CollectionJSON); err != nil {
+				return nil, err
+			}
+		case info.IsDir():
+			if _, err := collections.GetCollection(collectionsDir, name); err != nil {
+				p := Problem{Kind: kindInvalidJSON, Path: metadata.CollectionJSON, Collection: name, Message: err.Error()}
+				if err := c.quarantineItem(p, metadata.CollectionJSON, metadata.CollectionRoot); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// checkID finds collections whose id does not start with their name, as Zebedee creates them, or whose name does not
+// match their file name.
+func (c *checker) checkID(col *collections.Collection) {
+	fileName := col.Metadata.Name
+	if col.Name != fileName && filename(col.Name) != fileName {
+		c.add(Problem{
+			Kind:       kindIDMismatch,
+			Path:       col.Metadata.CollectionJSON,
+			Collection: fileName,
+			Message:    fmt.Sprintf("collection name %q does not match its file name", col.Name),
+		})
+	}
+
+	if !strings.HasPrefix(col.ID, col.Name+"-") && !strings.HasPrefix(col.ID, filename(col.Name)+"-") {
+		c.add(Problem{
+			Kind:       kindIDMismatch,
+			Path:       col.Metadata.CollectionJSON,
+			Collection: fileName,
+			Message:    fmt.Sprintf("collection id %q does not match its name %q", col.ID, col.Name),
+		})
+	}
+}
+
+// checkInProgress finds dirs in the collection's inprogress dir that have no files beneath them.
+func (c *checker) checkInProgress(col *collections.Collection) error {
+	inProgress := col.GetInProgress()
+	if !collections.Exists(inProgress) {
+		return nil
+	}
+
+	infos, err := ioutil.ReadDir(inProgress)
+	if err != nil {
+		return errs.New("failed to read inprogress dir", err, log.Data{"collection": col.Metadata.Name})
+	}
+
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+
+		dir := path.Join(inProgress, info.Name())
+		empty, err := isEmptyTree(dir)
+		if err != nil {
+			return errs.New("failed to read inprogress dir", err, log.Data{"dir": dir})
+		}
+
+		if !empty {
+			continue
+		}
+
+		p := Problem{Kind: kindEmptyInProgress, Path: dir, Collection: col.Metadata.Name, Message: "inprogress dir has no files"}
+		if c.repair {
+			if err := os.RemoveAll(dir); err != nil {
+				return errs.New("failed to remove empty inprogress dir", err, log.Data{"dir": dir})
+			}
+			p.Action = actionRemoved
+		}
+		c.add(p)
+	}
+	return nil
+}
+
+// checkDuplicateURIs finds files that are in more than one collection, see cmd/conflicts for the full report.
+func (c *checker) checkDuplicateURIs(cols *collections.Collections) error {
+	conflicts, err := collections.FindConflicts(cols)
+	if err != nil {
+		return err
+	}
+
+	for _, conflict := range conflicts {
+		names := make([]string, 0, len(conflict.Copies))
+		seen := make(map[string]bool)
+		for _, cp := range conflict.Copies {
+			if !seen[cp.Collection] {
+				seen[cp.Collection] = true
+				names = append(names, cp.Collection)
+			}
+		}
+
+		c.add(Problem{
+			Kind:       kindDuplicateURI,
+			Path:       conflict.URI,
+			Collection: strings.Join(names, ","),
+			Message:    fmt.Sprintf("uri is in %d collections", len(names)),
+		})
+	}
+	return nil
+}
+
+// quarantineItem records the problem, moving the files to the quarantine dir if repair is set.
+func (c *checker) quarantineItem(p Problem, files ...string) error {
+	if c.repair {
+		dest := path.Join(c.quarantine, cms.Collections)
+		if err := os.MkdirAll(dest, 0755); err != nil {
+			return errs.New("failed to create quarantine dir", err, log.Data{"dir": dest})
+		}
+
+		for _, f := range files {
+			if !collections.Exists(f) {
+				continue
+			}
+
+			target := path.Join(dest, filepath.Base(f))
+			if err := os.Rename(f, target); err != nil {
+				return errs.New("failed to quarantine", err, log.Data{"from": f, "to": target})
+			}
+		}
+		p.Action = actionQuarantined
+	}
+	c.add(p)
+	return nil
+}
+
+func (c *checker) add(p Problem) {
+	log.Event(nil, "fsck problem", log.Data{"kind": p.Kind, "path": p.Path, "collection": p.Collection, "action": p.Action})
+	c.problems = append(c.problems, p)
+}
+
+// isEmptyTree returns true if there are no files beneath dir.
+func isEmptyTree(dir string) (bool, error) {
+	empty := true
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			empty = false
+			return io.EOF
+		}
+		return nil
+	})
+	if err == io.EOF {
+		err = nil
+	}
+	return empty, err
+}
+
+// filename returns the name Zebedee stores a collection under, the lowercase letters and digits of its name.
+func filename(name string) string {
+	return filenameRegex.ReplaceAllString(strings.ToLower(name), "")
+}
+
+func writeReport(format string, out string, problems []Problem) error {
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return errs.New("failed to create report file", err, log.Data{"out": out})
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(problems)
+	}
+
+	csvW := csv.NewWriter(w)
+	if err := csvW.Write([]string{"kind", "path", "collection", "message", "action"}); err != nil {
+		return err
+	}
+
+	for _, p := range problems {
+		if err := csvW.Write([]string{p.Kind, p.Path, p.Collection, p.Message, p.Action}); err != nil {
+			return err
+		}
+	}
+	csvW.Flush()
+	return csvW.Error()
+}
+
+func logAndExit(err error) {
+	if colErr, ok := err.(errs.Error); ok {
+		if colErr.OriginalErr != nil {
+			log.Event(nil, colErr.Message, log.Error(colErr.OriginalErr), colErr.Data)
+		} else {
+			log.Event(nil, colErr.Message, colErr.Data)
+		}
+	} else {
+		log.Event(nil, "unknown error", log.Error(err))
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/content/cms"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testZebRoot creates a consistent zebedee root with a collection called good containing /a/data.json.
+func testZebRoot(t *testing.T) (string, string) {
+	dir, err := ioutil.TempDir("", "fsck")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zebRoot := path.Join(dir, "zebedee")
+	for _, d := range cms.Dirs(zebRoot) {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	addCollection(t, zebRoot, "good", "/a/data.json")
+	return dir, zebRoot
+}
+
+// addCollection creates a collection with a file in inprogress for each uri.
+func addCollection(t *testing.T, zebRoot string, name string, uris ...string) *collections.Collection {
+	col := collections.New(path.Join(zebRoot, cms.Collections), name)
+	if err := collections.Save(col); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, uri := range uris {
+		if err := col.AddContent(uri, []byte(`{"uri":"`+path.Dir(uri)+`"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return col
+}
+
+func writeFile(t *testing.T, filename string, content string) {
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mkdir(t *testing.T, dir string) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tree returns the paths of every file and dir under dir, relative to dir.
+func tree(t *testing.T, dir string) []string {
+	paths := make([]string, 0)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func runCheck(t *testing.T, zebRoot string, quarantine string, repair bool) []Problem {
+	c := &checker{zebRoot: zebRoot, quarantine: quarantine, repair: repair, problems: make([]Problem, 0)}
+	if err := c.check(); err != nil {
+		t.Fatal(err)
+	}
+	return c.problems
+}
+
+func TestCheckConsistentRoot(t *testing.T) {
+	dir, zebRoot := testZebRoot(t)
+	defer os.RemoveAll(dir)
+
+	if problems := runCheck(t, zebRoot, path.Join(dir, "quarantine"), true); len(problems) != 0 {
+		t.Errorf("expected no problems, got %+v", problems)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		kind string
+
+		// corrupt breaks the zebedee root, returning the path and collection of the problem expected.
+		corrupt func(t *testing.T, zebRoot string) (string, string)
+
+		// action is what -repair is expected to do, repaired checks the root after the repair.
+		action   string
+		repaired func(t *testing.T, zebRoot string, quarantine string)
+	}{
+		{
+			kind: kindMissingDir,
+			corrupt: func(t *testing.T, zebRoot string) (string, string) {
+				dir := path.Join(zebRoot, cms.Teams)
+				if err := os.Remove(dir); err != nil {
+					t.Fatal(err)
+				}
+				return dir, ""
+			},
+			action: actionCreated,
+			repaired: func(t *testing.T, zebRoot string, quarantine string) {
+				if !collections.Exists(path.Join(zebRoot, cms.Teams)) {
+					t.Error("expected the missing dir to be created")
+				}
+			},
+		},
+		{
+			kind: kindCollectionWithoutJSON,
+			corrupt: func(t *testing.T, zebRoot string) (string, string) {
+				dir := path.Join(zebRoot, cms.Collections, "orphan")
+				mkdir(t, path.Join(dir, "inprogress", "b"))
+				writeFile(t, path.Join(dir, "inprogress", "b", "data.json"), `{"uri":"/b"}`)
+				return dir, "orphan"
+			},
+			action: actionQuarantined,
+			repaired: func(t *testing.T, zebRoot string, quarantine string) {
+				if collections.Exists(path.Join(zebRoot, cms.Collections, "orphan")) {
+					t.Error("expected the collection dir to be removed from collections")
+				}
+
+				if !collections.Exists(path.Join(quarantine, cms.Collections, "orphan", "inprogress", "b", "data.json")) {
+					t.Error("expected the collection dir and its content to be quarantined")
+				}
+			},
+		},
+		{
+			kind: kindJSONWithoutCollection,
+			corrupt: func(t *testing.T, zebRoot string) (string, string) {
+				filename := path.Join(zebRoot, cms.Collections, "lonely.json")
+				writeFile(t, filename, `{"name":"lonely","id":"lonely-1"}`)
+				return filename, "lonely"
+			},
+			action: actionQuarantined,
+			repaired: func(t *testing.T, zebRoot string, quarantine string) {
+				if collections.Exists(path.Join(zebRoot, cms.Collections, "lonely.json")) {
+					t.Error("expected the collection json to be removed from collections")
+				}
+
+				if !collections.Exists(path.Join(quarantine, cms.Collections, "lonely.json")) {
+					t.Error("expected the collection json to be quarantined")
+				}
+			},
+		},
+		{
+			kind: kindInvalidJSON,
+			corrupt: func(t *testing.T, zebRoot string) (string, string) {
+				col := addCollection(t, zebRoot, "broken", "/b/data.json")
+				writeFile(t, col.Metadata.CollectionJSON, `{"name":`)
+				return col.Metadata.CollectionJSON, "broken"
+			},
+			action: actionQuarantined,
+			repaired: func(t *testing.T, zebRoot string, quarantine string) {
+				for _, name := range []string{"broken", "broken.json"} {
+					if collections.Exists(path.Join(zebRoot, cms.Collections, name)) {
+						t.Errorf("expected %s to be removed from collections", name)
+					}
+
+					if !collections.Exists(path.Join(quarantine, cms.Collections, name)) {
+						t.Errorf("expected %s to be quarantined", name)
+					}
+				}
+			},
+		},
+		{
+			kind: kindIDMismatch,
+			corrupt: func(t *testing.T, zebRoot string) (string, string) {
+				col := addCollection(t, zebRoot, "renamed")
+				writeFile(t, col.Metadata.CollectionJSON, `{"name":"renamed","id":"other-1","type":"manual"}`)
+				return col.Metadata.CollectionJSON, "renamed"
+			},
+		},
+		{
+			kind: kindEmptyInProgress,
+			corrupt: func(t *testing.T, zebRoot string) (string, string) {
+				dir := path.Join(zebRoot, cms.Collections, "good", "inprogress", "empty")
+				mkdir(t, path.Join(dir, "nested"))
+				return dir, "good"
+			},
+			action: actionRemoved,
+			repaired: func(t *testing.T, zebRoot string, quarantine string) {
+				inProgress := path.Join(zebRoot, cms.Collections, "good", "inprogress")
+				if collections.Exists(path.Join(inProgress, "empty")) {
+					t.Error("expected the empty dir to be removed")
+				}
+
+				if !collections.Exists(path.Join(inProgress, "a", "data.json")) {
+					t.Error("expected the collection content to be left")
+				}
+			},
+		},
+		{
+			kind: kindDuplicateURI,
+			corrupt: func(t *testing.T, zebRoot string) (string, string) {
+				addCollection(t, zebRoot, "other", "/a/data.json")
+				return "/a/data.json", "good,other"
+			},
+		},
+	}
+
+	for _, c := range cases {
+		for _, repair := range []bool{false, true} {
+			dir, zebRoot := testZebRoot(t)
+			quarantine := path.Join(dir, "quarantine")
+			problemPath, collection := c.corrupt(t, zebRoot)
+			before := tree(t, dir)
+
+			problems := runCheck(t, zebRoot, quarantine, repair)
+			if len(problems) != 1 {
+				t.Fatalf("%s repair=%t: expected 1 problem, got %+v", c.kind, repair, problems)
+			}
+
+			p := problems[0]
+			if p.Kind != c.kind || p.Path != problemPath || p.Collection != collection {
+				t.Errorf("%s repair=%t: expected the problem at %s in %q, got %+v", c.kind, repair, problemPath, collection, p)
+			}
+
+			if !repair {
+				if p.Action != "" {
+					t.Errorf("%s: expected no action without -repair, got %s", c.kind, p.Action)
+				}
+
+				if after := tree(t, dir); !reflect.DeepEqual(after, before) {
+					t.Errorf("%s: expected nothing to be changed without -repair, got %v", c.kind, after)
+				}
+				os.RemoveAll(dir)
+				continue
+			}
+
+			if p.Action != c.action {
+				t.Errorf("%s: expected -repair to take action %q, got %q", c.kind, c.action, p.Action)
+			}
+
+			if c.repaired != nil {
+				c.repaired(t, zebRoot, quarantine)
+
+				if again := runCheck(t, zebRoot, quarantine, false); len(again) != 0 {
+					t.Errorf("%s: expected no problems after the repair, got %+v", c.kind, again)
+				}
+			}
+			os.RemoveAll(dir)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"flag"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"path"
+	"strings"
+)
+
+const defaultIgnore = "/search,/releasecalendar,/timeseriestool,/file,/chartimage,/chartconfig,/generator,/export,/embed,/resource"
+
+type Args struct {
+	zebRoot        string
+	collectionName string
+	format         string
+	out            string
+	indexFile      string
+	ignore         []string
+	fixesFile      string
+	fixCollection  string
+	create         bool
+}
+
+func (a *Args) GetCollectionsDir() string {
+	return path.Join(a.zebRoot, "collections")
+}
+
+func (a *Args) GetMasterDir() string {
+	return path.Join(a.zebRoot, "master")
+}
+
+// GetCollectionName returns the collection to resolve links against in addition to master.
+func (a *Args) GetCollectionName() string {
+	return a.collectionName
+}
+
+func (a *Args) GetFormat() string {
+	return a.format
+}
+
+// GetOut returns the report output file, if empty the report is written to stdout.
+func (a *Args) GetOut() string {
+	return a.out
+}
+
+func (a *Args) GetIndexFile() string {
+	return a.indexFile
+}
+
+// GetIgnore returns the uri prefixes of links that are not expected to be in master.
+func (a *Args) GetIgnore() []string {
+	return a.ignore
+}
+
+// GetFixesFile returns the csv file of old,new uri replacements to apply to the broken links.
+func (a *Args) GetFixesFile() string {
+	return a.fixesFile
+}
+
+func (a *Args) GetFixCollection() string {
+	return a.fixCollection
+}
+
+func (a *Args) CreateCollection() bool {
+	return a.create
+}
+
+func GetArgs() (*Args, error) {
+	zebRoot := flag.String("zeb_root", "", "The root zebedee directory")
+	collectionName := flag.String("collection", "", "Optional collection to resolve links against as well as master")
+	format := flag.String("format", "csv", "The report format: csv or json")
+	out := flag.String("out", "", "The file to write the report to, defaults to stdout")
+	indexFile := flag.String("index", "", "A link index file to use instead of reading every page in master")
+	ignore := flag.String("ignore", defaultIgnore, "Comma separated uri prefixes of links that are not content in master")
+	fixesFile := flag.String("fixes", "", "A csv file of old,new uri replacements to apply to the broken links")
+	fixCollection := flag.String("fix_collection", "", "The name of the collection to write fixed pages to")
+	create := flag.Bool("create", false, "True flag to create the fix collection, false to load the collection specified")
+	flag.Parse()
+
+	if *zebRoot == "" {
+		return nil, errs.New("missing flag", nil, log.Data{"var": "zeb_root"})
+	}
+
+	if *format != "csv" && *format != "json" {
+		return nil, errs.New("invalid flag value expected csv or json", nil, log.Data{"var": "format", "value": *format})
+	}
+
+	if *fixesFile != "" && *fixCollection == "" {
+		return nil, errs.New("missing flag", nil, log.Data{"var": "fix_collection"})
+	}
+
+	ignored := make([]string, 0)
+	for _, prefix := range strings.Split(*ignore, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			ignored = append(ignored, prefix)
+		}
+	}
+
+	return &Args{
+		zebRoot:        *zebRoot,
+		collectionName: *collectionName,
+		format:         *format,
+		out:            *out,
+		indexFile:      *indexFile,
+		ignore:         ignored,
+		fixesFile:      *fixesFile,
+		fixCollection:  *fixCollection,
+		create:         *create,
+	}, nil
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/ONSdigital/dp-zebedee-utils/cmd/linkcheck/config"
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/dp-zebedee-utils/linkindex"
+	"github.com/ONSdigital/log.go/log"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pageReport is the broken links of a single referring page.
+type pageReport struct {
+	Page        string   `json:"page"`
+	BrokenLinks []string `json:"broken_links"`
+}
+
+type resolver struct {
+	masterDir  string
+	collection *collections.Collection
+	ignore     []string
+	cache      map[string]bool
+}
+
+func main() {
+	log.Namespace = "link-checker"
+
+	args, err := config.GetArgs()
+	if err != nil {
+		logAndExit(err)
+	}
+
+	log.Event(nil, "Link check configuration", log.Data{
+		"master":         args.GetMasterDir(),
+		"collection":     args.GetCollectionName(),
+		"format":         args.GetFormat(),
+		"index":          args.GetIndexFile(),
+		"fixes":          args.GetFixesFile(),
+		"fix_collection": args.GetFixCollection(),
+	})
+
+	if err := checkLinks(args); err != nil {
+		logAndExit(err)
+	}
+}
+
+func checkLinks(args *config.Args) error {
+	r := &resolver{
+		masterDir: args.GetMasterDir(),
+		ignore:    args.GetIgnore(),
+		cache:     make(map[string]bool),
+	}
+
+	if args.GetCollectionName() != "" {
+		col, err := collections.GetCollection(args.GetCollectionsDir(), args.GetCollectionName())
+		if err != nil {
+			return err
+		}
+		if col == nil {
+			return errs.New("collection not found", nil, log.Data{"collection": args.GetCollectionName()})
+		}
+		r.collection = col
+	}
+
+	pages, err := getPageLinks(args)
+	if err != nil {
+		return err
+	}
+
+	reports := make([]*pageReport, 0)
+	totalBroken := 0
+	for page, links := range pages {
+		broken := make([]string, 0)
+		for _, l := range links {
+			if !r.resolves(l) {
+				broken = append(broken, l)
+			}
+		}
+
+		if len(broken) > 0 {
+			reports = append(reports, &pageReport{Page: page, BrokenLinks: broken})
+			totalBroken += len(broken)
+		}
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Page < reports[j].Page
+	})
+
+	if err := writeReport(args, reports); err != nil {
+		return err
+	}
+
+	log.Event(nil, "link check completed", log.Data{
+		"pages_checked":          len(pages),
+		"pages_with_broken_link": len(reports),
+		"broken_links":           totalBroken,
+	})
+
+	if args.GetFixesFile() != "" {
+		return applyFixes(args, reports)
+	}
+	return nil
+}
+
+// getPageLinks returns the links of every page in master keyed by the master relative path of the page.
+func getPageLinks(args *config.Args) (map[string][]string, error) {
+	pages := make(map[string][]string)
+
+	if args.GetIndexFile() != "" {
+		idx, err := linkindex.Open(args.GetIndexFile(), args.GetMasterDir())
+		if err != nil {
+			return nil, err
+		}
+
+		for page, entry := range idx.Files {
+			pages[page] = entry.Links
+		}
+		return pages, nil
+	}
+
+	log.Event(nil, "reading links from every page in master", log.Data{"master": args.GetMasterDir()})
+	err := filepath.Walk(args.GetMasterDir(), func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || filepath.Ext(p) != ".json" {
+			return nil
+		}
+
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(args.GetMasterDir(), p)
+		page := path.Join("/", filepath.ToSlash(rel))
+
+		links, err := collections.ExtractLinks(b)
+		if err != nil {
+			log.Event(nil, "skipping page as links could not be read", log.Error(err), log.Data{"page": page})
+			return nil
+		}
+		pages[page] = links
+		return nil
+	})
+	return pages, err
+}
+
+// resolves returns true if the link is to content in master, content in the collection or is an ignored uri.
+func (r *resolver) resolves(link string) bool {
+	if strings.HasPrefix(link, "mailto:") || link == "/" {
+		return true
+	}
+
+	for _, prefix := range r.ignore {
+		if link == prefix || strings.HasPrefix(link, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+
+	if resolved, ok := r.cache[link]; ok {
+		return resolved
+	}
+
+	resolved := isContent(path.Join(r.masterDir, link))
+	if !resolved && r.collection != nil {
+		resolved = r.collection.Contains(path.Join(link, "data.json")) || r.collection.Contains(link)
+	}
+
+	r.cache[link] = resolved
+	return resolved
+}
+
+// isContent returns true if p is a page (a dir with a data.json) or a file.
+func isContent(p string) bool {
+	if collections.Exists(path.Join(p, "data.json")) {
+		return true
+	}
+
+	info, err := os.Stat(p)
+	return err == nil && !info.IsDir()
+}
+
+func writeReport(args *config.Args, reports []*pageReport) error {
+	var w io.Writer = os.Stdout
+	if args.GetOut() != "" {
+		f, err := os.Create(args.GetOut())
+		if err != nil {
+			return errs.New("failed to create report file", err, log.Data{"out": args.GetOut()})
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if args.GetFormat() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+
+	csvW := csv.NewWriter(w)
+	if err := csvW.Write([]string{"page", "broken_link"}); err != nil {
+		return err
+	}
+
+	for _, r := range reports {
+		for _, l := range r.BrokenLinks {
+			if err := csvW.Write([]string{r.Page, l}); err != nil {
+				return err
+			}
+		}
+	}
+	csvW.Flush()
+	return csvW.Error()
+}
+
+// applyFixes rewrites the broken links with a replacement in the fixes file and adds each fixed page to the fix
+// collection. Pages already in another collection are not fixed.
+func applyFixes(args *config.Args, reports []*pageReport) error {
+	fixes, err := loadFixes(args.GetFixesFile())
+	if err != nil {
+		return err
+	}
+
+	// record every change so the run can be reverted.
+	collections.StartJournal(args.GetCollectionsDir(), "linkcheck")
+
+	if args.CreateCollection() {
+		if err := collections.Save(collections.New(args.GetCollectionsDir(), args.GetFixCollection())); err != nil {
+			return err
+		}
+	}
+
+	cols, err := collections.GetCollections(args.GetCollectionsDir())
+	if err != nil {
+		return err
+	}
+
+	fixCollection, err := cols.GetByName(args.GetFixCollection())
+	if err != nil {
+		return err
+	}
+
+	fixed := make([]collections.LinkFix, 0)
+	blocked := make(map[string][]string)
+	for _, r := range reports {
+		b, err := ioutil.ReadFile(path.Join(args.GetMasterDir(), r.Page))
+		if err != nil {
+			return err
+		}
+
+		pageFixes := make([]collections.LinkFix, 0)
+		for _, l := range r.BrokenLinks {
+			to, ok := replacement(fixes, l)
+			if !ok {
+				continue
+			}
+
+			// only the broken link is rewritten, links to the uris beneath it may not be broken.
+			var changed []collections.LinkFix
+			if b, changed, err = collections.RewriteLinksExact(r.Page, b, l, to); err != nil {
+				return err
+			}
+			pageFixes = append(pageFixes, changed...)
+		}
+
+		if len(pageFixes) == 0 {
+			continue
+		}
+
+		for _, c := range collections.GetCollectionsContaining(r.Page, cols) {
+			if c.Name != fixCollection.Name {
+				blocked[r.Page] = append(blocked[r.Page], c.Name)
+			}
+		}
+
+		if len(blocked[r.Page]) > 0 {
+			continue
+		}
+
+		if err := fixCollection.AddContent(r.Page, b); err != nil {
+			return err
+		}
+		fixed = append(fixed, pageFixes...)
+	}
+
+	log.Event(nil, "link fixes completed", log.Data{
+		"collection":            fixCollection.Name,
+		"fields_changed":        fixed,
+		"blocked_by_collection": blocked,
+	})
+	return nil
+}
+
+// fix is a row of the fixes file. A fix replaces links to the old uri, and if prefix is set links to the uris beneath
+// it as well.
+type fix struct {
+	old    string
+	new    string
+	prefix bool
+}
+
+// replacement returns the uri to replace the broken link with, an exact fix is used before the longest prefix fix.
+func replacement(fixes []fix, link string) (string, bool) {
+	var match *fix
+	for i, f := range fixes {
+		if f.old == link {
+			return f.new, true
+		}
+
+		if f.prefix && strings.HasPrefix(link, f.old+"/") && (match == nil || len(f.old) > len(match.old)) {
+			match = &fixes[i]
+		}
+	}
+
+	if match == nil {
+		return "", false
+	}
+	return collections.RewriteURI(link, match.old, match.new), true
+}
+
+// loadFixes reads a csv file with an old,new header row. An optional third match column of prefix makes the row fix
+// the uris beneath old as well, exact (the default) only fixes old itself.
+func loadFixes(filename string) ([]fix, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errs.New("failed to open fixes file", err, log.Data{"fixes": filename})
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, errs.New("failed to read fixes file", err, log.Data{"fixes": filename})
+	}
+
+	fixes := make([]fix, 0)
+	for i, row := range rows {
+		if len(row) != 2 && len(row) != 3 {
+			return nil, errs.New("fixes file row should have 2 or 3 columns: old,new,match", nil, log.Data{"fixes": filename, "row": i})
+		}
+
+		// skip the header.
+		if i == 0 && row[0] == "old" {
+			continue
+		}
+
+		old, ok := collections.NormaliseLink(row[0])
+		if !ok {
+			return nil, errs.New("fixes file row old value is not an internal uri", nil, log.Data{"fixes": filename, "row": i})
+		}
+
+		prefix := false
+		if len(row) == 3 {
+			switch strings.ToLower(strings.TrimSpace(row[2])) {
+			case "", "exact":
+			case "prefix":
+				prefix = true
+			default:
+				return nil, errs.New("fixes file row match value should be exact or prefix", nil, log.Data{"fixes": filename, "row": i, "match": row[2]})
+			}
+		}
+		fixes = append(fixes, fix{old: old, new: strings.TrimSpace(row[1]), prefix: prefix})
+	}
+	return fixes, nil
+}
+
+func logAndExit(err error) {
+	if colErr, ok := err.(errs.Error); ok {
+		if colErr.OriginalErr != nil {
+			log.Event(nil, colErr.Message, log.Error(colErr.OriginalErr), colErr.Data)
+		} else {
+			log.Event(nil, colErr.Message, colErr.Data)
+		}
+	} else {
+		log.Event(nil, "unknown error", log.Error(err))
+	}
+	os.Exit(1)
+}
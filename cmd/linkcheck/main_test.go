@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestLoadFixes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkcheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := path.Join(dir, "fixes.csv")
+	csv := "old,new,match\n/a/b,/x\n/c/,/y,exact\n/c/d,/z,prefix\n"
+	if err := ioutil.WriteFile(filename, []byte(csv), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fixes, err := loadFixes(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []fix{{old: "/a/b", new: "/x"}, {old: "/c", new: "/y"}, {old: "/c/d", new: "/z", prefix: true}}
+	if len(fixes) != len(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, fixes)
+	}
+	for i := range expected {
+		if fixes[i] != expected[i] {
+			t.Errorf("row %d: expected %+v, got %+v", i, expected[i], fixes[i])
+		}
+	}
+
+	if err := ioutil.WriteFile(filename, []byte("/a,/b,children\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadFixes(filename); err == nil {
+		t.Error("expected an unknown match value to be rejected")
+	}
+}
+
+func TestReplacement(t *testing.T) {
+	fixes := []fix{
+		{old: "/a", new: "/x"},
+		{old: "/b", new: "/y", prefix: true},
+		{old: "/b/c", new: "/z", prefix: true},
+	}
+
+	cases := map[string]string{
+		"/a":       "/x",
+		"/a/child": "",
+		"/b":       "/y",
+		"/b/d":     "/y/d",
+		"/b/c/e":   "/z/e",
+		"/bc":      "",
+	}
+
+	for link, expected := range cases {
+		to, ok := replacement(fixes, link)
+		if expected == "" {
+			if ok {
+				t.Errorf("%s: expected no replacement, got %s", link, to)
+			}
+			continue
+		}
+
+		if !ok || to != expected {
+			t.Errorf("%s: expected %s, got %s", link, expected, to)
+		}
+	}
+}
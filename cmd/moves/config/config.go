@@ -13,6 +13,10 @@ type Args struct {
 	src            string
 	dest           string
 	create         bool
+	manifest       string
+	planFile       string
+	applyFile      string
+	indexFile      string
 }
 
 func (a *Args) GetCollectionsDir() string {
@@ -47,18 +51,65 @@ func (a *Args) CreateCollection() bool {
 	return a.create
 }
 
+func (a *Args) GetManifest() string {
+	return a.manifest
+}
+
+// GetPlanFile returns the file to write the move plan to, if set the moves are planned but not applied.
+func (a *Args) GetPlanFile() string {
+	return a.planFile
+}
+
+// GetApplyFile returns the plan file to apply, if set the moves are read from the plan rather than the other flags.
+func (a *Args) GetApplyFile() string {
+	return a.applyFile
+}
+
+// GetIndexFile returns the link index file to use to find the pages affected by the moves, if not set master is scanned.
+func (a *Args) GetIndexFile() string {
+	return a.indexFile
+}
+
 func GetArgs() (*Args, error) {
 	zebRoot := flag.String("zeb_root", "", "The root zebedee directory")
 	collectionName := flag.String("collection", "", "The name of the collection to use")
 	create := flag.Bool("create", false, "True flag to create a collection, false to load the collection specified")
 	src := flag.String("src", "", "The source taxonomy uri of the content to move")
 	dest := flag.String("dest", "", "The destination taxonomy uri to move the content to")
+	manifest := flag.String("manifest", "", "A .json or .csv manifest of src/dest(/collection) moves to apply instead of src and dest")
+	planFile := flag.String("plan", "", "Write the outcome of the moves to this plan file without changing anything on disk")
+	applyFile := flag.String("apply", "", "Apply the moves in this plan file, refused if master has changed since the plan was made")
+	indexFile := flag.String("index", "", "A link index file to use instead of scanning master, created if it does not exist")
 	flag.Parse()
 
 	if *zebRoot == "" {
 		return nil, errs.New("missing flag", nil, log.Data{"var": "zeb_root"})
 	}
 
+	if *applyFile != "" {
+		if *planFile != "" {
+			return nil, errs.New("plan and apply flags cannot be used together", nil, nil)
+		}
+
+		// everything else is provided by the plan.
+		return &Args{
+			zebRoot:   *zebRoot,
+			applyFile: *applyFile,
+		}, nil
+	}
+
+	if *manifest != "" {
+		// src, dest and collection are provided by the manifest.
+		return &Args{
+			zebRoot:        *zebRoot,
+			collectionName: *collectionName,
+			create:         *create,
+			manifest:       *manifest,
+			planFile:       *planFile,
+			indexFile:      *indexFile,
+		}, nil
+	}
+
 	if *collectionName == "" {
 		return nil, errs.New("missing flag", nil, log.Data{"var": "collection"})
 	}
@@ -77,5 +128,7 @@ func GetArgs() (*Args, error) {
 		src:            *src,
 		dest:           *dest,
 		create:         *create,
+		planFile:       *planFile,
+		indexFile:      *indexFile,
 	}, nil
 }
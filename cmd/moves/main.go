@@ -2,13 +2,25 @@ package main
 
 import (
 	"github.com/ONSdigital/dp-zebedee-utils/cmd/moves/config"
+	"github.com/ONSdigital/dp-zebedee-utils/cmd/moves/manifest"
 	"github.com/ONSdigital/dp-zebedee-utils/collections"
 	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/dp-zebedee-utils/linkindex"
 	"github.com/ONSdigital/log.go/log"
 	"os"
+	"path"
 	"path/filepath"
 )
 
+type moveReport struct {
+	Src           string                `json:"src"`
+	Dest          string                `json:"dest"`
+	Collection    string                `json:"collection"`
+	MovedContent  map[string]string     `json:"moved_content"`
+	LinkFixes     []string              `json:"link_fixes"`
+	FieldsChanged []collections.LinkFix `json:"fields_changed"`
+}
+
 func main() {
 	log.Namespace = "content-mover"
 
@@ -17,84 +29,253 @@ func main() {
 		logAndExit(err)
 	}
 
+	if args.GetApplyFile() != "" {
+		if err := applyPlan(args); err != nil {
+			logAndExit(err)
+		}
+		return
+	}
+
+	moves, err := getMoves(args)
+	if err != nil {
+		logAndExit(err)
+	}
+
 	log.Event(nil, "Content move configuration", log.Data{
-		"src":        args.GetRelSrc(),
-		"create":     args.CreateCollection(),
-		"dest":       args.GetDest(),
-		"collection": args.GetCollectionName(),
+		"manifest": args.GetManifest(),
+		"create":   args.CreateCollection(),
+		"plan":     args.GetPlanFile(),
+		"moves":    moves,
 	})
 
+	if args.GetPlanFile() != "" {
+		if err := writePlan(args, moves); err != nil {
+			logAndExit(err)
+		}
+		return
+	}
+
+	// record every change so the run can be reverted.
+	collections.StartJournal(args.GetCollectionsDir(), "moves")
+
 	if args.CreateCollection() {
-		col := collections.New(args.GetCollectionsDir(), args.GetCollectionName())
-		if err := collections.Save(col); err != nil {
+		if err := createCollections(args, moves); err != nil {
 			logAndExit(err)
 		}
 	}
 
-	if err := doMove(args); err != nil {
+	if err := doMoves(args, moves); err != nil {
 		logAndExit(err)
 	}
 }
 
-func doMove(args *config.Args) error {
-	plan := collections.ContentMove{
-		MovingFromAbs: args.GetAbsSrc(),
-		MovingFromRel: args.GetRelSrc(),
-		MovingToRel:   args.GetDest(),
-		MasterDir:     args.GetMasterDir(),
+// getMoves returns the moves from the manifest if one was provided otherwise the single move specified by the src and
+// dest flags.
+func getMoves(args *config.Args) ([]manifest.Move, error) {
+	if args.GetManifest() != "" {
+		return manifest.Load(args.GetManifest(), args.GetCollectionName())
+	}
+
+	return []manifest.Move{{
+		Src:        args.GetRelSrc(),
+		Dest:       args.GetDest(),
+		Collection: args.GetCollectionName(),
+	}}, nil
+}
+
+// getUsesFinder returns a finder querying the link index if one was provided otherwise one that scans master.
+func getUsesFinder(args *config.Args) (collections.UsesFinder, error) {
+	if args.GetIndexFile() == "" {
+		return collections.FindUsesOfAllUris, nil
 	}
 
-	// find all the pages in master that contain the uri being moved.
-	pagesContainingURI, err := collections.FindUsesOfUris(plan)
+	idx, err := linkindex.Open(args.GetIndexFile(), args.GetMasterDir())
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return idx.FindUses, nil
+}
+
+func createCollections(args *config.Args, moves []manifest.Move) error {
+	created := make(map[string]bool)
+	for _, m := range moves {
+		if created[m.Collection] {
+			continue
+		}
+
+		col := collections.New(args.GetCollectionsDir(), m.Collection)
+		if err := collections.Save(col); err != nil {
+			return err
+		}
+		created[m.Collection] = true
 	}
+	return nil
+}
 
+func doMoves(args *config.Args, moves []manifest.Move) error {
 	// load the existing collections.
 	cols, err := collections.GetCollections(args.GetCollectionsDir())
 	if err != nil {
 		return err
 	}
 
-	plan.Collection, err = cols.GetByName(args.GetCollectionName())
+	plans := make([]collections.ContentMove, 0)
+	for _, m := range moves {
+		col, err := cols.GetByName(m.Collection)
+		if err != nil {
+			return err
+		}
+
+		plans = append(plans, collections.ContentMove{
+			Collection:    col,
+			MovingFromAbs: path.Join(args.GetMasterDir(), m.Src),
+			MovingFromRel: m.Src,
+			MovingToRel:   m.Dest,
+			MasterDir:     args.GetMasterDir(),
+		})
+	}
+
+	if err := collections.CheckMoves(plans); err != nil {
+		return err
+	}
+
+	findUses, err := getUsesFinder(args)
 	if err != nil {
 		return err
 	}
 
+	// find all the pages in master that contain the uris being moved.
+	pagesContainingURI, err := findUses(args.GetMasterDir(), plans)
+	if err != nil {
+		return err
+	}
+
+	if err := collections.CheckAffectedFiles(plans, pagesContainingURI); err != nil {
+		return err
+	}
+
 	// check that none of the affected files are in another collection
-	for _, usage := range pagesContainingURI {
-		relURI, err := filepath.Rel(plan.MasterDir, usage)
+	for _, plan := range plans {
+		for _, usage := range pagesContainingURI[plan.MovingFromRel] {
+			relURI, err := filepath.Rel(plan.MasterDir, usage)
+			if err != nil {
+				return err
+			}
+
+			blocking := make([]string, 0)
+			for _, c := range collections.GetCollectionsContaining(relURI, cols) {
+				if c.Name != plan.Collection.Name {
+					blocking = append(blocking, c.Name)
+				}
+			}
+
+			if len(blocking) > 0 {
+				return errs.New("cannot proceed with move as affected uri is contained in another collection", nil, log.Data{"collections": blocking, "uri": relURI})
+			}
+		}
+	}
+
+	// do the moves.
+	reports := make([]*moveReport, 0)
+	allMovedUris := make(map[string]string)
+	for _, plan := range plans {
+		movedUris, movedLinkFixes, err := collections.MoveContent(plan)
 		if err != nil {
 			return err
 		}
 
-		blockingCollection := collections.GetCollectionContaining(relURI, cols)
-		if blockingCollection != nil && blockingCollection.Name != plan.Collection.Name {
-			return errs.New("cannot proceed with move as affected uri is contained in another collection", nil, log.Data{"collection": blockingCollection, "uri": relURI})
+		for src, dest := range movedUris {
+			allMovedUris[src] = dest
+		}
+
+		reports = append(reports, &moveReport{
+			Src:           plan.MovingFromRel,
+			Dest:          plan.MovingToRel,
+			Collection:    plan.Collection.Name,
+			MovedContent:  movedUris,
+			FieldsChanged: movedLinkFixes,
+		})
+	}
+
+	// fix the links once everything has moved so pages linking to more than one moved uri get every fix.
+	totalFixes := 0
+	for i, plan := range plans {
+		fixedLinks, linkFixes, err := collections.FixUris(plan, pagesContainingURI[plan.MovingFromRel], allMovedUris)
+		if err != nil {
+			return err
 		}
+
+		reports[i].LinkFixes = fixedLinks
+		reports[i].FieldsChanged = append(reports[i].FieldsChanged, linkFixes...)
+		totalFixes += len(fixedLinks)
 	}
 
-	// do the move.
-	movedUris, err := collections.MoveContent(plan)
+	log.Event(nil, "content move completed successfully", log.Data{
+		"moves":               reports,
+		"total_moves":         len(reports),
+		"total_moved_content": len(allMovedUris),
+		"total_link_fixes":    totalFixes,
+	})
+	return nil
+}
+
+// writePlan works out the outcome of the moves and writes it to the plan file without changing the zebedee root.
+func writePlan(args *config.Args, moves []manifest.Move) error {
+	planned := make([]collections.PlannedMove, 0)
+	for _, m := range moves {
+		planned = append(planned, collections.PlannedMove{Src: m.Src, Dest: m.Dest, Collection: m.Collection})
+	}
+
+	findUses, err := getUsesFinder(args)
 	if err != nil {
 		return err
 	}
 
-	fixedLinks, err := collections.FixUris(plan, pagesContainingURI, movedUris)
+	plan, err := collections.PlanMoves(args.GetMasterDir(), args.GetCollectionsDir(), planned, args.CreateCollection(), findUses)
 	if err != nil {
 		return err
 	}
 
-	log.Event(nil, "content move completed successfully", log.Data{
-		"collection":    args.GetCollectionName(),
-		"move_src":      args.GetRelSrc(),
-		"move_dest":     args.GetDest(),
-		"moved_content": movedUris,
-		"link_fixes":    fixedLinks,
+	if err := collections.SavePlan(plan, args.GetPlanFile()); err != nil {
+		return errs.New("failed to write plan file", err, log.Data{"plan": args.GetPlanFile()})
+	}
+
+	log.Event(nil, "content move plan written successfully", log.Data{
+		"plan":                 args.GetPlanFile(),
+		"new_collections":      plan.NewCollections,
+		"copies":               len(plan.Copies),
+		"link_fixes":           len(plan.LinkFixes),
+		"blocking_collections": plan.BlockingCollections,
+		"collisions":           plan.Collisions,
+		"can_apply":            !plan.IsBlocked(),
 	})
 	return nil
 }
 
+// applyPlan executes the moves in a previously written plan file.
+func applyPlan(args *config.Args) error {
+	plan, err := collections.LoadPlan(args.GetApplyFile())
+	if err != nil {
+		return err
+	}
+
+	if plan.MasterDir != args.GetMasterDir() || plan.CollectionsDir != args.GetCollectionsDir() {
+		return errs.New("cannot apply plan as it was made for a different zebedee root", nil, log.Data{
+			"plan_master": plan.MasterDir,
+			"master":      args.GetMasterDir(),
+		})
+	}
+
+	collections.StartJournal(args.GetCollectionsDir(), "moves")
+
+	log.Event(nil, "applying content move plan", log.Data{
+		"plan":       args.GetApplyFile(),
+		"created_at": plan.CreatedAt,
+		"moves":      plan.Moves,
+	})
+	return collections.ApplyPlan(plan)
+}
+
 func logAndExit(err error) {
 	if colErr, ok := err.(errs.Error); ok {
 		if colErr.OriginalErr != nil {
@@ -0,0 +1,122 @@
+package manifest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Move is a single src -> dest row of a move manifest. Collection is optional and defaults to the collection flag.
+type Move struct {
+	Src        string `json:"src"`
+	Dest       string `json:"dest"`
+	Collection string `json:"collection"`
+}
+
+// Load reads a .json or .csv move manifest. A JSON manifest is an array of Move objects, a CSV manifest must have a
+// header row containing src, dest and optionally collection columns.
+func Load(filename string, defaultCollection string) ([]Move, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errs.New("failed to open move manifest", err, log.Data{"manifest": filename})
+	}
+	defer f.Close()
+
+	var moves []Move
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		moves, err = readJSON(f)
+	case ".csv":
+		moves, err = readCSV(f)
+	default:
+		return nil, errs.New("unsupported move manifest format, expected .json or .csv", nil, log.Data{"manifest": filename})
+	}
+	if err != nil {
+		return nil, errs.New("failed to read move manifest", err, log.Data{"manifest": filename})
+	}
+
+	for i, m := range moves {
+		if m.Collection == "" {
+			moves[i].Collection = defaultCollection
+		}
+		if err := moves[i].validate(i); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(moves) == 0 {
+		return nil, errs.New("move manifest is empty", nil, log.Data{"manifest": filename})
+	}
+	return moves, nil
+}
+
+func (m Move) validate(row int) error {
+	data := log.Data{"row": row, "src": m.Src, "dest": m.Dest}
+	if m.Src == "" {
+		return errs.New("move manifest row missing src", nil, data)
+	}
+	if m.Dest == "" {
+		return errs.New("move manifest row missing dest", nil, data)
+	}
+	if m.Collection == "" {
+		return errs.New("move manifest row missing collection and no collection flag provided", nil, data)
+	}
+	return nil
+}
+
+func readJSON(r io.Reader) ([]Move, error) {
+	var moves []Move
+	if err := json.NewDecoder(r).Decode(&moves); err != nil {
+		return nil, err
+	}
+	return moves, nil
+}
+
+func readCSV(r io.Reader) ([]Move, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return []Move{}, nil
+	}
+
+	cols := make(map[string]int)
+	for i, name := range rows[0] {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"src", "dest"} {
+		if _, ok := cols[name]; !ok {
+			return nil, errs.New("move manifest csv header missing column", nil, log.Data{"column": name})
+		}
+	}
+
+	get := func(row []string, name string) string {
+		i, ok := cols[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	moves := make([]Move, 0)
+	for _, row := range rows[1:] {
+		moves = append(moves, Move{
+			Src:        get(row, "src"),
+			Dest:       get(row, "dest"),
+			Collection: get(row, "collection"),
+		})
+	}
+	return moves, nil
+}
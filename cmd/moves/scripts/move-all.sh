@@ -0,0 +1,11 @@
+#!/usr/bin/env bash
+
+export HUMAN_LOG="true"
+
+go build -o moves
+
+ECHO "executing moves 1 - 3 from manifest...."
+
+../moves -zeb_root="/zebe-test" \
+    -create=true \
+	-manifest="manifest.csv"
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"os"
+	"path"
+)
+
+func main() {
+	log.Namespace = "local-publish"
+
+	zebRoot := flag.String("zeb_root", "", "The root zebedee directory")
+	collectionName := flag.String("collection", "", "The name of the collection to publish")
+	approve := flag.Bool("approve", false, "Approve the collection before publishing if its approval has not started")
+	flag.Parse()
+
+	if *zebRoot == "" {
+		logAndExit(errs.New("missing flag", nil, log.Data{"var": "zeb_root"}))
+	}
+
+	if *collectionName == "" {
+		logAndExit(errs.New("missing flag", nil, log.Data{"var": "collection"}))
+	}
+
+	col, err := collections.GetCollection(path.Join(*zebRoot, "collections"), *collectionName)
+	if err != nil {
+		logAndExit(err)
+	}
+
+	if col == nil {
+		logAndExit(errs.New("collection not found", nil, log.Data{"collection": *collectionName}))
+	}
+
+	// the approval is recorded in the publish-log entry, the collection is left unapproved if the publish fails.
+	if *approve && col.ApprovalStatus == collections.ApprovalNotStarted {
+		col.ApprovalStatus = collections.ApprovalComplete
+		log.Event(nil, "approved collection", log.Data{"collection": col.Name})
+	}
+
+	published, err := collections.Publish(col, path.Join(*zebRoot, "master"), path.Join(*zebRoot, "publish-log"))
+	if err != nil {
+		logAndExit(err)
+	}
+
+	log.Event(nil, "local publish completed", log.Data{
+		"collection":  col.Name,
+		"publish_log": collections.PublishLogName(published.PublishDate, col.Name),
+		"uris":        len(published.PublishResults[0].Transaction.URIInfos),
+	})
+}
+
+func logAndExit(err error) {
+	if colErr, ok := err.(errs.Error); ok {
+		if colErr.OriginalErr != nil {
+			log.Event(nil, colErr.Message, log.Error(colErr.OriginalErr), colErr.Data)
+		} else {
+			log.Event(nil, colErr.Message, colErr.Data)
+		}
+	} else {
+		log.Event(nil, "unknown error", log.Error(err))
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/dp-zebedee-utils/publishlog"
+	"github.com/ONSdigital/log.go/log"
+	"os"
+	"path"
+	"time"
+)
+
+func main() {
+	log.Namespace = "publish-log"
+
+	zebRoot := flag.String("zeb_root", "", "The root zebedee directory")
+	uri := flag.String("uri", "", "Find when this uri was last published and by which collection")
+	all := flag.Bool("all", false, "With uri, list every publish of the uri rather than just the last")
+	from := flag.String("from", "", "Only include publishes on or after this date (2006-01-02 or RFC3339)")
+	to := flag.String("to", "", "Only include publishes before this date (2006-01-02 or RFC3339)")
+	format := flag.String("format", "text", "The output format: text, json or csv")
+	flag.Parse()
+
+	if *zebRoot == "" {
+		logAndExit(errs.New("missing flag", nil, log.Data{"var": "zeb_root"}))
+	}
+
+	if *format != "text" && *format != "json" && *format != "csv" {
+		logAndExit(errs.New("invalid flag value expected text, json or csv", nil, log.Data{"var": "format", "value": *format}))
+	}
+
+	fromDate, err := parseDateFlag("from", *from)
+	if err != nil {
+		logAndExit(err)
+	}
+
+	toDate, err := parseDateFlag("to", *to)
+	if err != nil {
+		logAndExit(err)
+	}
+
+	records, err := publishlog.Read(path.Join(*zebRoot, "publish-log"))
+	if err != nil {
+		logAndExit(err)
+	}
+
+	records = publishlog.Between(records, fromDate, toDate)
+
+	if *uri != "" {
+		if *all {
+			records = publishlog.PublishedBy(records, *uri)
+		} else if last := publishlog.LastPublished(records, *uri); last != nil {
+			records = []*publishlog.Record{last}
+		} else {
+			records = []*publishlog.Record{}
+		}
+	}
+
+	if err := write(records, *format); err != nil {
+		logAndExit(err)
+	}
+}
+
+func parseDateFlag(name string, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := publishlog.ParseDate(value)
+	if err != nil {
+		return t, errs.New("invalid date flag value", err, log.Data{"var": name, "value": value})
+	}
+	return t, nil
+}
+
+func write(records []*publishlog.Record, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write([]string{"publish_date", "collection", "collection_id", "uri"}); err != nil {
+			return err
+		}
+
+		for _, r := range records {
+			for _, u := range r.URIs {
+				if err := w.Write([]string{r.PublishDate.Format(time.RFC3339), r.Collection, r.CollectionID, u}); err != nil {
+					return err
+				}
+			}
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		for _, r := range records {
+			fmt.Printf("%s\t%s\t%d uris\n", r.PublishDate.Format(time.RFC3339), r.Collection, len(r.URIs))
+		}
+		return nil
+	}
+}
+
+func logAndExit(err error) {
+	if colErr, ok := err.(errs.Error); ok {
+		if colErr.OriginalErr != nil {
+			log.Event(nil, colErr.Message, log.Error(colErr.OriginalErr), colErr.Data)
+		} else {
+			log.Event(nil, colErr.Message, colErr.Data)
+		}
+	} else {
+		log.Event(nil, "unknown error", log.Error(err))
+	}
+	os.Exit(1)
+}
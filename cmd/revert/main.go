@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"os"
+	"path"
+)
+
+func main() {
+	log.Namespace = "collection-revert"
+
+	zebRoot := flag.String("zeb_root", "", "The root zebedee directory")
+	collectionName := flag.String("collection", "", "The name of the collection to revert")
+	run := flag.String("run", "", "The id of the run to revert, defaults to the most recent run")
+	list := flag.Bool("list", false, "List the runs in the collection journal instead of reverting")
+	force := flag.Bool("force", false, "Revert even if files have been changed since the run wrote them")
+	flag.Parse()
+
+	if *zebRoot == "" {
+		logAndExit(errs.New("missing flag", nil, log.Data{"var": "zeb_root"}))
+	}
+
+	if *collectionName == "" {
+		logAndExit(errs.New("missing flag", nil, log.Data{"var": "collection"}))
+	}
+
+	collectionsDir := path.Join(*zebRoot, "collections")
+
+	if *list {
+		entries, err := collections.ReadJournal(collectionsDir, *collectionName)
+		if err != nil {
+			logAndExit(err)
+		}
+
+		for _, r := range collections.JournalRuns(entries) {
+			fmt.Printf("%s\t%s\t%s\t%d changes\n", r.Run, r.Tool, r.Started.Format("2006-01-02T15:04:05"), r.Changes)
+		}
+		return
+	}
+
+	reverted, err := collections.Revert(collectionsDir, *collectionName, *run, *force)
+	if err != nil {
+		logAndExit(err)
+	}
+
+	log.Event(nil, "collection run reverted successfully", log.Data{
+		"collection": *collectionName,
+		"run":        reverted.Run,
+		"tool":       reverted.Tool,
+		"started":    reverted.Started,
+		"changes":    reverted.Changes,
+	})
+}
+
+func logAndExit(err error) {
+	if colErr, ok := err.(errs.Error); ok {
+		if colErr.OriginalErr != nil {
+			log.Event(nil, colErr.Message, log.Error(colErr.OriginalErr), colErr.Data)
+		} else {
+			log.Event(nil, colErr.Message, colErr.Data)
+		}
+	} else {
+		log.Event(nil, "unknown error", log.Error(err))
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"flag"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"path"
+)
+
+type Args struct {
+	zebRoot        string
+	collectionName string
+	file           string
+	format         string
+	out            string
+}
+
+func (a *Args) GetCollectionsDir() string {
+	return path.Join(a.zebRoot, "collections")
+}
+
+func (a *Args) GetMasterDir() string {
+	return path.Join(a.zebRoot, "master")
+}
+
+// GetCollectionName returns the collection to validate, if empty master is validated.
+func (a *Args) GetCollectionName() string {
+	return a.collectionName
+}
+
+// GetFile returns the single page file to validate.
+func (a *Args) GetFile() string {
+	return a.file
+}
+
+func (a *Args) GetFormat() string {
+	return a.format
+}
+
+// GetOut returns the report output file, if empty the report is written to stdout.
+func (a *Args) GetOut() string {
+	return a.out
+}
+
+func GetArgs() (*Args, error) {
+	zebRoot := flag.String("zeb_root", "", "The root zebedee directory")
+	collectionName := flag.String("collection", "", "Optional collection to validate instead of master")
+	file := flag.String("file", "", "Optional single page file to validate instead of master")
+	format := flag.String("format", "csv", "The report format: csv or json")
+	out := flag.String("out", "", "The file to write the report to, defaults to stdout")
+	flag.Parse()
+
+	if *zebRoot == "" {
+		return nil, errs.New("missing flag", nil, log.Data{"var": "zeb_root"})
+	}
+
+	if *collectionName != "" && *file != "" {
+		return nil, errs.New("only one of collection or file can be specified", nil, log.Data{"collection": *collectionName, "file": *file})
+	}
+
+	if *format != "csv" && *format != "json" {
+		return nil, errs.New("invalid flag value expected csv or json", nil, log.Data{"var": "format", "value": *format})
+	}
+
+	return &Args{
+		zebRoot:        *zebRoot,
+		collectionName: *collectionName,
+		file:           *file,
+		format:         *format,
+		out:            *out,
+	}, nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/ONSdigital/dp-zebedee-utils/cmd/validate/config"
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/dp-zebedee-utils/pages"
+	"github.com/ONSdigital/log.go/log"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// page files validated, data_cy.json is the welsh version of a page.
+var pageFiles = map[string]bool{
+	"data.json":    true,
+	"data_cy.json": true,
+}
+
+func main() {
+	log.Namespace = "page-validator"
+
+	args, err := config.GetArgs()
+	if err != nil {
+		logAndExit(err)
+	}
+
+	log.Event(nil, "Page validation configuration", log.Data{
+		"master":     args.GetMasterDir(),
+		"collection": args.GetCollectionName(),
+		"file":       args.GetFile(),
+		"format":     args.GetFormat(),
+	})
+
+	problems, err := validate(args)
+	if err != nil {
+		logAndExit(err)
+	}
+
+	if err := writeReport(args, problems); err != nil {
+		logAndExit(err)
+	}
+
+	errors := 0
+	for _, p := range problems {
+		if p.Severity == pages.SeverityError {
+			errors++
+		}
+	}
+
+	log.Event(nil, "page validation completed", log.Data{
+		"errors":   errors,
+		"warnings": len(problems) - errors,
+	})
+
+	if errors > 0 {
+		os.Exit(1)
+	}
+}
+
+func validate(args *config.Args) ([]pages.Problem, error) {
+	if args.GetFile() != "" {
+		return validateFile(args)
+	}
+
+	if args.GetCollectionName() == "" {
+		v := pages.NewValidator(pages.DirResolver(args.GetMasterDir()))
+		return validateDirs(v, args.GetMasterDir())
+	}
+
+	col, err := collections.GetCollection(args.GetCollectionsDir(), args.GetCollectionName())
+	if err != nil {
+		return nil, err
+	}
+	if col == nil {
+		return nil, errs.New("collection not found", nil, log.Data{"collection": args.GetCollectionName()})
+	}
+
+	v := pages.NewValidator(collectionResolver(col, args.GetMasterDir()))
+	return validateDirs(v, col.GetInProgress(), col.GetComplete(), col.GetReviewed())
+}
+
+// collectionResolver resolves links against the collection content first, as Zebedee does, then master.
+func collectionResolver(col *collections.Collection, masterDir string) pages.TypeResolver {
+	return pages.DirResolver(col.GetInProgress(), col.GetComplete(), col.GetReviewed(), masterDir)
+}
+
+// validateDirs validates every page in the content dirs. Previous versions of pages are not validated.
+func validateDirs(v *pages.Validator, dirs ...string) ([]pages.Problem, error) {
+	problems := make([]pages.Problem, 0)
+	for _, dir := range dirs {
+		if !collections.Exists(dir) {
+			continue
+		}
+
+		log.Event(nil, "validating pages", log.Data{"dir": dir})
+		err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() && info.Name() == "previous" {
+				return filepath.SkipDir
+			}
+
+			if info.IsDir() || !pageFiles[info.Name()] {
+				return nil
+			}
+
+			b, err := ioutil.ReadFile(p)
+			if err != nil {
+				return err
+			}
+
+			rel, _ := filepath.Rel(dir, filepath.Dir(p))
+			problems = append(problems, v.Validate(path.Join("/", filepath.ToSlash(rel)), b)...)
+			return nil
+		})
+		if err != nil {
+			return nil, errs.New("failed to validate pages", err, log.Data{"dir": dir})
+		}
+	}
+	return problems, nil
+}
+
+// validateFile validates a single page. If the file is in master or a collection its uri is checked against its
+// location and links are resolved against the same content Zebedee would use.
+func validateFile(args *config.Args) ([]pages.Problem, error) {
+	filename, err := filepath.Abs(args.GetFile())
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errs.New("failed to read page file", err, log.Data{"file": filename})
+	}
+
+	location := ""
+	resolve := pages.DirResolver(args.GetMasterDir())
+
+	masterDir, _ := filepath.Abs(args.GetMasterDir())
+	collectionsDir, _ := filepath.Abs(args.GetCollectionsDir())
+
+	if rel, ok := relativeTo(masterDir, filename); ok {
+		location = path.Dir(rel)
+	} else if rel, ok := relativeTo(collectionsDir, filename); ok {
+		// collections/<name>/<inprogress|complete|reviewed>/<uri>
+		parts := strings.SplitN(strings.TrimPrefix(rel, "/"), "/", 3)
+		if len(parts) == 3 {
+			location = path.Dir(path.Join("/", parts[2]))
+
+			col, err := collections.GetCollection(args.GetCollectionsDir(), parts[0])
+			if err != nil {
+				return nil, err
+			}
+			if col != nil {
+				resolve = collectionResolver(col, args.GetMasterDir())
+			}
+		}
+	}
+
+	return pages.NewValidator(resolve).Validate(location, b), nil
+}
+
+// relativeTo returns the slash separated path of filename relative to dir, false if it is not beneath dir.
+func relativeTo(dir string, filename string) (string, bool) {
+	rel, err := filepath.Rel(dir, filename)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return path.Join("/", filepath.ToSlash(rel)), true
+}
+
+func writeReport(args *config.Args, problems []pages.Problem) error {
+	var w io.Writer = os.Stdout
+	if args.GetOut() != "" {
+		f, err := os.Create(args.GetOut())
+		if err != nil {
+			return errs.New("failed to create report file", err, log.Data{"out": args.GetOut()})
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if args.GetFormat() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(problems)
+	}
+
+	csvW := csv.NewWriter(w)
+	if err := csvW.Write([]string{"page", "field", "severity", "message"}); err != nil {
+		return err
+	}
+
+	for _, p := range problems {
+		if err := csvW.Write([]string{p.Page, p.Field, p.Severity, p.Message}); err != nil {
+			return err
+		}
+	}
+	csvW.Flush()
+	return csvW.Error()
+}
+
+func logAndExit(err error) {
+	if colErr, ok := err.(errs.Error); ok {
+		if colErr.OriginalErr != nil {
+			log.Event(nil, colErr.Message, log.Error(colErr.OriginalErr), colErr.Data)
+		} else {
+			log.Event(nil, colErr.Message, colErr.Data)
+		}
+	} else {
+		log.Event(nil, "unknown error", log.Error(err))
+	}
+	os.Exit(1)
+}
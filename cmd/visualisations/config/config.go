@@ -2,15 +2,20 @@ package config
 
 import (
 	"flag"
+	"fmt"
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
 	"github.com/ONSdigital/dp-zebedee-utils/errs"
 	"github.com/ONSdigital/log.go/log"
 	"path"
+	"time"
 )
 
 type Args struct {
 	zebRoot        string
 	collectionName string
 	reverseChanges bool
+	rulesFile      string
+	recordFile     string
 }
 
 func (a *Args) GetCollectionsDir() string {
@@ -29,14 +34,27 @@ func (a *Args) GetZebedeeDir() string {
 	return a.zebRoot
 }
 
+// ReverseChanges returns true if the edits of a previous run are being reversed rather than the rules applied.
 func (a *Args) ReverseChanges() bool {
 	return a.reverseChanges
 }
 
+// GetRulesFile returns the script rules file, if empty the default rules are used.
+func (a *Args) GetRulesFile() string {
+	return a.rulesFile
+}
+
+// GetRecordFile returns the file the edits are recorded in, or if reversing the record of the previous run to reverse.
+func (a *Args) GetRecordFile() string {
+	return a.recordFile
+}
+
 func GetArgs() (*Args, error) {
 	zebRoot := flag.String("zeb_root", "", "The root zebedee directory")
 	collectionName := flag.String("collection", "", "The name of the collection to use")
-	reverseChanges := flag.Bool("reverse_changes", false, "True flag to create a collection, false to load the collection specified")
+	reverseChanges := flag.Bool("reverse_changes", false, "Reverse the edits in the record file of a previous run instead of applying the rules")
+	rulesFile := flag.String("rules", "", "Optional json file of script rules to use instead of the default rules")
+	recordFile := flag.String("record", "", "The file to record the edits made in, defaults to a timestamped file beside the collection. With reverse_changes the record of the run to reverse")
 	flag.Parse()
 
 	if *zebRoot == "" {
@@ -46,9 +64,33 @@ func GetArgs() (*Args, error) {
 		return nil, errs.New("missing flag", nil, log.Data{"var": "collection"})
 	}
 
-	return &Args{
+	a := &Args{
 		zebRoot:        *zebRoot,
 		collectionName: *collectionName,
 		reverseChanges: *reverseChanges,
-	}, nil
+		rulesFile:      *rulesFile,
+		recordFile:     *recordFile,
+	}
+
+	if a.ReverseChanges() {
+		if a.recordFile == "" {
+			return nil, errs.New("missing flag, the record file of the run to reverse is required with reverse_changes", nil, log.Data{"var": "record"})
+		}
+
+		if !collections.Exists(a.recordFile) {
+			return nil, errs.New("record file to reverse does not exist", nil, log.Data{"var": "record", "value": a.recordFile})
+		}
+		return a, nil
+	}
+
+	// each run gets its own record so the record of an earlier run, needed to reverse it, is never overwritten. It is
+	// not a .json file as Zebedee reads those in the collections dir as collections.
+	if a.recordFile == "" {
+		a.recordFile = path.Join(a.GetCollectionsDir(), fmt.Sprintf("%s-%s.edits", a.collectionName, time.Now().UTC().Format("20060102-150405")))
+	}
+
+	if collections.Exists(a.recordFile) {
+		return nil, errs.New("record file already exists, refusing to overwrite it", nil, log.Data{"var": "record", "value": a.recordFile})
+	}
+	return a, nil
 }
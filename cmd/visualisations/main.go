@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"github.com/ONSdigital/dp-zebedee-utils/cmd/visualisations/config"
+	"github.com/ONSdigital/dp-zebedee-utils/cmd/visualisations/tracking"
 	"github.com/ONSdigital/dp-zebedee-utils/collections"
 	"github.com/ONSdigital/dp-zebedee-utils/errs"
 	"github.com/ONSdigital/log.go/log"
@@ -11,45 +12,17 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
-var snippetReplacements = map[string]string{
-	"var ga = document.createElement('script'); ga.type = 'text/javascript'; ga.async = true;": "// var ga = document.createElement('script'); ga.type = 'text/javascript'; ga.async = true;",
-
-	"ga.src = ('https:' == document.location.protocol ? 'https://ssl' : 'http://www') + '.google-analytics.com/ga.js';": "// ga.src = ('https:' == document.location.protocol ? 'https://ssl' : 'http://www') + '.google-analytics.com/ga.js';",
-
-	"var s = document.getElementsByTagName('script')[0]; s.parentNode.insertBefore(ga, s);": "// var s = document.getElementsByTagName('script')[0]; s.parentNode.insertBefore(ga, s);",
-
-	"ga.src = ('https:' == document.location.protocol ? 'https://' : 'http://') + 'stats.g.doubleclick.net/dc.js';": "// ga.src = ('https:' == document.location.protocol ? 'https://' : 'http://') + 'stats.g.doubleclick.net/dc.js';",
-
-	"var _gaq = _gaq || [];": "// var _gaq = _gaq || [];",
-
-	"_gaq.push(['_setAccount', 'UA-37894017-1']);": "// _gaq.push(['_setAccount', 'UA-37894017-1']);",
-
-	"_gaq.push(['_trackPageview']);": "// _gaq.push(['_trackPageview']);",
-
-	"ga('create', 'GTM-MBCBVQS');": "// ga('create', 'GTM-MBCBVQS');",
-
-	"ga('send', 'pageview');": "// ga('send', 'pageview');",
-
-	"ga('create', 'GTM-MBCBVQS', {'name': 'section_linger' });": "// ga('create', 'GTM-MBCBVQS', {'name': 'section_linger' });",
-
-	"ga('create', 'UA-42055132-1', {'name': 'shorthand' });": "// ga('create', 'UA-42055132-1', {'name': 'shorthand' });",
-
-	"ga('create', 'UA-37894017-2', 'auto');": "// ga('create', 'UA-37894017-2', 'auto');",
-
-	"ga('shorthand.send', 'pageview', {\n        'dimension1': 24846,\n        'dimension2': 67636,\n        'metric1':24846,\n        'metric2':67636\n      });": "// ga('shorthand.send', 'pageview', {\n        // 'dimension1': 24846,\n        // 'dimension2': 67636,\n        // 'metric1':24846,\n        // 'metric2':67636\n      // });",
-
-	// some have windows line endings
-	"ga('shorthand.send', 'pageview', {\r\n        'dimension1': 24846,\r\n        'dimension2': 67636,\r\n        'metric1':24846,\r\n        'metric2':67636\r\n      });": "// ga('shorthand.send', 'pageview', {\n        // 'dimension1': 24846,\n        // 'dimension2': 67636,\n        // 'metric1':24846,\n        // 'metric2':67636\n      // });",
-}
-
 type Tracker struct {
 	numOfHtmlFiles     int
 	dataJsonFilesMoved int
 	filesFixed         []string
 	blocked            []string
-	snippetsReplaceed  map[string]int
+	scriptsEdited      map[string]int
+	edits              []tracking.Edit
+	notReversed        []tracking.Edit
 }
 
 func main() {
@@ -63,8 +36,18 @@ func main() {
 		"collection":     args.GetCollectionName(),
 		"master dir":     args.GetMasterDir(),
 		"reverseChanges": args.ReverseChanges(),
+		"rules":          args.GetRulesFile(),
+		"record":         args.GetRecordFile(),
 	})
 
+	rules, err := getRules(args)
+	if err != nil {
+		logAndExit(err)
+	}
+
+	// record every change so the run can be reverted.
+	collections.StartJournal(args.GetCollectionsDir(), "visualisations")
+
 	col := collections.New(args.GetCollectionsDir(), args.GetCollectionName())
 	if err := collections.Save(col); err != nil {
 		logAndExit(err)
@@ -75,16 +58,39 @@ func main() {
 		logAndExit(err)
 	}
 
-	replaceCodeInVisualisations(args, cols, col)
+	if args.ReverseChanges() {
+		reverseChangesInVisualisations(args, cols, col)
+		return
+	}
+
+	replaceCodeInVisualisations(args, cols, col, rules)
 }
 
-func replaceCodeInVisualisations(args *config.Args, cols *collections.Collections, col *collections.Collection) {
+// getRules returns the rules from the rules file if one was provided otherwise the default rules.
+func getRules(args *config.Args) ([]*tracking.Rule, error) {
+	if args.GetRulesFile() != "" {
+		return tracking.LoadRules(args.GetRulesFile())
+	}
+
+	if err := tracking.Compile(tracking.DefaultRules); err != nil {
+		return nil, err
+	}
+	return tracking.DefaultRules, nil
+}
 
-	t := &Tracker{
-		filesFixed:        make([]string, 0),
-		snippetsReplaceed: make(map[string]int, 0),
-		numOfHtmlFiles:    0,
+func newTracker() *Tracker {
+	return &Tracker{
+		filesFixed:     make([]string, 0),
+		blocked:        make([]string, 0),
+		scriptsEdited:  make(map[string]int, 0),
+		edits:          make([]tracking.Edit, 0),
+		notReversed:    make([]tracking.Edit, 0),
+		numOfHtmlFiles: 0,
 	}
+}
+
+func replaceCodeInVisualisations(args *config.Args, cols *collections.Collections, col *collections.Collection, rules []*tracking.Rule) {
+	t := newTracker()
 
 	visualisationDir := path.Join(args.GetMasterDir(), "visualisations")
 	err := filepath.Walk(visualisationDir, func(path string, info os.FileInfo, err error) error {
@@ -92,9 +98,9 @@ func replaceCodeInVisualisations(args *config.Args, cols *collections.Collection
 			return nil
 		}
 
-		// only html files have the Google Analytics snippets we want to replace
+		// only html files have the Google Analytics scripts we want to remove
 		if ext := filepath.Ext(info.Name()); ext == ".html" {
-			err := replaceCodeInHtmlFile(path, t, args, cols, col)
+			err := replaceCodeInHtmlFile(path, t, args, cols, col, rules)
 			if err != nil {
 				return err
 			}
@@ -107,9 +113,16 @@ func replaceCodeInVisualisations(args *config.Args, cols *collections.Collection
 
 	addDataJsonFilesToCollection(t, args, col)
 
+	record := &tracking.Record{CreatedAt: time.Now(), Collection: col.Name, Edits: t.edits}
+	if err := tracking.SaveRecord(args.GetRecordFile(), record); err != nil {
+		logAndExit(err)
+	}
+
 	log.Event(nil, "Finished", log.Data{
 		"numOfHtmlFiles":                t.numOfHtmlFiles,
-		"snippetsReplaced":              t.snippetsReplaceed,
+		"scriptsEdited":                 t.scriptsEdited,
+		"numOfEdits":                    len(t.edits),
+		"record":                        args.GetRecordFile(),
 		"filesFixed":                    t.filesFixed,
 		"dataJsonFilesMoved":            t.dataJsonFilesMoved,
 		"numOfFilesFixed":               len(t.filesFixed),
@@ -162,17 +175,13 @@ func fileExists(name string) bool {
 	return true
 }
 
-func replaceCodeInHtmlFile(masterPath string, t *Tracker, args *config.Args, cols *collections.Collections, col *collections.Collection) error {
+func replaceCodeInHtmlFile(masterPath string, t *Tracker, args *config.Args, cols *collections.Collections, col *collections.Collection, rules []*tracking.Rule) error {
 	t.numOfHtmlFiles++
 
 	b, err := ioutil.ReadFile(masterPath)
 	if err != nil {
 		return err
 	}
-	fileContents := string(b)
-
-	existingCollectionsChecked := false
-	fileUpdated := false
 
 	uri, err := filepath.Rel(args.GetMasterDir(), masterPath)
 	if err != nil {
@@ -180,51 +189,91 @@ func replaceCodeInHtmlFile(masterPath string, t *Tracker, args *config.Args, col
 	}
 
 	fmt.Println("Checking file: " + masterPath)
-	for k, v := range snippetReplacements {
+	updated, edits := tracking.Apply(uri, b, rules)
+	if len(edits) == 0 {
+		return nil
+	}
 
-		searchString := k
-		replaceString := v
+	if isBlocked(uri, cols, col, t) {
+		fmt.Println("   Skipping file in another collection: " + uri)
+		return nil
+	}
 
-		if args.ReverseChanges() {
-			searchString = v
-			replaceString = k
-		}
+	for _, e := range edits {
+		fmt.Println("   " + e.Action + " script matching rule: " + e.Rule)
+		t.scriptsEdited[e.Rule] = t.scriptsEdited[e.Rule] + 1
+	}
 
-		if strings.Contains(fileContents, searchString) {
+	t.filesFixed = append(t.filesFixed, uri)
+	t.edits = append(t.edits, edits...)
+	return collections.WriteContent(path.Join(col.GetReviewed(), uri), updated)
+}
 
-			checkIfFileAlreadyExistsInACollection(&existingCollectionsChecked, uri, cols, t)
+// reverseChangesInVisualisations restores the scripts removed or neutralised by a previous run, using its record of
+// edits, in the published visualisations and adds them to the collection.
+func reverseChangesInVisualisations(args *config.Args, cols *collections.Collections, col *collections.Collection) {
+	record, err := tracking.LoadRecord(args.GetRecordFile())
+	if err != nil {
+		logAndExit(err)
+	}
 
-			t.snippetsReplaceed[k] = t.snippetsReplaceed[k] + 1
-			fileUpdated = true
-			fmt.Println("   Replacing snippet: " + searchString)
-			fileContents = strings.Replace(fileContents, searchString, replaceString, -1)
+	t := newTracker()
+	byURI := make(map[string][]tracking.Edit)
+	uris := make([]string, 0)
+	for _, e := range record.Edits {
+		if _, ok := byURI[e.URI]; !ok {
+			uris = append(uris, e.URI)
 		}
+		byURI[e.URI] = append(byURI[e.URI], e)
 	}
 
-	if fileUpdated {
-		t.filesFixed = append(t.filesFixed, uri)
+	for _, uri := range uris {
+		t.numOfHtmlFiles++
 
-		dataJsonReviewedUri := path.Join(col.GetReviewed(), uri)
+		if isBlocked(uri, cols, col, t) {
+			continue
+		}
 
-		err = collections.WriteContent(dataJsonReviewedUri, []byte(fileContents))
+		b, err := ioutil.ReadFile(path.Join(args.GetMasterDir(), uri))
 		if err != nil {
-			return err
+			logAndExit(err)
+		}
+
+		restored, notReversed := tracking.Reverse(b, byURI[uri])
+		t.notReversed = append(t.notReversed, notReversed...)
+		if len(notReversed) == len(byURI[uri]) {
+			continue
+		}
+
+		if err := collections.WriteContent(path.Join(col.GetReviewed(), uri), restored); err != nil {
+			logAndExit(err)
 		}
+		t.filesFixed = append(t.filesFixed, uri)
 	}
-	return nil
-}
 
-func checkIfFileAlreadyExistsInACollection(existingCollectionsChecked *bool, uri string, cols *collections.Collections, t *Tracker) {
+	addDataJsonFilesToCollection(t, args, col)
 
-	if !*existingCollectionsChecked {
-		for _, c := range cols.Collections {
-			if c.Contains(uri) {
-				t.blocked = append(t.blocked, uri)
-				break
-			}
+	log.Event(nil, "Finished reversing changes", log.Data{
+		"record":                        args.GetRecordFile(),
+		"numOfHtmlFiles":                t.numOfHtmlFiles,
+		"filesFixed":                    t.filesFixed,
+		"dataJsonFilesMoved":            t.dataJsonFilesMoved,
+		"notReversed":                   t.notReversed,
+		"numOfFilesBlockedByCollection": len(t.blocked),
+		"filesBlockedByCollection":      t.blocked,
+	})
+}
+
+// isBlocked returns true if the file is in another collection, recording it as blocked. Blocked files are not changed
+// as adding them to the collection as well would produce conflicting collections.
+func isBlocked(uri string, cols *collections.Collections, col *collections.Collection, t *Tracker) bool {
+	for _, c := range cols.Collections {
+		if c.Name != col.Name && c.Contains(uri) {
+			t.blocked = append(t.blocked, uri)
+			return true
 		}
-		*existingCollectionsChecked = true
 	}
+	return false
 }
 
 func logAndExit(err error) {
@@ -0,0 +1,285 @@
+package tracking
+
+import (
+	"encoding/json"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rule actions.
+const (
+	// ActionRemove deletes the script element.
+	ActionRemove = "remove"
+
+	// ActionNeutralise keeps the script element but changes its type so the browser does not run it.
+	ActionNeutralise = "neutralise"
+)
+
+const neutralisedType = "text/plain"
+
+var typeAttrRegex = regexp.MustCompile(`(?i)\stype\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+
+// KnownTrackers is the domains of known analytics and advertising trackers, subdomains are also trackers.
+var KnownTrackers = []string{
+	"google-analytics.com",
+	"googletagmanager.com",
+	"doubleclick.net",
+	"googleadservices.com",
+	"googlesyndication.com",
+	"facebook.net",
+	"hotjar.com",
+	"scorecardresearch.com",
+	"quantserve.com",
+	"chartbeat.com",
+	"chartbeat.net",
+	"addthis.com",
+	"sharethis.com",
+}
+
+// IsTracker returns true if host is, or is a subdomain of, a known tracker domain.
+func IsTracker(host string) bool {
+	return matchesDomain(host, KnownTrackers)
+}
+
+// DefaultRules remove the Google Analytics, Google Tag Manager and DoubleClick trackers found in visualisations.
+var DefaultRules = []*Rule{
+	{
+		Name:       "google-tracker-src",
+		SrcDomains: []string{"google-analytics.com", "googletagmanager.com", "doubleclick.net"},
+		Action:     ActionRemove,
+	},
+	{
+		Name:           "google-analytics-inline",
+		ContentPattern: `_gaq\s*\.push|\bga\s*\(\s*['"](create|send)|GoogleAnalyticsObject|\bgtag\s*\(|google-analytics\.com|doubleclick\.net`,
+		Action:         ActionRemove,
+	},
+	{
+		Name:        "ons-tracking-ids",
+		TrackingIDs: []string{"UA-37894017-1", "UA-37894017-2", "UA-42055132-1", "GTM-MBCBVQS"},
+		Action:      ActionRemove,
+	},
+}
+
+// Rule targets script elements by the domain of their src, a regular expression matched against their inline
+// content or the tracking ids they contain. A script matches the rule if it matches any of them.
+type Rule struct {
+	Name           string   `json:"name"`
+	SrcDomains     []string `json:"src_domains"`
+	ContentPattern string   `json:"content_pattern"`
+	TrackingIDs    []string `json:"tracking_ids"`
+	Action         string   `json:"action"`
+
+	content *regexp.Regexp
+}
+
+// Edit is a single change made to an html file. Offset is the position of the replacement in the changed file so an
+// edit can be reversed by replacing it with the original.
+type Edit struct {
+	URI         string `json:"uri"`
+	Rule        string `json:"rule"`
+	Action      string `json:"action"`
+	Offset      int    `json:"offset"`
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+}
+
+// LoadRules reads a json array of rules from filename.
+func LoadRules(filename string) ([]*Rule, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errs.New("failed to read rules file", err, log.Data{"rules": filename})
+	}
+
+	var rules []*Rule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, errs.New("failed to unmarshal rules file", err, log.Data{"rules": filename})
+	}
+
+	if err := Compile(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Compile validates the rules, it must be called before the rules are used.
+func Compile(rules []*Rule) error {
+	for _, r := range rules {
+		if r.Action != ActionRemove && r.Action != ActionNeutralise {
+			return errs.New("invalid rule action expected remove or neutralise", nil, log.Data{"rule": r.Name, "action": r.Action})
+		}
+
+		if len(r.SrcDomains) == 0 && r.ContentPattern == "" && len(r.TrackingIDs) == 0 {
+			return errs.New("rule must have a src domain, content pattern or tracking id", nil, log.Data{"rule": r.Name})
+		}
+
+		if r.ContentPattern != "" {
+			re, err := regexp.Compile(r.ContentPattern)
+			if err != nil {
+				return errs.New("invalid rule content pattern", err, log.Data{"rule": r.Name})
+			}
+			r.content = re
+		}
+	}
+	return nil
+}
+
+// Matches returns true if the script matches the rule.
+func (r *Rule) Matches(s *Element) bool {
+	if matchesDomain(s.SrcHost(), r.SrcDomains) {
+		return true
+	}
+
+	if r.content != nil && r.content.MatchString(s.Content) {
+		return true
+	}
+
+	for _, id := range r.TrackingIDs {
+		if strings.Contains(s.Content, id) || strings.Contains(s.Src(), id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply removes or neutralises the scripts in the html matching the rules, the first matching rule is used for each
+// script. Scripts that have already been neutralised are not changed. Returns the changed html and an edit for each
+// script changed.
+func Apply(uri string, html []byte, rules []*Rule) ([]byte, []Edit) {
+	var out strings.Builder
+	edits := make([]Edit, 0)
+	last := 0
+
+	for _, s := range FindScripts(html) {
+		if s.Neutralised() {
+			continue
+		}
+
+		for _, r := range rules {
+			if !r.Matches(s) {
+				continue
+			}
+
+			replacement := ""
+			if r.Action == ActionNeutralise {
+				replacement = neutralise(s)
+			}
+
+			out.Write(html[last:s.Start])
+			edits = append(edits, Edit{
+				URI:         uri,
+				Rule:        r.Name,
+				Action:      r.Action,
+				Offset:      out.Len(),
+				Original:    string(html[s.Start:s.End]),
+				Replacement: replacement,
+			})
+			out.WriteString(replacement)
+			last = s.End
+			break
+		}
+	}
+
+	if len(edits) == 0 {
+		return html, edits
+	}
+
+	out.Write(html[last:])
+	return []byte(out.String()), edits
+}
+
+// Reverse undoes the edits made to a file, restoring the original script elements. Edits whose replacement is no
+// longer at their offset are returned as not reversed.
+func Reverse(html []byte, edits []Edit) ([]byte, []Edit) {
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+
+	// reverse from the end of the file so the offsets of the earlier edits stay valid.
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Offset > sorted[j].Offset
+	})
+
+	s := string(html)
+	notReversed := make([]Edit, 0)
+	for _, e := range sorted {
+		end := e.Offset + len(e.Replacement)
+		if e.Offset > len(s) || end > len(s) || s[e.Offset:end] != e.Replacement {
+			notReversed = append(notReversed, e)
+			continue
+		}
+		s = s[:e.Offset] + e.Original + s[end:]
+	}
+	return []byte(s), notReversed
+}
+
+// matchesDomain returns true if host is one of the domains or a subdomain of one.
+func matchesDomain(host string, domains []string) bool {
+	if host == "" {
+		return false
+	}
+
+	for _, d := range domains {
+		d = strings.ToLower(d)
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// neutralise returns the script element with its type changed so the browser treats it as data.
+func neutralise(s *Element) string {
+	tag := s.OpenTag
+	if typeAttrRegex.MatchString(tag) {
+		tag = typeAttrRegex.ReplaceAllString(tag, ` type="`+neutralisedType+`"`)
+	} else {
+		tag = tag[:len("<script")] + ` type="` + neutralisedType + `"` + tag[len("<script"):]
+	}
+	return tag + s.Content + s.CloseTag
+}
+
+// Record is the reversible record of the edits made by a run.
+type Record struct {
+	CreatedAt  time.Time `json:"created_at"`
+	Collection string    `json:"collection"`
+	Edits      []Edit    `json:"edits"`
+}
+
+// SaveRecord writes the record to filename, which must not already exist.
+func SaveRecord(filename string, r *Record) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// never overwrite an existing record as it is needed to reverse the run that wrote it.
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return errs.New("failed to create edit record", err, log.Data{"record": filename})
+	}
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		return errs.New("failed to write edit record", err, log.Data{"record": filename})
+	}
+	return nil
+}
+
+// LoadRecord reads a record written by SaveRecord.
+func LoadRecord(filename string) (*Record, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errs.New("failed to read edit record", err, log.Data{"record": filename})
+	}
+
+	var r Record
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, errs.New("failed to unmarshal edit record", err, log.Data{"record": filename})
+	}
+	return &r, nil
+}
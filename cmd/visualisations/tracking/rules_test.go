@@ -0,0 +1,71 @@
+package tracking
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+const testHTML = `<html><head>
+<script async src="https://www.googletagmanager.com/gtag/js?id=UA-1"></script>
+<script src="/js/chart.js"></script>
+<script>gtag('config', 'UA-1');</script>
+</head></html>`
+
+func TestApplyAndReverse(t *testing.T) {
+	if err := Compile(DefaultRules); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, edits := Apply("/visualisations/a/index.html", []byte(testHTML), DefaultRules)
+	if len(edits) != 2 {
+		t.Fatalf("expected 2 edits, got %d: %s", len(edits), updated)
+	}
+
+	for _, s := range FindScripts(updated) {
+		if s.Src() == "/js/chart.js" {
+			continue
+		}
+		if IsTracker(s.SrcHost()) && s.Attrs["type"] != neutralisedType {
+			t.Errorf("expected tracker script to be removed or neutralised: %s", s.OpenTag)
+		}
+	}
+
+	restored, notReversed := Reverse(updated, edits)
+	if len(notReversed) != 0 {
+		t.Errorf("expected every edit to be reversed, got %+v", notReversed)
+	}
+
+	if string(restored) != testHTML {
+		t.Errorf("expected original html to be restored, got %s", restored)
+	}
+}
+
+func TestSaveRecordRefusesToOverwrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := path.Join(dir, "test.edits")
+	record := &Record{CreatedAt: time.Now(), Collection: "test", Edits: []Edit{{URI: "/a", Rule: "r", Action: ActionRemove, Original: "<script></script>"}}}
+	if err := SaveRecord(filename, record); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveRecord(filename, &Record{Collection: "other"}); err == nil {
+		t.Error("expected an existing record not to be overwritten")
+	}
+
+	loaded, err := LoadRecord(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Collection != "test" || len(loaded.Edits) != 1 || loaded.Edits[0].Original != "<script></script>" {
+		t.Errorf("expected the original record to be kept, got %+v", loaded)
+	}
+}
@@ -0,0 +1,201 @@
+package tracking
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+)
+
+// Element is an element found in an html file. Start and End are the byte offsets of the whole element, from the
+// opening < to the closing > of the closing tag for script and style elements, otherwise the end of the opening tag.
+// Only script and style elements have Content and a CloseTag.
+type Element struct {
+	Name     string
+	Start    int
+	End      int
+	OpenTag  string
+	CloseTag string
+	Attrs    map[string]string
+	Content  string
+}
+
+// Src returns the src attribute of the element, empty for inline scripts.
+func (e *Element) Src() string {
+	return e.Attrs["src"]
+}
+
+// Neutralised returns true if the script has been neutralised so the browser does not run it.
+func (e *Element) Neutralised() bool {
+	return strings.EqualFold(e.Attrs["type"], neutralisedType)
+}
+
+// SrcHost returns the lowercase host of the element src, empty if the script is inline or the src has no host.
+func (e *Element) SrcHost() string {
+	return Host(e.Src())
+}
+
+// Host returns the lowercase host of a link, empty if the link is relative. Protocol relative links e.g.
+// //www.example.com/x.js are treated as https.
+func Host(link string) string {
+	link = strings.TrimSpace(link)
+	if strings.HasPrefix(link, "//") {
+		link = "https:" + link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// FindScripts returns the script elements in the html in the order they appear.
+func FindScripts(html []byte) []*Element {
+	scripts := make([]*Element, 0)
+	for _, e := range FindElements(html) {
+		if e.Name == "script" {
+			scripts = append(scripts, e)
+		}
+	}
+	return scripts
+}
+
+// FindElements returns the elements in the html in the order they appear. Elements inside comments are ignored and
+// the content of script and style elements is not parsed as markup, as a browser would.
+func FindElements(html []byte) []*Element {
+	elements := make([]*Element, 0)
+	lower := bytes.ToLower(html)
+
+	for pos := 0; pos < len(html); {
+		i := bytes.IndexByte(html[pos:], '<')
+		if i < 0 {
+			break
+		}
+		pos += i
+
+		if bytes.HasPrefix(html[pos:], []byte("<!--")) {
+			end := bytes.Index(html[pos+4:], []byte("-->"))
+			if end < 0 {
+				break
+			}
+			pos += 4 + end + 3
+			continue
+		}
+
+		name := tagName(lower[pos+1:])
+		if name == "" {
+			pos++
+			continue
+		}
+
+		tagEnd, attrs := parseTag(html, pos+1+len(name))
+		if name != "script" && name != "style" {
+			elements = append(elements, &Element{
+				Name:    name,
+				Start:   pos,
+				End:     tagEnd,
+				OpenTag: string(html[pos:tagEnd]),
+				Attrs:   attrs,
+			})
+			pos = tagEnd
+			continue
+		}
+
+		// raw text elements end at the first matching closing tag.
+		closeTag := []byte("</" + name)
+		closeStart := bytes.Index(lower[tagEnd:], closeTag)
+		if closeStart < 0 {
+			break
+		}
+		closeStart += tagEnd
+
+		end := bytes.IndexByte(html[closeStart:], '>')
+		if end < 0 {
+			break
+		}
+		end += closeStart + 1
+
+		elements = append(elements, &Element{
+			Name:     name,
+			Start:    pos,
+			End:      end,
+			OpenTag:  string(html[pos:tagEnd]),
+			CloseTag: string(html[closeStart:end]),
+			Attrs:    attrs,
+			Content:  string(html[tagEnd:closeStart]),
+		})
+		pos = end
+	}
+	return elements
+}
+
+// tagName returns the lowercase name of the tag starting at b, empty if b is not the start of an opening tag.
+func tagName(b []byte) string {
+	i := 0
+	for i < len(b) && (b[i] >= 'a' && b[i] <= 'z' || i > 0 && (b[i] >= '0' && b[i] <= '9' || b[i] == '-')) {
+		i++
+	}
+
+	if i == 0 || i < len(b) && !strings.ContainsRune(" \t\r\n/>", rune(b[i])) {
+		return ""
+	}
+	return string(b[:i])
+}
+
+// parseTag reads the attributes of the tag from pos, returning the offset after the closing > and the attributes
+// keyed by lowercase name.
+func parseTag(html []byte, pos int) (int, map[string]string) {
+	attrs := make(map[string]string)
+	for pos < len(html) {
+		for pos < len(html) && strings.ContainsRune(" \t\r\n/", rune(html[pos])) {
+			pos++
+		}
+
+		if pos >= len(html) {
+			break
+		}
+
+		if html[pos] == '>' {
+			return pos + 1, attrs
+		}
+
+		nameStart := pos
+		for pos < len(html) && !strings.ContainsRune(" \t\r\n/>=", rune(html[pos])) {
+			pos++
+		}
+		name := strings.ToLower(string(html[nameStart:pos]))
+
+		for pos < len(html) && strings.ContainsRune(" \t\r\n", rune(html[pos])) {
+			pos++
+		}
+
+		value := ""
+		if pos < len(html) && html[pos] == '=' {
+			pos++
+			for pos < len(html) && strings.ContainsRune(" \t\r\n", rune(html[pos])) {
+				pos++
+			}
+
+			if pos < len(html) && (html[pos] == '"' || html[pos] == '\'') {
+				quote := html[pos]
+				end := bytes.IndexByte(html[pos+1:], quote)
+				if end < 0 {
+					end = len(html) - pos - 1
+				}
+				value = string(html[pos+1 : pos+1+end])
+				pos += end + 2
+			} else {
+				valueStart := pos
+				for pos < len(html) && !strings.ContainsRune(" \t\r\n>", rune(html[pos])) {
+					pos++
+				}
+				value = string(html[valueStart:pos])
+			}
+		}
+
+		if name != "" {
+			attrs[name] = value
+		}
+	}
+	return len(html), attrs
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"github.com/ONSdigital/dp-zebedee-utils/cmd/visualisations/tracking"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Asset kinds.
+const (
+	kindScript     = "script"
+	kindStylesheet = "stylesheet"
+	kindIframe     = "iframe"
+	kindFont       = "font"
+	kindImage      = "image"
+	kindMedia      = "media"
+	kindEmbed      = "embed"
+	kindOther      = "other"
+)
+
+var (
+	// css url(...) references and @import "..." rules.
+	cssURLRegex    = regexp.MustCompile(`(?i)url\(\s*['"]?([^'")\s]+)['"]?\s*\)`)
+	cssImportRegex = regexp.MustCompile(`(?i)@import\s+['"]([^'"]+)['"]`)
+
+	// absolute and protocol relative urls in inline scripts e.g. the analytics.js loaded by the Google Analytics snippet.
+	scriptURLRegex = regexp.MustCompile(`(?i)(?:https?:)?//[a-z0-9-]+(?:\.[a-z0-9-]+)+[^\s'"<>()\\]*`)
+
+	fontExts  = map[string]bool{".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true}
+	imageExts = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true, ".ico": true}
+	fontHosts = []string{"fonts.googleapis.com", "fonts.gstatic.com", "use.typekit.net", "use.fontawesome.com"}
+)
+
+// Asset is a single external resource loaded by a visualisation.
+type Asset struct {
+	Visualisation string `json:"visualisation"`
+	File          string `json:"file"`
+	Kind          string `json:"kind"`
+	URL           string `json:"url"`
+	Origin        string `json:"origin"`
+	MixedContent  bool   `json:"mixed_content"`
+	Tracker       bool   `json:"tracker"`
+	Rule          string `json:"rule,omitempty"`
+}
+
+// visualisationReport is the external assets of a single visualisation.
+type visualisationReport struct {
+	Visualisation string   `json:"visualisation"`
+	Origins       []string `json:"origins"`
+	MixedContent  int      `json:"mixed_content"`
+	Trackers      int      `json:"trackers"`
+	Assets        []Asset  `json:"assets"`
+}
+
+func main() {
+	log.Namespace = "visualisation-audit"
+
+	zebRoot := flag.String("zeb_root", "", "The root zebedee directory")
+	format := flag.String("format", "csv", "The report format: csv or json")
+	out := flag.String("out", "", "The file to write the report to, defaults to stdout")
+	flag.Parse()
+
+	if *zebRoot == "" {
+		logAndExit(errs.New("missing flag", nil, log.Data{"var": "zeb_root"}))
+	}
+
+	if *format != "csv" && *format != "json" {
+		logAndExit(errs.New("invalid flag value expected csv or json", nil, log.Data{"var": "format", "value": *format}))
+	}
+
+	if err := tracking.Compile(tracking.DefaultRules); err != nil {
+		logAndExit(err)
+	}
+
+	masterDir := path.Join(*zebRoot, "master")
+	assets, err := audit(masterDir, tracking.DefaultRules)
+	if err != nil {
+		logAndExit(err)
+	}
+
+	reports := groupByVisualisation(assets)
+	if err := writeReport(*format, *out, assets, reports); err != nil {
+		logAndExit(err)
+	}
+
+	mixed, trackers := 0, 0
+	for _, r := range reports {
+		mixed += r.MixedContent
+		trackers += r.Trackers
+	}
+
+	log.Event(nil, "visualisation audit completed", log.Data{
+		"visualisations_with_external_assets": len(reports),
+		"external_assets":                     len(assets),
+		"mixed_content":                       mixed,
+		"trackers":                            trackers,
+	})
+}
+
+// audit returns the external assets of every html and css file in master/visualisations. Inline scripts matching the
+// rules are reported as trackers.
+func audit(masterDir string, rules []*tracking.Rule) ([]Asset, error) {
+	visualisationsDir := path.Join(masterDir, "visualisations")
+	assets := make([]Asset, 0)
+
+	err := filepath.Walk(visualisationsDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		ext := strings.ToLower(filepath.Ext(p))
+		if info.IsDir() || (ext != ".html" && ext != ".htm" && ext != ".css") {
+			return nil
+		}
+
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(visualisationsDir, p)
+		rel = filepath.ToSlash(rel)
+		file := path.Join("/visualisations", rel)
+		visualisation := strings.Split(rel, "/")[0]
+
+		var links []link
+		if ext == ".css" {
+			links = cssLinks(string(b))
+		} else {
+			links = htmlLinks(b, rules)
+		}
+
+		for _, l := range links {
+			if a, ok := newAsset(visualisation, file, l); ok {
+				assets = append(assets, a)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errs.New("failed to audit visualisations", err, log.Data{"dir": visualisationsDir})
+	}
+	return assets, nil
+}
+
+// link is a reference to a resource found in a file and the kind of resource it is. Rule is the name of the tracking
+// rule an inline script matched, inline scripts have no url.
+type link struct {
+	kind string
+	url  string
+	rule string
+}
+
+// htmlLinks returns the resources referenced by the elements of an html file, including any in inline css. Inline
+// scripts give a link for each of the rules they match and each known tracker url in them, scripts that have been
+// neutralised are not run by the browser and are left out.
+func htmlLinks(html []byte, rules []*tracking.Rule) []link {
+	links := make([]link, 0)
+	add := func(kind string, u string) {
+		if u = strings.TrimSpace(u); u != "" {
+			links = append(links, link{kind: kind, url: u})
+		}
+	}
+
+	for _, e := range tracking.FindElements(html) {
+		switch e.Name {
+		case "script":
+			if e.Src() != "" || e.Neutralised() {
+				add(kindScript, e.Src())
+				break
+			}
+			links = append(links, inlineScriptLinks(e, rules)...)
+		case "link":
+			add(linkKind(e.Attrs), e.Attrs["href"])
+		case "iframe", "frame":
+			add(kindIframe, e.Attrs["src"])
+		case "img":
+			add(kindImage, e.Attrs["src"])
+			for _, u := range srcset(e.Attrs["srcset"]) {
+				add(kindImage, u)
+			}
+		case "source", "video", "audio", "track":
+			add(kindMedia, e.Attrs["src"])
+			for _, u := range srcset(e.Attrs["srcset"]) {
+				add(kindMedia, u)
+			}
+			add(kindImage, e.Attrs["poster"])
+		case "object":
+			add(kindEmbed, e.Attrs["data"])
+		case "embed":
+			add(kindEmbed, e.Attrs["src"])
+		case "style":
+			links = append(links, cssLinks(e.Content)...)
+		}
+
+		if style, ok := e.Attrs["style"]; ok {
+			links = append(links, cssLinks(style)...)
+		}
+	}
+	return links
+}
+
+// inlineScriptLinks returns a link for each rule the inline script matches and each url in it on a known tracker
+// domain. Other urls are not reported as scripts often contain urls that are not loaded e.g. svg namespaces.
+func inlineScriptLinks(e *tracking.Element, rules []*tracking.Rule) []link {
+	links := make([]link, 0)
+	for _, r := range rules {
+		if r.Matches(e) {
+			links = append(links, link{kind: kindScript, rule: r.Name})
+		}
+	}
+
+	for _, u := range scriptURLRegex.FindAllString(e.Content, -1) {
+		if tracking.IsTracker(tracking.Host(u)) {
+			links = append(links, link{kind: kindScript, url: u})
+		}
+	}
+	return links
+}
+
+// cssLinks returns the resources referenced by css.
+func cssLinks(css string) []link {
+	links := make([]link, 0)
+	for _, m := range cssImportRegex.FindAllStringSubmatch(css, -1) {
+		links = append(links, link{kind: kindStylesheet, url: m[1]})
+	}
+
+	for _, m := range cssURLRegex.FindAllStringSubmatch(css, -1) {
+		links = append(links, link{kind: urlKind(m[1], kindOther), url: m[1]})
+	}
+	return links
+}
+
+// linkKind returns the kind of resource a <link> element loads.
+func linkKind(attrs map[string]string) string {
+	rel := strings.Fields(strings.ToLower(attrs["rel"]))
+	for _, r := range rel {
+		switch r {
+		case "stylesheet":
+			return urlKind(attrs["href"], kindStylesheet)
+		case "icon", "apple-touch-icon":
+			return kindImage
+		case "preload", "prefetch":
+			switch strings.ToLower(attrs["as"]) {
+			case "font":
+				return kindFont
+			case "script":
+				return kindScript
+			case "style":
+				return kindStylesheet
+			case "image":
+				return kindImage
+			}
+		}
+	}
+	return urlKind(attrs["href"], kindOther)
+}
+
+// urlKind returns the kind of resource a url is from its host or extension, or def if it cannot be told.
+func urlKind(u string, def string) string {
+	host := tracking.Host(u)
+	for _, h := range fontHosts {
+		if host == h {
+			return kindFont
+		}
+	}
+
+	p := u
+	if parsed, err := url.Parse(u); err == nil {
+		p = parsed.Path
+	}
+
+	ext := strings.ToLower(path.Ext(p))
+	switch {
+	case fontExts[ext]:
+		return kindFont
+	case imageExts[ext]:
+		return kindImage
+	case ext == ".css":
+		return kindStylesheet
+	case ext == ".js":
+		return kindScript
+	}
+	return def
+}
+
+// srcset returns the urls in a srcset attribute e.g. "a.png 1x, b.png 2x".
+func srcset(s string) []string {
+	urls := make([]string, 0)
+	for _, candidate := range strings.Split(s, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+	return urls
+}
+
+// newAsset returns the asset for an external link, false if the link is to a resource on the same site. A link for a
+// matched tracking rule is a tracker without a url or origin.
+func newAsset(visualisation string, file string, l link) (Asset, bool) {
+	if l.rule != "" {
+		return Asset{Visualisation: visualisation, File: file, Kind: l.kind, Tracker: true, Rule: l.rule}, true
+	}
+
+	host := tracking.Host(l.url)
+	if host == "" {
+		return Asset{}, false
+	}
+
+	origin := "//" + host
+	mixed := false
+	if u, err := url.Parse(strings.TrimSpace(l.url)); err == nil && u.Scheme != "" {
+		scheme := strings.ToLower(u.Scheme)
+		if scheme != "http" && scheme != "https" {
+			return Asset{}, false
+		}
+
+		origin = scheme + "://" + host
+		if u.Port() != "" {
+			origin += ":" + u.Port()
+		}
+		mixed = scheme == "http"
+	}
+
+	return Asset{
+		Visualisation: visualisation,
+		File:          file,
+		Kind:          l.kind,
+		URL:           l.url,
+		Origin:        origin,
+		MixedContent:  mixed,
+		Tracker:       tracking.IsTracker(host),
+	}, true
+}
+
+func groupByVisualisation(assets []Asset) []*visualisationReport {
+	byName := make(map[string]*visualisationReport)
+	origins := make(map[string]map[string]bool)
+	reports := make([]*visualisationReport, 0)
+
+	for _, a := range assets {
+		r, ok := byName[a.Visualisation]
+		if !ok {
+			r = &visualisationReport{Visualisation: a.Visualisation, Origins: make([]string, 0), Assets: make([]Asset, 0)}
+			byName[a.Visualisation] = r
+			origins[a.Visualisation] = make(map[string]bool)
+			reports = append(reports, r)
+		}
+
+		r.Assets = append(r.Assets, a)
+		if a.MixedContent {
+			r.MixedContent++
+		}
+		if a.Tracker {
+			r.Trackers++
+		}
+
+		if a.Origin != "" && !origins[a.Visualisation][a.Origin] {
+			origins[a.Visualisation][a.Origin] = true
+			r.Origins = append(r.Origins, a.Origin)
+		}
+	}
+
+	for _, r := range reports {
+		sort.Strings(r.Origins)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Visualisation < reports[j].Visualisation
+	})
+	return reports
+}
+
+func writeReport(format string, out string, assets []Asset, reports []*visualisationReport) error {
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return errs.New("failed to create report file", err, log.Data{"out": out})
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+
+	csvW := csv.NewWriter(w)
+	if err := csvW.Write([]string{"visualisation", "file", "kind", "url", "origin", "mixed_content", "tracker", "rule"}); err != nil {
+		return err
+	}
+
+	for _, a := range assets {
+		row := []string{a.Visualisation, a.File, a.Kind, a.URL, a.Origin, strconv.FormatBool(a.MixedContent), strconv.FormatBool(a.Tracker), a.Rule}
+		if err := csvW.Write(row); err != nil {
+			return err
+		}
+	}
+	csvW.Flush()
+	return csvW.Error()
+}
+
+func logAndExit(err error) {
+	if colErr, ok := err.(errs.Error); ok {
+		if colErr.OriginalErr != nil {
+			log.Event(nil, colErr.Message, log.Error(colErr.OriginalErr), colErr.Data)
+		} else {
+			log.Event(nil, colErr.Message, colErr.Data)
+		}
+	} else {
+		log.Event(nil, "unknown error", log.Error(err))
+	}
+	os.Exit(1)
+}
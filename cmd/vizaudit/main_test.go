@@ -0,0 +1,158 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-zebedee-utils/cmd/visualisations/tracking"
+)
+
+const gaSnippet = `(function(i,s,o,g,r,a,m){i['GoogleAnalyticsObject']=r;})` +
+	`(window,document,'script','https://www.google-analytics.com/analytics.js','ga');
+ga('create', 'UA-1', 'auto');`
+
+func compiledRules(t *testing.T) []*tracking.Rule {
+	if err := tracking.Compile(tracking.DefaultRules); err != nil {
+		t.Fatal(err)
+	}
+	return tracking.DefaultRules
+}
+
+func TestHTMLLinks(t *testing.T) {
+	html := `<html><head>
+<script src="https://cdn.example.com/d3.js"></script>
+<script>` + gaSnippet + `</script>
+<script>var ns = "http://www.w3.org/2000/svg";</script>
+<script type="text/plain">` + gaSnippet + `</script>
+<link rel="stylesheet" href="https://fonts.googleapis.com/css?family=Open+Sans">
+<link rel="preload" as="font" href="/fonts/a.woff2">
+<style>@import "https://cdn.example.com/base.css"; body { background: url(bg.png) }</style>
+</head><body>
+<!-- <img src="https://commented.example.com/a.png"> -->
+<iframe src="https://www.youtube.com/embed/1"></iframe>
+<img src="a.png" srcset="https://img.example.com/a.png 1x, b.png 2x">
+<video src="https://media.example.com/a.mp4" poster="https://media.example.com/a.jpg"></video>
+<object data="https://embed.example.com/a.swf"></object>
+<div style="background-image: url('https://img.example.com/bg.jpg')"></div>
+</body></html>`
+
+	expected := []link{
+		{kind: kindScript, url: "https://cdn.example.com/d3.js"},
+		{kind: kindScript, rule: "google-analytics-inline"},
+		{kind: kindScript, url: "https://www.google-analytics.com/analytics.js"},
+		{kind: kindFont, url: "https://fonts.googleapis.com/css?family=Open+Sans"},
+		{kind: kindFont, url: "/fonts/a.woff2"},
+		{kind: kindStylesheet, url: "https://cdn.example.com/base.css"},
+		{kind: kindImage, url: "bg.png"},
+		{kind: kindIframe, url: "https://www.youtube.com/embed/1"},
+		{kind: kindImage, url: "a.png"},
+		{kind: kindImage, url: "https://img.example.com/a.png"},
+		{kind: kindImage, url: "b.png"},
+		{kind: kindMedia, url: "https://media.example.com/a.mp4"},
+		{kind: kindImage, url: "https://media.example.com/a.jpg"},
+		{kind: kindEmbed, url: "https://embed.example.com/a.swf"},
+		{kind: kindImage, url: "https://img.example.com/bg.jpg"},
+	}
+
+	actual := htmlLinks([]byte(html), compiledRules(t))
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("link %d: expected %+v, got %+v", i, expected[i], actual[i])
+		}
+	}
+}
+
+func TestHTMLLinksInlineTrackingIDs(t *testing.T) {
+	html := `<script>window.dataLayer.push({id: "GTM-MBCBVQS"}); gtag('config', 'UA-37894017-1');</script>`
+
+	actual := htmlLinks([]byte(html), compiledRules(t))
+	expected := []link{
+		{kind: kindScript, rule: "google-analytics-inline"},
+		{kind: kindScript, rule: "ons-tracking-ids"},
+	}
+
+	if len(actual) != len(expected) || actual[0] != expected[0] || actual[1] != expected[1] {
+		t.Errorf("expected a link for each matching rule %+v, got %+v", expected, actual)
+	}
+}
+
+func TestCSSLinks(t *testing.T) {
+	css := `@import "https://cdn.example.com/a.css";
+@IMPORT 'b.css';
+@font-face { src: url("https://fonts.gstatic.com/s/a.woff2") format("woff2"), url(/fonts/a.ttf); }
+.logo { background: url( 'https://img.example.com/logo.svg' ) }
+.cursor { cursor: url(https://cdn.example.com/cursor) }`
+
+	expected := []link{
+		{kind: kindStylesheet, url: "https://cdn.example.com/a.css"},
+		{kind: kindStylesheet, url: "b.css"},
+		{kind: kindFont, url: "https://fonts.gstatic.com/s/a.woff2"},
+		{kind: kindFont, url: "/fonts/a.ttf"},
+		{kind: kindImage, url: "https://img.example.com/logo.svg"},
+		{kind: kindOther, url: "https://cdn.example.com/cursor"},
+	}
+
+	actual := cssLinks(css)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("link %d: expected %+v, got %+v", i, expected[i], actual[i])
+		}
+	}
+}
+
+func TestNewAsset(t *testing.T) {
+	cases := []struct {
+		link     link
+		external bool
+		expected Asset
+	}{
+		{
+			link:     link{kind: kindScript, url: "https://www.google-analytics.com/analytics.js"},
+			external: true,
+			expected: Asset{Kind: kindScript, URL: "https://www.google-analytics.com/analytics.js", Origin: "https://www.google-analytics.com", Tracker: true},
+		},
+		{
+			link:     link{kind: kindImage, url: "http://img.example.com:8080/a.png"},
+			external: true,
+			expected: Asset{Kind: kindImage, URL: "http://img.example.com:8080/a.png", Origin: "http://img.example.com:8080", MixedContent: true},
+		},
+		{
+			link:     link{kind: kindFont, url: "//fonts.gstatic.com/a.woff2"},
+			external: true,
+			expected: Asset{Kind: kindFont, URL: "//fonts.gstatic.com/a.woff2", Origin: "//fonts.gstatic.com"},
+		},
+		{
+			link:     link{kind: kindScript, rule: "google-analytics-inline"},
+			external: true,
+			expected: Asset{Kind: kindScript, Tracker: true, Rule: "google-analytics-inline"},
+		},
+		{link: link{kind: kindImage, url: "/img/a.png"}},
+		{link: link{kind: kindImage, url: "a.png"}},
+		{link: link{kind: kindOther, url: "ftp://files.example.com/a.zip"}},
+	}
+
+	for _, c := range cases {
+		actual, ok := newAsset("viz", "/visualisations/viz/index.html", c.link)
+		if ok != c.external {
+			t.Errorf("%+v: expected external %t, got %t", c.link, c.external, ok)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		c.expected.Visualisation = "viz"
+		c.expected.File = "/visualisations/viz/index.html"
+		if actual != c.expected {
+			t.Errorf("%+v: expected %+v, got %+v", c.link, c.expected, actual)
+		}
+	}
+}
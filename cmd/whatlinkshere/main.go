@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/dp-zebedee-utils/linkindex"
+	"github.com/ONSdigital/log.go/log"
+	"os"
+	"path"
+)
+
+func main() {
+	log.Namespace = "what-links-here"
+
+	zebRoot := flag.String("zeb_root", "", "The root zebedee directory")
+	indexFile := flag.String("index", "", "The link index file, created if it does not exist")
+	uri := flag.String("uri", "", "The taxonomy uri to find the pages linking to")
+	children := flag.Bool("children", false, "Also find pages linking to any uri beneath uri")
+	flag.Parse()
+
+	if *zebRoot == "" {
+		logAndExit(errs.New("missing flag", nil, log.Data{"var": "zeb_root"}))
+	}
+
+	if *indexFile == "" {
+		logAndExit(errs.New("missing flag", nil, log.Data{"var": "index"}))
+	}
+
+	if *uri == "" {
+		logAndExit(errs.New("missing flag", nil, log.Data{"var": "uri"}))
+	}
+
+	idx, err := linkindex.Open(*indexFile, path.Join(*zebRoot, "master"))
+	if err != nil {
+		logAndExit(err)
+	}
+
+	referrers := idx.Referrers(*uri, *children)
+	for _, r := range referrers {
+		fmt.Println(r)
+	}
+
+	log.Event(nil, "what links here completed", log.Data{
+		"uri":       *uri,
+		"children":  *children,
+		"referrers": len(referrers),
+	})
+}
+
+func logAndExit(err error) {
+	if colErr, ok := err.(errs.Error); ok {
+		if colErr.OriginalErr != nil {
+			log.Event(nil, colErr.Message, log.Error(colErr.OriginalErr), colErr.Data)
+		} else {
+			log.Event(nil, colErr.Message, colErr.Data)
+		}
+	} else {
+		log.Event(nil, "unknown error", log.Error(err))
+	}
+	os.Exit(1)
+}
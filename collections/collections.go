@@ -8,7 +8,20 @@ import (
 	"io/ioutil"
 	"path"
 	"path/filepath"
-	"strings"
+)
+
+// Collection approval statuses.
+const (
+	ApprovalNotStarted = "NOT_STARTED"
+	ApprovalInProgress = "IN_PROGRESS"
+	ApprovalComplete   = "COMPLETE"
+	ApprovalError      = "ERROR"
+)
+
+// Collection types, scheduled collections are published automatically at their publish date.
+const (
+	TypeManual    = "manual"
+	TypeScheduled = "scheduled"
 )
 
 type Metadata struct {
@@ -30,6 +43,7 @@ type Collection struct {
 	ID                    string        `json:"id"`
 	Name                  string        `json:"name"`
 	Type                  string        `json:"type"`
+	PublishDate           string        `json:"publishDate,omitempty"`
 	Teams                 []interface{} `json:"teams"`
 }
 
@@ -59,11 +73,11 @@ func New(rootPath string, name string) *Collection {
 
 	return &Collection{
 		Metadata:              metadata,
-		ApprovalStatus:        "NOT_STARTED",
+		ApprovalStatus:        ApprovalNotStarted,
 		CollectionOwner:       "PUBLISHING_SUPPORT",
 		IsEncrypted:           false,
 		PublishComplete:       false,
-		Type:                  "manual",
+		Type:                  TypeManual,
 		ID:                    id,
 		Name:                  name,
 		TimeSeriesImportFiles: []string{},
@@ -134,35 +148,44 @@ func (c *Collection) Contains(uri string) bool {
 	return false
 }
 
+// ContentPath returns the path of the collection's copy of uri, the in progress copy if there is one then complete then
+// reviewed. Returns false if the collection does not contain uri.
+func (c *Collection) ContentPath(uri string) (string, bool) {
+	for _, dir := range []string{c.Metadata.InProgress, c.Metadata.Complete, c.Metadata.Reviewed} {
+		if p := path.Join(dir, uri); Exists(p) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
 func (c *Collection) AddContent(uri string, fileBytes []byte) error {
 	collectionURI := c.inProgressURI(uri)
 	return WriteContent(collectionURI, fileBytes)
 }
 
-func (c *Collection) MoveContent(absoluteSrcPath string, relSrcPath string, relDestUri string) error {
+// MoveContent copies the file at absoluteSrcPath into the collection at relDestUri. Any links in a .json file to the
+// from uri are rewritten to point to the to uri.
+func (c *Collection) MoveContent(absoluteSrcPath string, relDestUri string, from string, to string) ([]LinkFix, error) {
 	absoluteDest := c.inProgressURI(relDestUri)
 
 	// if not a .json file just copy it into the new location.
 	if filepath.Ext(absoluteSrcPath) != ".json" {
-		return moveContent(absoluteSrcPath, absoluteDest)
+		return nil, moveContent(absoluteSrcPath, absoluteDest)
 	}
 
 	// otherwise we have to read the file into memory so we can check if we need fix any broken links before moving it
 	// to its new location.
 	b, err := ioutil.ReadFile(absoluteSrcPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return WriteContent(absoluteDest, FixBrokenLinks(b, relSrcPath, relDestUri))
-}
 
-func FixBrokenLinks(fileBytes []byte, old string, new string) []byte {
-	fileStr := string(fileBytes)
-	if !strings.Contains(fileStr, old) {
-		return fileBytes
+	b, fixes, err := RewriteLinks(relDestUri, b, from, to)
+	if err != nil {
+		return nil, err
 	}
-	fileStr = strings.Replace(fileStr, old, new, -1)
-	return []byte(fileStr)
+	return fixes, WriteContent(absoluteDest, b)
 }
 
 func (c *Collection) inProgressURI(taxonomyURI string) string {
@@ -0,0 +1,108 @@
+package collections
+
+import (
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Collection content states, the dir of a collection a file is in.
+const (
+	StateInProgress = "inprogress"
+	StateComplete   = "complete"
+	StateReviewed   = "reviewed"
+)
+
+// Copy is a collection's copy of a uri.
+type Copy struct {
+	Collection     string    `json:"collection"`
+	State          string    `json:"state"`
+	Modified       time.Time `json:"modified"`
+	ApprovalStatus string    `json:"approval_status"`
+	Type           string    `json:"type"`
+	PublishDate    string    `json:"publish_date"`
+}
+
+// Conflict is a uri that is in more than one collection and each collection's copies of it.
+type Conflict struct {
+	URI    string `json:"uri"`
+	Copies []Copy `json:"copies"`
+}
+
+// GetCollectionsContaining returns every collection that contains relURI, unlike GetCollectionContaining which
+// returns the first.
+func GetCollectionsContaining(relURI string, cols *Collections) []*Collection {
+	containing := make([]*Collection, 0)
+	for _, c := range cols.Collections {
+		if c.Contains(relURI) {
+			containing = append(containing, c)
+		}
+	}
+	return containing
+}
+
+// FindConflicts returns every uri of a file that is in more than one collection, sorted by uri. A collection with
+// copies of a uri in more than one state has a Copy for each.
+func FindConflicts(cols *Collections) ([]Conflict, error) {
+	copies := make(map[string][]Copy)
+	for _, c := range cols.Collections {
+		states := map[string]string{
+			StateInProgress: c.Metadata.InProgress,
+			StateComplete:   c.Metadata.Complete,
+			StateReviewed:   c.Metadata.Reviewed,
+		}
+
+		for _, state := range []string{StateInProgress, StateComplete, StateReviewed} {
+			dir := states[state]
+			if !Exists(dir) {
+				continue
+			}
+
+			err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+
+				rel, err := filepath.Rel(dir, p)
+				if err != nil {
+					return err
+				}
+
+				uri := path.Join("/", filepath.ToSlash(rel))
+				copies[uri] = append(copies[uri], Copy{
+					Collection:     c.Name,
+					State:          state,
+					Modified:       info.ModTime().UTC(),
+					ApprovalStatus: c.ApprovalStatus,
+					Type:           c.Type,
+					PublishDate:    c.PublishDate,
+				})
+				return nil
+			})
+			if err != nil {
+				return nil, errs.New("failed to list collection content", err, log.Data{"collection": c.Name, "dir": dir})
+			}
+		}
+	}
+
+	conflicts := make([]Conflict, 0)
+	for uri, cs := range copies {
+		names := make(map[string]bool)
+		for _, c := range cs {
+			names[c.Collection] = true
+		}
+
+		if len(names) > 1 {
+			conflicts = append(conflicts, Conflict{URI: uri, Copies: cs})
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].URI < conflicts[j].URI
+	})
+	return conflicts, nil
+}
@@ -0,0 +1,328 @@
+package collections
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"github.com/satori/go.uuid"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	journalExt = ".journal"
+
+	// JournalWrite is a file written into a collection.
+	JournalWrite = "write"
+
+	// JournalCreateCollection is a collection created by a run.
+	JournalCreateCollection = "create_collection"
+)
+
+// JournalEntry is a single change made to a collection. Entries are appended to the collection's journal file before
+// the change is made so a run can be reverted even if it did not complete.
+type JournalEntry struct {
+	Run          string    `json:"run"`
+	Tool         string    `json:"tool"`
+	Time         time.Time `json:"time"`
+	Action       string    `json:"action"`
+	Path         string    `json:"path"`
+	Existed      bool      `json:"existed"`
+	Original     []byte    `json:"original,omitempty"`
+	OriginalHash string    `json:"original_hash,omitempty"`
+	NewHash      string    `json:"new_hash,omitempty"`
+}
+
+// JournalRun is a summary of the changes a single run of a tool made to a collection.
+type JournalRun struct {
+	Run     string    `json:"run"`
+	Tool    string    `json:"tool"`
+	Started time.Time `json:"started"`
+	Changes int       `json:"changes"`
+}
+
+type journal struct {
+	run            string
+	tool           string
+	collectionsDir string
+}
+
+// the journal recording changes for the current run, nil if journaling is not enabled.
+var activeJournal *journal
+
+// StartJournal enables journaling of every change made to the collections in collectionsDir by WriteContent,
+// moveContent and Save. The changes are recorded against a new run id which is returned.
+func StartJournal(collectionsDir string, tool string) string {
+	activeJournal = &journal{
+		run:            uuid.NewV4().String(),
+		tool:           tool,
+		collectionsDir: collectionsDir,
+	}
+
+	log.Event(nil, "journaling collection changes", log.Data{"run": activeJournal.run, "tool": tool})
+	return activeJournal.run
+}
+
+// JournalPath returns the path of the journal file of the named collection.
+func JournalPath(collectionsDir string, name string) string {
+	return path.Join(collectionsDir, name+journalExt)
+}
+
+// ReadJournal returns the entries in the journal of the named collection, oldest first.
+func ReadJournal(collectionsDir string, name string) ([]JournalEntry, error) {
+	entries := make([]JournalEntry, 0)
+	filename := JournalPath(collectionsDir, name)
+	if !Exists(filename) {
+		return entries, nil
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errs.New("failed to read collection journal", err, log.Data{"journal": filename})
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(b)+1)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var e JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, errs.New("failed to unmarshal collection journal entry", err, log.Data{"journal": filename})
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// JournalRuns returns a summary of each run in the journal entries, oldest first.
+func JournalRuns(entries []JournalEntry) []JournalRun {
+	runs := make([]JournalRun, 0)
+	index := make(map[string]int)
+	for _, e := range entries {
+		i, ok := index[e.Run]
+		if !ok {
+			i = len(runs)
+			index[e.Run] = i
+			runs = append(runs, JournalRun{Run: e.Run, Tool: e.Tool, Started: e.Time})
+		}
+		runs[i].Changes++
+	}
+	return runs
+}
+
+// Revert undoes the changes made to the named collection by a run, restoring the original content of every file the
+// run overwrote and removing every file it created. If the run created the collection the collection is deleted. If
+// run is empty the most recent run is reverted. Unless force is true the revert is refused if any file has been
+// changed since the run wrote it.
+func Revert(collectionsDir string, name string, run string, force bool) (*JournalRun, error) {
+	entries, err := ReadJournal(collectionsDir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := JournalRuns(entries)
+	if len(runs) == 0 {
+		return nil, errs.New("collection journal has no runs to revert", nil, log.Data{"collection": name})
+	}
+
+	if run == "" {
+		run = runs[len(runs)-1].Run
+	}
+
+	var target *JournalRun
+	for i := range runs {
+		if runs[i].Run == run {
+			target = &runs[i]
+		}
+	}
+	if target == nil {
+		return nil, errs.New("run not found in collection journal", nil, log.Data{"collection": name, "run": run})
+	}
+
+	remaining := make([]JournalEntry, 0)
+	toRevert := make([]JournalEntry, 0)
+	for _, e := range entries {
+		if e.Run == run {
+			toRevert = append(toRevert, e)
+		} else {
+			remaining = append(remaining, e)
+		}
+	}
+
+	if !force {
+		if err := checkUnchanged(toRevert); err != nil {
+			return nil, err
+		}
+	}
+
+	collectionDeleted := false
+	for i := len(toRevert) - 1; i >= 0; i-- {
+		e := toRevert[i]
+		switch e.Action {
+		case JournalWrite:
+			err = revertWrite(e)
+		case JournalCreateCollection:
+			err = revertCreateCollection(collectionsDir, name)
+			collectionDeleted = true
+		default:
+			err = errs.New("unknown collection journal action", nil, log.Data{"action": e.Action})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if collectionDeleted && len(remaining) == 0 {
+		return target, os.Remove(JournalPath(collectionsDir, name))
+	}
+	return target, writeJournal(collectionsDir, name, remaining)
+}
+
+// checkUnchanged returns an error if any file written by the entries has been changed since the last write to it.
+func checkUnchanged(entries []JournalEntry) error {
+	last := make(map[string]JournalEntry)
+	for _, e := range entries {
+		if e.Action == JournalWrite {
+			last[e.Path] = e
+		}
+	}
+
+	for p, e := range last {
+		if !Exists(p) {
+			continue
+		}
+
+		current, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+
+		if current != e.NewHash {
+			return errs.New("cannot revert as file has been changed since it was written", nil, log.Data{"path": p, "run": e.Run})
+		}
+	}
+	return nil
+}
+
+func revertWrite(e JournalEntry) error {
+	if !e.Existed {
+		if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return WriteContent(e.Path, e.Original)
+}
+
+func revertCreateCollection(collectionsDir string, name string) error {
+	metadata := NewMetadata(collectionsDir, name)
+	if err := os.RemoveAll(metadata.CollectionRoot); err != nil {
+		return err
+	}
+
+	if err := os.Remove(metadata.CollectionJSON); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	log.Event(nil, "reverted collection creation", log.Data{"collection": name})
+	return nil
+}
+
+func writeJournal(collectionsDir string, name string, entries []JournalEntry) error {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	return ioutil.WriteFile(JournalPath(collectionsDir, name), buf.Bytes(), 0644)
+}
+
+// collectionName returns the name of the collection the file is in, false if it is not in a collection.
+func (j *journal) collectionName(filePath string) (string, bool) {
+	rel, err := filepath.Rel(j.collectionsDir, filePath)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")[0], true
+}
+
+func (j *journal) append(name string, e JournalEntry) error {
+	e.Run = j.run
+	e.Tool = j.tool
+	e.Time = time.Now()
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(JournalPath(j.collectionsDir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errs.New("failed to open collection journal", err, log.Data{"collection": name})
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// journalWrite records the current state of filePath before it is overwritten with content hashing to newHash.
+func journalWrite(filePath string, newHash string) error {
+	if activeJournal == nil {
+		return nil
+	}
+
+	name, ok := activeJournal.collectionName(filePath)
+	if !ok {
+		return nil
+	}
+
+	e := JournalEntry{
+		Action:  JournalWrite,
+		Path:    filePath,
+		Existed: Exists(filePath),
+		NewHash: newHash,
+	}
+
+	if e.Existed {
+		b, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		e.Original = b
+		e.OriginalHash = hashBytes(b)
+	}
+	return activeJournal.append(name, e)
+}
+
+// journalCreateCollection records the creation of a collection.
+func journalCreateCollection(c *Collection) error {
+	if activeJournal == nil {
+		return nil
+	}
+
+	return activeJournal.append(c.Name, JournalEntry{
+		Action: JournalCreateCollection,
+		Path:   c.Metadata.CollectionRoot,
+	})
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,184 @@
+package collections
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// snapshot returns the content of every file under dir by its path relative to dir. Journal files are left out.
+func snapshot(t *testing.T, dir string) map[string]string {
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(p) == journalExt {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = readFile(t, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// journalRoot creates a zebedee root with a collection containing files and starts journaling its collections dir.
+func journalRoot(t *testing.T, files map[string]string) (string, string, *Collection) {
+	root, col := testRoot(t, map[string]string{"/master/data.json": `{"uri":"/master"}`})
+	for uri, content := range files {
+		if err := col.AddContent(uri, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collectionsDir := path.Join(root, "collections")
+	StartJournal(collectionsDir, "test")
+	return root, collectionsDir, col
+}
+
+func TestRevert(t *testing.T) {
+	defer func() { activeJournal = nil }()
+
+	root, collectionsDir, col := journalRoot(t, map[string]string{
+		"/a/data.json": `{"uri":"/a","title":"original"}`,
+		"/b/data.json": `{"uri":"/b"}`,
+	})
+	defer os.RemoveAll(root)
+	original := snapshot(t, collectionsDir)
+
+	if err := col.AddContent("/a/data.json", []byte(`{"uri":"/a","title":"first run"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := col.MoveContent(path.Join(root, "master", "/master/data.json"), "/c/data.json", "/master", "/c"); err != nil {
+		t.Fatal(err)
+	}
+	afterFirst := snapshot(t, collectionsDir)
+
+	second := StartJournal(collectionsDir, "test")
+	if err := col.AddContent("/a/data.json", []byte(`{"uri":"/a","title":"second run"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := col.AddContent("/d/data.json", []byte(`{"uri":"/d"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// two writes to the same file in a run are reverted to the content before the first.
+	if err := col.AddContent("/d/data.json", []byte(`{"uri":"/d","title":"again"}`)); err != nil {
+		t.Fatal(err)
+	}
+	activeJournal = nil
+
+	entries, err := ReadJournal(collectionsDir, col.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs := JournalRuns(entries)
+	if len(runs) != 2 || runs[0].Changes != 2 || runs[1].Run != second || runs[1].Changes != 3 {
+		t.Fatalf("expected a run of 2 changes then a run of 3, got %+v", runs)
+	}
+
+	reverted, err := Revert(collectionsDir, col.Name, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reverted.Run != second {
+		t.Errorf("expected the most recent run to be reverted, got %s", reverted.Run)
+	}
+
+	if actual := snapshot(t, collectionsDir); !reflect.DeepEqual(actual, afterFirst) {
+		t.Errorf("expected the collection as it was after the first run %v, got %v", afterFirst, actual)
+	}
+
+	if _, err := Revert(collectionsDir, col.Name, runs[0].Run, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := snapshot(t, collectionsDir); !reflect.DeepEqual(actual, original) {
+		t.Errorf("expected the original collection %v, got %v", original, actual)
+	}
+
+	if _, err := Revert(collectionsDir, col.Name, "", false); err == nil {
+		t.Error("expected a journal without runs not to be reverted")
+	}
+}
+
+func TestRevertCreateCollection(t *testing.T) {
+	defer func() { activeJournal = nil }()
+
+	root, collectionsDir, _ := journalRoot(t, map[string]string{})
+	defer os.RemoveAll(root)
+	original := snapshot(t, collectionsDir)
+
+	col := New(collectionsDir, "created")
+	if err := Save(col); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := col.AddContent("/a/data.json", []byte(`{"uri":"/a"}`)); err != nil {
+		t.Fatal(err)
+	}
+	activeJournal = nil
+
+	if _, err := Revert(collectionsDir, col.Name, "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := snapshot(t, collectionsDir); !reflect.DeepEqual(actual, original) {
+		t.Errorf("expected the created collection to be deleted %v, got %v", original, actual)
+	}
+
+	if Exists(JournalPath(collectionsDir, col.Name)) {
+		t.Error("expected the journal of the deleted collection to be removed")
+	}
+}
+
+func TestRevertChangedFile(t *testing.T) {
+	defer func() { activeJournal = nil }()
+
+	root, collectionsDir, col := journalRoot(t, map[string]string{"/a/data.json": `{"uri":"/a","title":"original"}`})
+	defer os.RemoveAll(root)
+
+	run := StartJournal(collectionsDir, "test")
+	if err := col.AddContent("/a/data.json", []byte(`{"uri":"/a","title":"run"}`)); err != nil {
+		t.Fatal(err)
+	}
+	activeJournal = nil
+
+	// changed by hand after the run, e.g. in Florence.
+	filename := path.Join(col.GetInProgress(), "/a/data.json")
+	if err := ioutil.WriteFile(filename, []byte(`{"uri":"/a","title":"by hand"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Revert(collectionsDir, col.Name, run, false); err == nil {
+		t.Fatal("expected a file changed since the run not to be reverted without force")
+	}
+
+	if actual := readFile(t, filename); actual != `{"uri":"/a","title":"by hand"}` {
+		t.Errorf("expected the changed file to be left, got %s", actual)
+	}
+
+	if _, err := Revert(collectionsDir, col.Name, "unknown", true); err == nil {
+		t.Error("expected an unknown run not to be reverted")
+	}
+
+	if _, err := Revert(collectionsDir, col.Name, run, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := readFile(t, filename); actual != `{"uri":"/a","title":"original"}` {
+		t.Errorf("expected the original content to be restored with force, got %s", actual)
+	}
+}
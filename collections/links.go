@@ -0,0 +1,425 @@
+package collections
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// markdown link targets: [text](/some/uri "title")
+	markdownLinkRegex = regexp.MustCompile(`\]\(\s*<?([^)\s>]+)`)
+
+	// markdown reference style link targets: [1]: /some/uri
+	markdownRefRegex = regexp.MustCompile(`(?m)^\s*\[[^\]]+\]:\s*<?([^\s>]+)`)
+
+	// zebedee markdown tags: <ons-chart path="/some/uri" />
+	markdownTagRegex = regexp.MustCompile(`<ons-[a-z-]+[^>]*?\spath="([^"]+)"`)
+
+	onsHosts = map[string]bool{
+		"ons.gov.uk":     true,
+		"www.ons.gov.uk": true,
+	}
+)
+
+// LinkFix is a record of a single JSON field value changed by the link rewriter.
+type LinkFix struct {
+	URI   string `json:"uri"`
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// RewriteLinks replaces links to the from uri (or any uri beneath it) with the equivalent link under the to uri. Only
+// URI-bearing string values and the link targets of markdown string values are changed - a uri is only matched on a
+// whole path segment so moving /economy/gdp will not touch /economy/gdpsupplement. The returned LinkFix slice
+// contains an entry for each field changed, uri is the page being rewritten and is only used in the report.
+func RewriteLinks(uri string, fileBytes []byte, from string, to string) ([]byte, []LinkFix, error) {
+	return rewriteLinks(uri, fileBytes, from, to, false)
+}
+
+// RewriteLinksExact is RewriteLinks for links to the from uri itself, optionally with a trailing slash, query or
+// fragment. Links to uris beneath from are left unchanged.
+func RewriteLinksExact(uri string, fileBytes []byte, from string, to string) ([]byte, []LinkFix, error) {
+	return rewriteLinks(uri, fileBytes, from, to, true)
+}
+
+func rewriteLinks(uri string, fileBytes []byte, from string, to string, exact bool) ([]byte, []LinkFix, error) {
+	if !json.Valid(fileBytes) {
+		return nil, nil, errs.New("cannot rewrite links as file is not valid json", nil, log.Data{"uri": uri})
+	}
+
+	r := &linkRewriter{
+		src:   fileBytes,
+		uri:   uri,
+		from:  cleanURI(from),
+		to:    cleanURI(to),
+		exact: exact,
+		fixes: make([]LinkFix, 0),
+	}
+
+	if err := r.value("", ""); err != nil {
+		return nil, nil, errs.New("failed to rewrite links", err, log.Data{"uri": uri})
+	}
+
+	if len(r.fixes) == 0 {
+		return fileBytes, r.fixes, nil
+	}
+
+	r.out.Write(r.src[r.copied:])
+	return r.out.Bytes(), r.fixes, nil
+}
+
+// RewriteStrings replaces every string value of the json with the result of replace, keeping the formatting of the rest
+// of the file. replace is called with the path of the field e.g. sections[0].markdown, the key of the field and the
+// value. The returned LinkFix slice contains an entry for each field changed, uri is only used in the report.
+func RewriteStrings(uri string, fileBytes []byte, replace func(field string, key string, s string) string) ([]byte, []LinkFix, error) {
+	if !json.Valid(fileBytes) {
+		return nil, nil, errs.New("cannot rewrite strings as file is not valid json", nil, log.Data{"uri": uri})
+	}
+
+	r := &linkRewriter{
+		src:     fileBytes,
+		uri:     uri,
+		replace: replace,
+		fixes:   make([]LinkFix, 0),
+	}
+
+	if err := r.value("", ""); err != nil {
+		return nil, nil, errs.New("failed to rewrite strings", err, log.Data{"uri": uri})
+	}
+
+	if len(r.fixes) == 0 {
+		return fileBytes, r.fixes, nil
+	}
+
+	r.out.Write(r.src[r.copied:])
+	return r.out.Bytes(), r.fixes, nil
+}
+
+// RewriteURI returns the uri with the from prefix replaced by to. If uri is not from or a child of from it is returned
+// unchanged. Absolute links to the ONS website are matched on their path.
+func RewriteURI(uri string, from string, to string) string {
+	return rewriteURI(uri, cleanURI(from), cleanURI(to), false)
+}
+
+func rewriteURI(uri string, from string, to string, exact bool) string {
+	if strings.HasPrefix(uri, "/") {
+		return replacePathPrefix(uri, from, to, exact)
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil || !onsHosts[strings.ToLower(u.Host)] {
+		return uri
+	}
+
+	p := replacePathPrefix(u.Path, from, to, exact)
+	if p == u.Path {
+		return uri
+	}
+	u.Path = p
+	return u.String()
+}
+
+// replacePathPrefix replaces from in p with to if p is from or, unless exact is set, a child of from.
+func replacePathPrefix(p string, from string, to string, exact bool) string {
+	if !strings.HasPrefix(p, from) {
+		return p
+	}
+
+	rest := p[len(from):]
+	if rest == "" || rest == "/" || strings.ContainsRune("?#", rune(rest[0])) {
+		return to + rest
+	}
+
+	if !exact && rest[0] == '/' {
+		return to + rest
+	}
+	return p
+}
+
+func cleanURI(uri string) string {
+	uri = strings.TrimSuffix(uri, "/")
+	if !strings.HasPrefix(uri, "/") {
+		uri = "/" + uri
+	}
+	return uri
+}
+
+func isURIField(key string) bool {
+	return key == "uri" || key == "url" || key == "href" || strings.HasSuffix(key, "Uri") || strings.HasSuffix(key, "Url")
+}
+
+func isMarkdownField(key string) bool {
+	return key == "markdown"
+}
+
+func isEmailField(key string) bool {
+	return key == "email"
+}
+
+// ExtractLinks returns the distinct internal links in the URI fields and markdown of a page. Links are normalised to
+// their taxonomy uri (no host, query or fragment), email addresses and mailto links are returned as mailto:address.
+func ExtractLinks(fileBytes []byte) ([]string, error) {
+	if !json.Valid(fileBytes) {
+		return nil, errs.New("cannot extract links as file is not valid json", nil, nil)
+	}
+
+	r := &linkRewriter{
+		src:     fileBytes,
+		collect: true,
+		links:   make(map[string]bool),
+	}
+
+	if err := r.value("", ""); err != nil {
+		return nil, errs.New("failed to extract links", err, nil)
+	}
+
+	links := make([]string, 0, len(r.links))
+	for l := range r.links {
+		links = append(links, l)
+	}
+	sort.Strings(links)
+	return links, nil
+}
+
+// NormaliseLink returns the taxonomy uri of an internal link or the mailto:address of an email link. Returns false if
+// the link is external or not a link.
+func NormaliseLink(link string) (string, bool) {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return "", false
+	}
+
+	if strings.HasPrefix(strings.ToLower(link), "mailto:") {
+		addr := strings.SplitN(link[len("mailto:"):], "?", 2)[0]
+		return "mailto:" + strings.ToLower(addr), addr != ""
+	}
+
+	if !strings.HasPrefix(link, "/") {
+		u, err := url.Parse(link)
+		if err != nil || !onsHosts[strings.ToLower(u.Host)] {
+			return "", false
+		}
+		link = u.Path
+	}
+
+	if i := strings.IndexAny(link, "?#"); i >= 0 {
+		link = link[:i]
+	}
+	if link != "/" {
+		link = strings.TrimSuffix(link, "/")
+	}
+	return link, link != ""
+}
+
+func (r *linkRewriter) collectLinks(key string, s string) {
+	add := func(link string) string {
+		if l, ok := NormaliseLink(link); ok {
+			r.links[l] = true
+		}
+		return link
+	}
+
+	switch {
+	case isURIField(key):
+		add(s)
+	case isMarkdownField(key):
+		for _, re := range []*regexp.Regexp{markdownLinkRegex, markdownRefRegex, markdownTagRegex} {
+			replaceSubmatch(re, s, add)
+		}
+	case isEmailField(key) && strings.Contains(s, "@"):
+		add("mailto:" + s)
+	}
+}
+
+// linkRewriter walks the raw JSON bytes of a page copying them to out. Anything that is not a rewritten string value is
+// copied verbatim so the formatting of the original file is kept.
+type linkRewriter struct {
+	src    []byte
+	pos    int
+	copied int
+	out    bytes.Buffer
+	uri    string
+	from   string
+	to     string
+	exact  bool
+	fixes  []LinkFix
+
+	// if collect is true links are added to links rather than being rewritten.
+	collect bool
+	links   map[string]bool
+
+	// if replace is set every string value is rewritten with it rather than just the links.
+	replace func(field string, key string, s string) string
+}
+
+func (r *linkRewriter) value(field string, key string) error {
+	r.skipSpace()
+	if r.pos >= len(r.src) {
+		return fmt.Errorf("unexpected end of json at %q", field)
+	}
+
+	switch r.src[r.pos] {
+	case '{':
+		return r.object(field)
+	case '[':
+		return r.array(field, key)
+	case '"':
+		start := r.pos
+		s, err := r.str()
+		if err != nil {
+			return err
+		}
+		return r.rewrite(start, field, key, s)
+	default:
+		for r.pos < len(r.src) && !strings.ContainsRune(",}] \t\r\n", rune(r.src[r.pos])) {
+			r.pos++
+		}
+		return nil
+	}
+}
+
+func (r *linkRewriter) object(field string) error {
+	r.pos++
+	for {
+		r.skipSpace()
+		if r.src[r.pos] == '}' {
+			r.pos++
+			return nil
+		}
+
+		key, err := r.str()
+		if err != nil {
+			return err
+		}
+
+		r.skipSpace()
+		r.pos++ // colon
+
+		child := key
+		if field != "" {
+			child = field + "." + key
+		}
+
+		if err := r.value(child, key); err != nil {
+			return err
+		}
+
+		r.skipSpace()
+		if r.src[r.pos] == ',' {
+			r.pos++
+		}
+	}
+}
+
+// array items inherit the key of the array so a "markdown": ["..."] field is treated the same as "markdown": "...".
+func (r *linkRewriter) array(field string, key string) error {
+	r.pos++
+	for i := 0; ; i++ {
+		r.skipSpace()
+		if r.src[r.pos] == ']' {
+			r.pos++
+			return nil
+		}
+
+		if err := r.value(fmt.Sprintf("%s[%d]", field, i), key); err != nil {
+			return err
+		}
+
+		r.skipSpace()
+		if r.src[r.pos] == ',' {
+			r.pos++
+		}
+	}
+}
+
+func (r *linkRewriter) str() (string, error) {
+	start := r.pos
+	r.pos++
+	for r.pos < len(r.src) && r.src[r.pos] != '"' {
+		if r.src[r.pos] == '\\' {
+			r.pos++
+		}
+		r.pos++
+	}
+	r.pos++
+
+	var s string
+	if err := json.Unmarshal(r.src[start:r.pos], &s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+func (r *linkRewriter) rewrite(start int, field string, key string, s string) error {
+	if r.collect {
+		r.collectLinks(key, s)
+		return nil
+	}
+
+	var updated string
+	switch {
+	case r.replace != nil:
+		updated = r.replace(field, key, s)
+	case isURIField(key):
+		updated = rewriteURI(s, r.from, r.to, r.exact)
+	case isMarkdownField(key):
+		updated = r.rewriteMarkdown(s)
+	default:
+		return nil
+	}
+
+	if updated == s {
+		return nil
+	}
+
+	// don't escape <, > and & as json.Marshal does, so markdown tags keep the representation they were read with.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(updated); err != nil {
+		return err
+	}
+
+	r.out.Write(r.src[r.copied:start])
+	r.out.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	r.copied = r.pos
+
+	r.fixes = append(r.fixes, LinkFix{URI: r.uri, Field: field, Old: s, New: updated})
+	return nil
+}
+
+func (r *linkRewriter) rewriteMarkdown(md string) string {
+	for _, re := range []*regexp.Regexp{markdownLinkRegex, markdownRefRegex, markdownTagRegex} {
+		md = replaceSubmatch(re, md, func(target string) string {
+			return rewriteURI(target, r.from, r.to, r.exact)
+		})
+	}
+	return md
+}
+
+// replaceSubmatch replaces the first capture group of each match of re with the result of fn.
+func replaceSubmatch(re *regexp.Regexp, s string, fn func(string) string) string {
+	var buf strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+		buf.WriteString(s[last:m[2]])
+		buf.WriteString(fn(s[m[2]:m[3]]))
+		last = m[3]
+	}
+	buf.WriteString(s[last:])
+	return buf.String()
+}
+
+func (r *linkRewriter) skipSpace() {
+	for r.pos < len(r.src) && strings.ContainsRune(" \t\r\n", rune(r.src[r.pos])) {
+		r.pos++
+	}
+}
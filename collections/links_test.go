@@ -0,0 +1,122 @@
+package collections
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRewriteLinks(t *testing.T) {
+	original := `{
+  "uri": "/economy/gdp",
+  "type": "bulletin",
+  "description": {"title": "/economy/gdp is not a link"},
+  "relatedData": [{"uri": "/economy/gdp/datasets/a"}, {"uri": "/economy/gdpsupplement"}],
+  "websiteUrl": "https://www.ons.gov.uk/economy/gdp?x=1#top",
+  "externalUrl": "https://example.com/economy/gdp",
+  "sections": [{"markdown": "See [gdp](/economy/gdp \"GDP\") and <ons-chart path=\"/economy/gdp/abc\" />\n[1]: /economy/gdp/b"}]
+}`
+
+	expected := `{
+  "uri": "/economy/output",
+  "type": "bulletin",
+  "description": {"title": "/economy/gdp is not a link"},
+  "relatedData": [{"uri": "/economy/output/datasets/a"}, {"uri": "/economy/gdpsupplement"}],
+  "websiteUrl": "https://www.ons.gov.uk/economy/output?x=1#top",
+  "externalUrl": "https://example.com/economy/gdp",
+  "sections": [{"markdown": "See [gdp](/economy/output \"GDP\") and <ons-chart path=\"/economy/output/abc\" />\n[1]: /economy/output/b"}]
+}`
+
+	b, fixes, err := RewriteLinks("/economy/gdp", []byte(original), "/economy/gdp", "/economy/output")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != expected {
+		t.Errorf("expected %s\ngot %s", expected, b)
+	}
+
+	fields := make([]string, 0)
+	for _, f := range fixes {
+		fields = append(fields, f.Field)
+	}
+	expectedFields := []string{"uri", "relatedData[0].uri", "websiteUrl", "sections[0].markdown"}
+	if !reflect.DeepEqual(fields, expectedFields) {
+		t.Errorf("expected fixes to %v, got %v", expectedFields, fields)
+	}
+}
+
+func TestRewriteLinksUnchanged(t *testing.T) {
+	original := []byte(`{"uri":"/a","links":[{"uri":"/b"}]}`)
+
+	b, fixes, err := RewriteLinks("/a", original, "/c", "/d")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != string(original) || len(fixes) != 0 {
+		t.Errorf("expected page without links to /c to be unchanged, got %s %+v", b, fixes)
+	}
+}
+
+func TestRewriteLinksExact(t *testing.T) {
+	original := `{"relatedData":[{"uri":"/a/b"},{"uri":"/a/b/c"},{"uri":"/a/b/"}],` +
+		`"markdown":["[b](/a/b#section) and [c](/a/b/c)"],"websiteUrl":"https://www.ons.gov.uk/a/b/c"}`
+	expected := `{"relatedData":[{"uri":"/x"},{"uri":"/a/b/c"},{"uri":"/x/"}],` +
+		`"markdown":["[b](/x#section) and [c](/a/b/c)"],"websiteUrl":"https://www.ons.gov.uk/a/b/c"}`
+
+	b, fixes, err := RewriteLinksExact("/page", []byte(original), "/a/b", "/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != expected {
+		t.Errorf("expected %s\ngot %s", expected, b)
+	}
+
+	if len(fixes) != 3 {
+		t.Errorf("expected 3 fixes, got %+v", fixes)
+	}
+}
+
+func TestRewriteLinksInvalidJSON(t *testing.T) {
+	if _, _, err := RewriteLinks("/a", []byte(`{"uri":`), "/a", "/b"); err == nil {
+		t.Error("expected invalid json to be rejected")
+	}
+}
+
+func TestRewriteURI(t *testing.T) {
+	cases := []struct {
+		uri      string
+		expected string
+	}{
+		{"/a/b", "/c"},
+		{"/a/b/", "/c/"},
+		{"/a/b/d", "/c/d"},
+		{"/a/b?q=1", "/c?q=1"},
+		{"/a/b#s", "/c#s"},
+		{"/a/bc", "/a/bc"},
+		{"/x/a/b", "/x/a/b"},
+		{"http://ons.gov.uk/a/b/d", "http://ons.gov.uk/c/d"},
+		{"https://example.com/a/b", "https://example.com/a/b"},
+	}
+
+	for _, c := range cases {
+		if actual := RewriteURI(c.uri, "/a/b/", "c"); actual != c.expected {
+			t.Errorf("RewriteURI(%q): expected %q, got %q", c.uri, c.expected, actual)
+		}
+	}
+}
+
+func TestExtractLinks(t *testing.T) {
+	page := `{"uri":"/a/","description":{"contact":{"email":"Someone@ONS.gov.uk"}},"links":[{"uri":"https://www.ons.gov.uk/b?x=1"},{"uri":"https://example.com/c"}],"markdown":["[d](/d#top) [e](mailto:e@ons.gov.uk)"]}`
+
+	links, err := ExtractLinks([]byte(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"/a", "/b", "/d", "mailto:e@ons.gov.uk", "mailto:someone@ons.gov.uk"}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("expected %v, got %v", expected, links)
+	}
+}
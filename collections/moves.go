@@ -1,6 +1,7 @@
 package collections
 
 import (
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
 	"github.com/ONSdigital/log.go/log"
 	"io/ioutil"
 	"os"
@@ -17,9 +18,12 @@ type ContentMove struct {
 	MasterDir     string
 }
 
-func MoveContent(move ContentMove) (map[string]string, error) {
+// MoveContent copies the content being moved into the collection at its new location. Returns a map of the master
+// relative src path to the destination uri for each file moved and a record of every link fixed in the moved content.
+func MoveContent(move ContentMove) (map[string]string, []LinkFix, error) {
 	// from -> to
 	completedMoves := make(map[string]string)
+	linkFixes := make([]LinkFix, 0)
 
 	err := filepath.Walk(move.MovingFromAbs, func(absoluteSrcPath string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -36,23 +40,43 @@ func MoveContent(move ContentMove) (map[string]string, error) {
 		// the taxonomy uri the content is being moved to
 		moveToTaxonomyURI := path.Join(move.MovingToRel, uri)
 
-		err = move.Collection.MoveContent(absoluteSrcPath, move.MovingFromRel, moveToTaxonomyURI)
+		fixes, err := move.Collection.MoveContent(absoluteSrcPath, moveToTaxonomyURI, move.MovingFromRel, move.MovingToRel)
 		if err != nil {
 			return err
 		}
+		linkFixes = append(linkFixes, fixes...)
 
 		relSrc, _ := filepath.Rel(move.MasterDir, absoluteSrcPath)
 		completedMoves[relSrc] = moveToTaxonomyURI
 		return nil
 	})
-	return completedMoves, err
+	return completedMoves, linkFixes, err
 }
 
 func FindUsesOfUris(p ContentMove) (map[string]string, error) {
-	log.Event(nil, "Scanning master for uses of uri", log.Data{"uri": p.MovingFromRel})
-	brokenUris := make(map[string]string)
+	uses, err := FindUsesOfAllUris(p.MasterDir, []ContentMove{p})
+	if err != nil {
+		return nil, err
+	}
+	return uses[p.MovingFromRel], nil
+}
+
+// UsesFinder finds the files in master that use the uris being moved by each of the moves. Returns a map of
+// MovingFromRel to the absolute paths of the files using that uri.
+type UsesFinder func(masterDir string, moves []ContentMove) (map[string]map[string]string, error)
+
+// FindUsesOfAllUris scans master once for uses of the uris being moved by each of the moves. Returns a map of
+// MovingFromRel to the files in master containing that uri.
+func FindUsesOfAllUris(masterDir string, moves []ContentMove) (map[string]map[string]string, error) {
+	brokenUris := make(map[string]map[string]string)
+	uris := make([]string, 0)
+	for _, m := range moves {
+		log.Event(nil, "Scanning master for uses of uri", log.Data{"uri": m.MovingFromRel})
+		brokenUris[m.MovingFromRel] = make(map[string]string)
+		uris = append(uris, m.MovingFromRel)
+	}
 
-	err := filepath.Walk(p.MasterDir, func(srcFilePath string, info os.FileInfo, err error) error {
+	err := filepath.Walk(masterDir, func(srcFilePath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -68,38 +92,138 @@ func FindUsesOfUris(p ContentMove) (map[string]string, error) {
 		}
 
 		fileStr := string(b)
-		if strings.Contains(fileStr, p.MovingFromRel) {
-			brokenUris[srcFilePath] = srcFilePath
+		for _, uri := range uris {
+			if strings.Contains(fileStr, uri) {
+				brokenUris[uri][srcFilePath] = srcFilePath
+			}
 		}
 		return nil
 	})
 	return brokenUris, err
 }
 
-func FixUris(p ContentMove, affectedFiles map[string]string, completedMoves map[string]string) ([]string, error) {
+// CheckMoves validates a batch of moves can be applied together. A batch is rejected if two moves overlap (one is
+// moving content inside the other) or are chained (content is moved to, or from, the location of another move) as the
+// outcome would depend on the order the moves are applied. A single move into its own subtree, e.g. /a/b -> /a/b/test,
+// is allowed as the content is copied from master so the move never reads its own output.
+func CheckMoves(moves []ContentMove) error {
+	for i, a := range moves {
+		for _, b := range moves[i+1:] {
+			data := log.Data{
+				"move_a": map[string]string{"src": a.MovingFromRel, "dest": a.MovingToRel},
+				"move_b": map[string]string{"src": b.MovingFromRel, "dest": b.MovingToRel},
+			}
+
+			if isSameOrChild(a.MovingFromRel, b.MovingFromRel) || isSameOrChild(b.MovingFromRel, a.MovingFromRel) {
+				return errs.New("cannot move content as moves overlap", nil, data)
+			}
+
+			if isSameOrChild(a.MovingToRel, b.MovingToRel) || isSameOrChild(b.MovingToRel, a.MovingToRel) {
+				return errs.New("cannot move content as move destinations overlap", nil, data)
+			}
+
+			if isSameOrChild(a.MovingToRel, b.MovingFromRel) || isSameOrChild(b.MovingToRel, a.MovingFromRel) ||
+				isSameOrChild(a.MovingFromRel, b.MovingToRel) || isSameOrChild(b.MovingFromRel, a.MovingToRel) {
+				return errs.New("cannot move content as moves are chained", nil, data)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckAffectedFiles validates that no file affected by a batch of moves has to be changed in more than one
+// collection, either because it links to content moved into different collections or because it is being moved into
+// one collection and has links fixed in another.
+func CheckAffectedFiles(moves []ContentMove, uses map[string]map[string]string) error {
+	owners := make(map[string]string)
+	for _, m := range moves {
+		for _, srcFilePath := range uses[m.MovingFromRel] {
+			relURI, err := filepath.Rel(m.MasterDir, srcFilePath)
+			if err != nil {
+				return err
+			}
+
+			if owner, ok := owners[relURI]; ok && owner != m.Collection.Name {
+				return errs.New("cannot proceed with moves as affected uri would be changed in more than one collection", nil, log.Data{
+					"uri":         relURI,
+					"collections": []string{owner, m.Collection.Name},
+				})
+			}
+			owners[relURI] = m.Collection.Name
+
+			for _, other := range moves {
+				if other.Collection.Name != m.Collection.Name && isSameOrChild(relURI, other.MovingFromRel) {
+					return errs.New("cannot proceed with moves as affected uri is being moved into a different collection", nil, log.Data{
+						"uri":         relURI,
+						"collections": []string{other.Collection.Name, m.Collection.Name},
+					})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// movedBy returns true if the master relative uri is part of the content moved by the move.
+func movedBy(relURI string, m ContentMove) bool {
+	return isSameOrChild(relURI, m.MovingFromRel)
+}
+
+// isSameOrChild returns true if uri is equal to parent or is beneath it in the taxonomy.
+func isSameOrChild(uri string, parent string) bool {
+	uri = cleanURI(uri)
+	return replacePathPrefix(uri, cleanURI(parent), "", false) != uri
+}
+
+// FixUris rewrites the links to the moved content in each of the affected files, adding any file with a link fixed
+// to the collection. Returns the uris of the files fixed and a record of every link changed.
+func FixUris(p ContentMove, affectedFiles map[string]string, completedMoves map[string]string) ([]string, []LinkFix, error) {
 	brokenLinks := make([]string, 0)
+	linkFixes := make([]LinkFix, 0)
 	for _, srcFilePath := range affectedFiles {
 		relURI, err := filepath.Rel(p.MasterDir, srcFilePath)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		_, alreadyMoved := completedMoves[relURI]
-		if alreadyMoved {
+
+		// the links in content moved by this move were fixed when it was copied, rewriting them again would move any
+		// link into a nested destination twice e.g. /a/b -> /a/b/test -> /a/b/test/test.
+		if movedBy(relURI, p) {
 			continue
 		}
 
+		// if the file has been moved by another move fix the moved copy rather than the original.
+		if dest, alreadyMoved := completedMoves[relURI]; alreadyMoved {
+			relURI = strings.TrimPrefix(dest, "/")
+		}
+
+		// if the collection already has a copy of the file (e.g. fixed by an earlier move in the same batch) fix that
+		// copy so the earlier changes are not lost.
+		if inProgress := p.Collection.inProgressURI(relURI); Exists(inProgress) {
+			srcFilePath = inProgress
+		}
+
 		b, err := ioutil.ReadFile(srcFilePath)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		b, fixes, err := RewriteLinks(relURI, b, p.MovingFromRel, p.MovingToRel)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		relPath, _ := filepath.Rel(p.MasterDir, srcFilePath)
+		// the file mentions the uri but not in a link field so there is nothing to fix.
+		if len(fixes) == 0 {
+			continue
+		}
 
-		if err := p.Collection.AddContent(relPath, FixBrokenLinks(b, p.MovingFromRel, p.MovingToRel)); err != nil {
-			return nil, err
+		if err := p.Collection.AddContent(relURI, b); err != nil {
+			return nil, nil, err
 		}
 
 		brokenLinks = append(brokenLinks, relURI)
+		linkFixes = append(linkFixes, fixes...)
 	}
-	return brokenLinks, nil
+	return brokenLinks, linkFixes, nil
 }
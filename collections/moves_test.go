@@ -0,0 +1,195 @@
+package collections
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRoot creates a zebedee root in a temp dir with the master files and an empty collection called test.
+func testRoot(t *testing.T, files map[string]string) (string, *Collection) {
+	root, err := ioutil.TempDir("", "collections")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for uri, content := range files {
+		if err := WriteContent(path.Join(root, "master", uri), []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collectionsDir := path.Join(root, "collections")
+	if err := os.MkdirAll(collectionsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	col := New(collectionsDir, "test")
+	if err := Save(col); err != nil {
+		t.Fatal(err)
+	}
+	return root, col
+}
+
+func readFile(t *testing.T, filename string) string {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestMoveIntoOwnSubtreeRewritesLinksOnce(t *testing.T) {
+	root, col := testRoot(t, map[string]string{
+		"/a/b/data.json":   `{"uri":"/a/b","relatedData":[{"uri":"/a/b/c"}]}`,
+		"/a/b/c/data.json": `{"uri":"/a/b/c"}`,
+		"/x/data.json":     `{"uri":"/x","links":[{"uri":"/a/b"}]}`,
+	})
+	defer os.RemoveAll(root)
+
+	masterDir := path.Join(root, "master")
+	move := ContentMove{
+		Collection:    col,
+		MovingFromAbs: path.Join(masterDir, "/a/b"),
+		MovingFromRel: "/a/b",
+		MovingToRel:   "/a/b/test",
+		MasterDir:     masterDir,
+	}
+
+	if err := CheckMoves([]ContentMove{move}); err != nil {
+		t.Fatalf("expected move into own subtree to be allowed: %v", err)
+	}
+
+	moved, _, err := MoveContent(move)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uses, err := FindUsesOfUris(move)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixed, _, err := FixUris(move, uses, moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fixed) != 1 || fixed[0] != "x/data.json" {
+		t.Errorf("expected only x/data.json to have links fixed, got %v", fixed)
+	}
+
+	expected := map[string]string{
+		"/a/b/test/data.json":   `{"uri":"/a/b/test","relatedData":[{"uri":"/a/b/test/c"}]}`,
+		"/a/b/test/c/data.json": `{"uri":"/a/b/test/c"}`,
+		"/x/data.json":          `{"uri":"/x","links":[{"uri":"/a/b/test"}]}`,
+	}
+	for uri, content := range expected {
+		if actual := readFile(t, path.Join(col.GetInProgress(), uri)); actual != content {
+			t.Errorf("%s: expected %s, got %s", uri, content, actual)
+		}
+	}
+}
+
+func TestFixUrisFixesContentMovedByAnotherMove(t *testing.T) {
+	root, col := testRoot(t, map[string]string{
+		"/a/data.json": `{"uri":"/a","links":[{"uri":"/b"}]}`,
+		"/b/data.json": `{"uri":"/b","links":[{"uri":"/a"}]}`,
+	})
+	defer os.RemoveAll(root)
+
+	masterDir := path.Join(root, "master")
+	moves := []ContentMove{
+		{Collection: col, MovingFromAbs: path.Join(masterDir, "/a"), MovingFromRel: "/a", MovingToRel: "/c", MasterDir: masterDir},
+		{Collection: col, MovingFromAbs: path.Join(masterDir, "/b"), MovingFromRel: "/b", MovingToRel: "/d", MasterDir: masterDir},
+	}
+
+	if err := CheckMoves(moves); err != nil {
+		t.Fatal(err)
+	}
+
+	allMoved := make(map[string]string)
+	for _, m := range moves {
+		moved, _, err := MoveContent(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for src, dest := range moved {
+			allMoved[src] = dest
+		}
+	}
+
+	uses, err := FindUsesOfAllUris(masterDir, moves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range moves {
+		if _, _, err := FixUris(m, uses[m.MovingFromRel], allMoved); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		"/c/data.json": `{"uri":"/c","links":[{"uri":"/d"}]}`,
+		"/d/data.json": `{"uri":"/d","links":[{"uri":"/c"}]}`,
+	}
+	for uri, content := range expected {
+		if actual := readFile(t, path.Join(col.GetInProgress(), uri)); actual != content {
+			t.Errorf("%s: expected %s, got %s", uri, content, actual)
+		}
+	}
+
+	if Exists(path.Join(col.GetInProgress(), "a")) || Exists(path.Join(col.GetInProgress(), "b")) {
+		t.Error("expected the originals of moved content not to be added to the collection")
+	}
+}
+
+func TestCheckMoves(t *testing.T) {
+	move := func(src string, dest string) ContentMove {
+		return ContentMove{MovingFromRel: src, MovingToRel: dest}
+	}
+
+	cases := []struct {
+		name  string
+		moves []ContentMove
+		err   string
+	}{
+		{name: "independent", moves: []ContentMove{move("/a", "/b"), move("/c", "/d")}},
+		{name: "into own subtree", moves: []ContentMove{move("/a/b", "/a/b/test")}},
+		{name: "sibling prefix", moves: []ContentMove{move("/a/b", "/x"), move("/a/bc", "/y")}},
+		{name: "overlapping src", moves: []ContentMove{move("/a", "/x"), move("/a/b", "/y")}, err: "moves overlap"},
+		{name: "overlapping dest", moves: []ContentMove{move("/a", "/x"), move("/b", "/x/y")}, err: "destinations overlap"},
+		{name: "chained", moves: []ContentMove{move("/a", "/b"), move("/b/c", "/d")}, err: "chained"},
+	}
+
+	for _, c := range cases {
+		err := CheckMoves(c.moves)
+		if c.err == "" && err != nil {
+			t.Errorf("%s: expected no error, got %v", c.name, err)
+		}
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%s: expected error containing %q, got %v", c.name, c.err, err)
+		}
+	}
+}
+
+func TestIsSameOrChild(t *testing.T) {
+	cases := map[string]bool{
+		"/a/b":        true,
+		"/a/b/":       true,
+		"/a/b/c":      true,
+		"a/b/c.json":  true,
+		"/a/bc":       false,
+		"/a":          false,
+		"/x/a/b/data": false,
+	}
+	for uri, expected := range cases {
+		if actual := isSameOrChild(filepath.ToSlash(uri), "/a/b"); actual != expected {
+			t.Errorf("isSameOrChild(%q, /a/b): expected %t, got %t", uri, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package collections
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MovePlan is the complete outcome of a batch of content moves worked out without writing anything to disk. A plan
+// can be saved, reviewed and later applied with ApplyPlan.
+type MovePlan struct {
+	CreatedAt           time.Time      `json:"created_at"`
+	MasterDir           string         `json:"master_dir"`
+	CollectionsDir      string         `json:"collections_dir"`
+	MasterFingerprint   string         `json:"master_fingerprint"`
+	Moves               []PlannedMove  `json:"moves"`
+	NewCollections      []string       `json:"new_collections"`
+	Copies              []*PlannedFile `json:"copies"`
+	LinkFixes           []*PlannedFile `json:"link_fixes"`
+	BlockingCollections []Blocker      `json:"blocking_collections"`
+	Collisions          []Collision    `json:"collisions"`
+
+	// the rewritten content of each .json file keyed by collection name + dest uri.
+	content map[string][]byte
+}
+
+// PlannedMove is a single src -> dest move in a plan.
+type PlannedMove struct {
+	Src        string `json:"src"`
+	Dest       string `json:"dest"`
+	Collection string `json:"collection"`
+}
+
+// PlannedFile is a file the plan will write into a collection. Src is the absolute path of the file the content is
+// read from and ResultHash the sha256 of the content that will be written.
+type PlannedFile struct {
+	Collection    string    `json:"collection"`
+	Src           string    `json:"src"`
+	Dest          string    `json:"dest"`
+	ResultHash    string    `json:"result_hash"`
+	FieldsChanged []LinkFix `json:"fields_changed,omitempty"`
+}
+
+// Blocker is a page the plan needs to change that is already in another collection.
+type Blocker struct {
+	URI        string `json:"uri"`
+	Collection string `json:"collection"`
+}
+
+// Collision is a move destination that already has content.
+type Collision struct {
+	URI    string `json:"uri"`
+	Reason string `json:"reason"`
+}
+
+func (p *MovePlan) key(collection string, uri string) string {
+	return collection + ":" + path.Join("/", uri)
+}
+
+// IsBlocked returns true if the plan cannot be applied because of blocking collections or destination collisions.
+func (p *MovePlan) IsBlocked() bool {
+	return len(p.BlockingCollections) > 0 || len(p.Collisions) > 0
+}
+
+// PlanMoves works out the outcome of applying the moves - the files to copy, the pages with links to fix, any
+// collections blocking the moves and any destination collisions. Nothing is written to disk. If create is true
+// collections that do not exist yet are added to the plan's NewCollections rather than being created. findUses is
+// used to find the pages in master affected by the moves.
+func PlanMoves(masterDir string, collectionsDir string, moves []PlannedMove, create bool, findUses UsesFinder) (*MovePlan, error) {
+	fingerprint, err := MasterFingerprint(masterDir)
+	if err != nil {
+		return nil, err
+	}
+
+	cols, err := GetCollections(collectionsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &MovePlan{
+		CreatedAt:           time.Now(),
+		MasterDir:           masterDir,
+		CollectionsDir:      collectionsDir,
+		MasterFingerprint:   fingerprint,
+		Moves:               moves,
+		NewCollections:      make([]string, 0),
+		Copies:              make([]*PlannedFile, 0),
+		LinkFixes:           make([]*PlannedFile, 0),
+		BlockingCollections: make([]Blocker, 0),
+		Collisions:          make([]Collision, 0),
+		content:             make(map[string][]byte),
+	}
+
+	contentMoves := make([]ContentMove, 0)
+	for _, m := range moves {
+		col, err := cols.GetByName(m.Collection)
+		if err != nil {
+			if !create {
+				return nil, err
+			}
+			col = New(collectionsDir, m.Collection)
+			cols.Add(col)
+			plan.NewCollections = append(plan.NewCollections, m.Collection)
+		}
+
+		contentMoves = append(contentMoves, ContentMove{
+			Collection:    col,
+			MovingFromAbs: path.Join(masterDir, m.Src),
+			MovingFromRel: m.Src,
+			MovingToRel:   m.Dest,
+			MasterDir:     masterDir,
+		})
+	}
+
+	if err := CheckMoves(contentMoves); err != nil {
+		return nil, err
+	}
+
+	uses, err := findUses(masterDir, contentMoves)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CheckAffectedFiles(contentMoves, uses); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*PlannedFile)
+	moved := make(map[string]string)
+	for _, m := range contentMoves {
+		if err := plan.planCopies(m, files, moved); err != nil {
+			return nil, err
+		}
+	}
+
+	// fix the links once everything has been planned so pages linking to more than one moved uri get every fix.
+	blocked := make(map[string]bool)
+	for _, m := range contentMoves {
+		for _, srcFilePath := range uses[m.MovingFromRel] {
+			relURI, err := filepath.Rel(masterDir, srcFilePath)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, c := range GetCollectionsContaining(relURI, cols) {
+				if key := relURI + " " + c.Name; c.Name != m.Collection.Name && !blocked[key] {
+					plan.BlockingCollections = append(plan.BlockingCollections, Blocker{URI: relURI, Collection: c.Name})
+					blocked[key] = true
+				}
+			}
+
+			if err := plan.planLinkFix(m, relURI, srcFilePath, files, moved); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sortPlannedFiles(plan.Copies)
+	sortPlannedFiles(plan.LinkFixes)
+	return plan, nil
+}
+
+func (p *MovePlan) planCopies(m ContentMove, files map[string]*PlannedFile, moved map[string]string) error {
+	return filepath.Walk(m.MovingFromAbs, func(absoluteSrcPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if m.MovingFromAbs == absoluteSrcPath || info.IsDir() {
+			return nil
+		}
+
+		uri, _ := filepath.Rel(m.MovingFromAbs, absoluteSrcPath)
+		dest := path.Join(m.MovingToRel, uri)
+
+		if Exists(path.Join(p.MasterDir, dest)) {
+			p.Collisions = append(p.Collisions, Collision{URI: dest, Reason: "destination exists in master"})
+		}
+		if m.Collection.Contains(dest) {
+			p.Collisions = append(p.Collisions, Collision{URI: dest, Reason: "destination exists in collection " + m.Collection.Name})
+		}
+
+		f := &PlannedFile{
+			Collection:    m.Collection.Name,
+			Src:           absoluteSrcPath,
+			Dest:          dest,
+			FieldsChanged: make([]LinkFix, 0),
+		}
+
+		if filepath.Ext(absoluteSrcPath) != ".json" {
+			if f.ResultHash, err = hashFile(absoluteSrcPath); err != nil {
+				return err
+			}
+		} else {
+			b, err := ioutil.ReadFile(absoluteSrcPath)
+			if err != nil {
+				return err
+			}
+
+			b, fixes, err := RewriteLinks(dest, b, m.MovingFromRel, m.MovingToRel)
+			if err != nil {
+				return err
+			}
+			p.setContent(f, b, fixes)
+		}
+
+		relSrc, _ := filepath.Rel(p.MasterDir, absoluteSrcPath)
+		moved[relSrc] = dest
+		files[p.key(f.Collection, dest)] = f
+		p.Copies = append(p.Copies, f)
+		return nil
+	})
+}
+
+func (p *MovePlan) planLinkFix(m ContentMove, relURI string, srcFilePath string, files map[string]*PlannedFile, moved map[string]string) error {
+	// the copy of content moved by m was planned from the master original with m's links already fixed, fixing the
+	// planned copy again would rewrite the links of a nested move twice.
+	if movedBy(relURI, m) {
+		return nil
+	}
+
+	// if the file is being moved by another move fix the moved copy rather than the original.
+	if dest, alreadyMoved := moved[relURI]; alreadyMoved {
+		relURI = dest
+	}
+	relURI = path.Join("/", relURI)
+
+	k := p.key(m.Collection.Name, relURI)
+	f, planned := files[k]
+
+	var b []byte
+	if planned {
+		b = p.content[k]
+	} else {
+		// if the collection already has a copy of the file fix that copy so the existing changes are not lost.
+		if inProgress := m.Collection.inProgressURI(relURI); Exists(inProgress) {
+			srcFilePath = inProgress
+		}
+
+		var err error
+		if b, err = ioutil.ReadFile(srcFilePath); err != nil {
+			return err
+		}
+	}
+
+	b, fixes, err := RewriteLinks(relURI, b, m.MovingFromRel, m.MovingToRel)
+	if err != nil {
+		return err
+	}
+
+	// the file mentions the uri but not in a link field so there is nothing to fix.
+	if len(fixes) == 0 {
+		return nil
+	}
+
+	if !planned {
+		f = &PlannedFile{
+			Collection:    m.Collection.Name,
+			Src:           srcFilePath,
+			Dest:          relURI,
+			FieldsChanged: make([]LinkFix, 0),
+		}
+		files[k] = f
+		p.LinkFixes = append(p.LinkFixes, f)
+	}
+	p.setContent(f, b, fixes)
+	return nil
+}
+
+func (p *MovePlan) setContent(f *PlannedFile, b []byte, fixes []LinkFix) {
+	f.ResultHash = hashBytes(b)
+	f.FieldsChanged = append(f.FieldsChanged, fixes...)
+	p.content[p.key(f.Collection, f.Dest)] = b
+}
+
+// ApplyPlan executes a saved plan. The plan is worked out again against the current state of master and the
+// collections and is only applied if master has not changed and the outcome is exactly the same as the saved plan.
+func ApplyPlan(saved *MovePlan) error {
+	if saved.IsBlocked() {
+		return errs.New("cannot apply plan as it has blocking collections or destination collisions", nil, log.Data{
+			"blocking_collections": saved.BlockingCollections,
+			"collisions":           saved.Collisions,
+		})
+	}
+
+	fingerprint, err := MasterFingerprint(saved.MasterDir)
+	if err != nil {
+		return err
+	}
+
+	if fingerprint != saved.MasterFingerprint {
+		return errs.New("cannot apply plan as master has changed since the plan was made", nil, log.Data{
+			"plan_created_at": saved.CreatedAt,
+			"master":          saved.MasterDir,
+		})
+	}
+
+	// always scan master when applying so the outcome does not depend on the state of any link index.
+	current, err := PlanMoves(saved.MasterDir, saved.CollectionsDir, saved.Moves, len(saved.NewCollections) > 0, FindUsesOfAllUris)
+	if err != nil {
+		return err
+	}
+
+	if err := current.matches(saved); err != nil {
+		return err
+	}
+
+	for _, name := range current.NewCollections {
+		if err := Save(New(saved.CollectionsDir, name)); err != nil {
+			return err
+		}
+	}
+
+	cols, err := GetCollections(saved.CollectionsDir)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range append(current.Copies, current.LinkFixes...) {
+		col, err := cols.GetByName(f.Collection)
+		if err != nil {
+			return err
+		}
+
+		dest := col.inProgressURI(f.Dest)
+		if b, ok := current.content[current.key(f.Collection, f.Dest)]; ok {
+			err = WriteContent(dest, b)
+		} else {
+			err = moveContent(f.Src, dest)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Event(nil, "move plan applied successfully", log.Data{
+		"copies":     len(current.Copies),
+		"link_fixes": len(current.LinkFixes),
+	})
+	return nil
+}
+
+// matches returns an error describing the first difference between the outcome of the two plans.
+func (p *MovePlan) matches(saved *MovePlan) error {
+	if p.IsBlocked() {
+		return errs.New("cannot apply plan as collections have changed since the plan was made", nil, log.Data{
+			"blocking_collections": p.BlockingCollections,
+			"collisions":           p.Collisions,
+		})
+	}
+
+	if !equalStrings(p.NewCollections, saved.NewCollections) {
+		return errs.New("cannot apply plan as collections have changed since the plan was made", nil, log.Data{
+			"planned_new_collections": saved.NewCollections,
+			"current_new_collections": p.NewCollections,
+		})
+	}
+
+	expected := plannedHashes(append(saved.Copies, saved.LinkFixes...))
+	actual := plannedHashes(append(p.Copies, p.LinkFixes...))
+	if len(expected) != len(actual) {
+		return errs.New("cannot apply plan as the outcome no longer matches the plan", nil, log.Data{
+			"planned_files": len(expected),
+			"current_files": len(actual),
+		})
+	}
+
+	for k, hash := range expected {
+		if actual[k] != hash {
+			return errs.New("cannot apply plan as the outcome no longer matches the plan", nil, log.Data{"file": k})
+		}
+	}
+	return nil
+}
+
+// MasterFingerprint returns a hash of the path, size and modification time of every file in master. Any file added,
+// removed or changed in master will change the fingerprint.
+func MasterFingerprint(masterDir string) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(masterDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(masterDir, p)
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", rel, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", errs.New("failed to fingerprint master", err, log.Data{"master": masterDir})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SavePlan writes the plan to filename as JSON.
+func SavePlan(plan *MovePlan, filename string) error {
+	b, err := json.MarshalIndent(plan, "", "	")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, b, 0644)
+}
+
+// LoadPlan reads a plan previously written by SavePlan.
+func LoadPlan(filename string) (*MovePlan, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errs.New("failed to read plan file", err, log.Data{"plan": filename})
+	}
+
+	var plan MovePlan
+	if err := json.Unmarshal(b, &plan); err != nil {
+		return nil, errs.New("failed to unmarshal plan file", err, log.Data{"plan": filename})
+	}
+	return &plan, nil
+}
+
+func hashFile(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func plannedHashes(files []*PlannedFile) map[string]string {
+	hashes := make(map[string]string)
+	for _, f := range files {
+		hashes[f.Collection+":"+f.Dest+":"+f.Src] = f.ResultHash
+	}
+	return hashes
+}
+
+func equalStrings(a []string, b []string) bool {
+	return strings.Join(a, "\x00") == strings.Join(b, "\x00")
+}
+
+func sortPlannedFiles(files []*PlannedFile) {
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Collection != files[j].Collection {
+			return files[i].Collection < files[j].Collection
+		}
+		return files[i].Dest < files[j].Dest
+	})
+}
@@ -0,0 +1,158 @@
+package collections
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestPlanMoveIntoOwnSubtree(t *testing.T) {
+	root, col := testRoot(t, map[string]string{
+		"/a/b/data.json":   `{"uri":"/a/b","relatedData":[{"uri":"/a/b/c"}]}`,
+		"/a/b/c/data.json": `{"uri":"/a/b/c"}`,
+		"/x/data.json":     `{"uri":"/x","links":[{"uri":"/a/b"}]}`,
+	})
+	defer os.RemoveAll(root)
+
+	masterDir := path.Join(root, "master")
+	collectionsDir := path.Join(root, "collections")
+	moves := []PlannedMove{{Src: "/a/b", Dest: "/a/b/test", Collection: col.Name}}
+
+	plan, err := PlanMoves(masterDir, collectionsDir, moves, false, FindUsesOfAllUris)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.IsBlocked() {
+		t.Fatalf("expected plan not to be blocked: %+v %+v", plan.BlockingCollections, plan.Collisions)
+	}
+
+	if len(plan.Copies) != 2 {
+		t.Errorf("expected 2 copies, got %d", len(plan.Copies))
+	}
+
+	if len(plan.LinkFixes) != 1 || plan.LinkFixes[0].Dest != "/x/data.json" {
+		t.Errorf("expected only /x/data.json to have links fixed, got %+v", plan.LinkFixes)
+	}
+
+	for _, f := range plan.Copies {
+		for _, fix := range f.FieldsChanged {
+			if strings.Contains(fix.New, "/test/test") {
+				t.Errorf("%s: link %s rewritten more than once to %s", f.Dest, fix.Old, fix.New)
+			}
+		}
+	}
+
+	filename := path.Join(root, "plan.json")
+	if err := SavePlan(plan, filename); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := LoadPlan(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ApplyPlan(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"/a/b/test/data.json":   `{"uri":"/a/b/test","relatedData":[{"uri":"/a/b/test/c"}]}`,
+		"/a/b/test/c/data.json": `{"uri":"/a/b/test/c"}`,
+		"/x/data.json":          `{"uri":"/x","links":[{"uri":"/a/b/test"}]}`,
+	}
+	for uri, content := range expected {
+		if actual := readFile(t, path.Join(col.GetInProgress(), uri)); actual != content {
+			t.Errorf("%s: expected %s, got %s", uri, content, actual)
+		}
+	}
+}
+
+func TestApplyPlanRejectsChangedMaster(t *testing.T) {
+	root, col := testRoot(t, map[string]string{
+		"/a/data.json": `{"uri":"/a"}`,
+	})
+	defer os.RemoveAll(root)
+
+	masterDir := path.Join(root, "master")
+	moves := []PlannedMove{{Src: "/a", Dest: "/b", Collection: col.Name}}
+
+	plan, err := PlanMoves(masterDir, path.Join(root, "collections"), moves, false, FindUsesOfAllUris)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteContent(path.Join(masterDir, "/c/data.json"), []byte(`{"uri":"/c"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ApplyPlan(plan); err == nil || !strings.Contains(err.Error(), "master has changed") {
+		t.Errorf("expected master has changed error, got %v", err)
+	}
+
+	if col.Contains("/b/data.json") {
+		t.Error("expected nothing to be written to the collection")
+	}
+}
+
+func TestApplyPlanRejectsBlockedPlan(t *testing.T) {
+	root, col := testRoot(t, map[string]string{
+		"/a/data.json": `{"uri":"/a"}`,
+		"/x/data.json": `{"uri":"/x","links":[{"uri":"/a"}]}`,
+	})
+	defer os.RemoveAll(root)
+
+	collectionsDir := path.Join(root, "collections")
+	other := New(collectionsDir, "other")
+	if err := Save(other); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.AddContent("/x/data.json", []byte(`{"uri":"/x","links":[{"uri":"/a"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	moves := []PlannedMove{{Src: "/a", Dest: "/b", Collection: col.Name}}
+	plan, err := PlanMoves(path.Join(root, "master"), collectionsDir, moves, false, FindUsesOfAllUris)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.BlockingCollections) != 1 || plan.BlockingCollections[0].Collection != "other" {
+		t.Fatalf("expected the plan to be blocked by collection other, got %+v", plan.BlockingCollections)
+	}
+
+	if err := ApplyPlan(plan); err == nil {
+		t.Error("expected blocked plan not to be applied")
+	}
+}
+
+func TestPlanReportsEveryBlockingCollection(t *testing.T) {
+	root, col := testRoot(t, map[string]string{
+		"/a/data.json": `{"uri":"/a"}`,
+		"/x/data.json": `{"uri":"/x","links":[{"uri":"/a"}]}`,
+	})
+	defer os.RemoveAll(root)
+
+	collectionsDir := path.Join(root, "collections")
+	for _, name := range []string{"another", "other"} {
+		c := New(collectionsDir, name)
+		if err := Save(c); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.AddContent("/x/data.json", []byte(`{"uri":"/x","links":[{"uri":"/a"}]}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	moves := []PlannedMove{{Src: "/a", Dest: "/b", Collection: col.Name}}
+	plan, err := PlanMoves(path.Join(root, "master"), collectionsDir, moves, false, FindUsesOfAllUris)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.BlockingCollections) != 2 || plan.BlockingCollections[0].Collection != "another" || plan.BlockingCollections[1].Collection != "other" {
+		t.Errorf("expected the plan to be blocked by collections another and other, got %+v", plan.BlockingCollections)
+	}
+}
@@ -0,0 +1,270 @@
+package collections
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"github.com/satori/go.uuid"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	previousDir       = "previous"
+	publishLogDateFmt = "2006-01-02-15-04"
+)
+
+// PublishedCollection is the publish-log entry written for a published collection. It is the collection json with
+// the details of the publish added.
+type PublishedCollection struct {
+	*Collection
+	PublishDate      time.Time       `json:"publishDate"`
+	PublishStartDate time.Time       `json:"publishStartDate"`
+	PublishEndDate   time.Time       `json:"publishEndDate"`
+	PublishResults   []PublishResult `json:"publishResults"`
+}
+
+// PublishResult is the result of a single publishing transaction.
+type PublishResult struct {
+	Transaction Transaction `json:"transaction"`
+}
+
+// Transaction is the record of the uris published by a transaction.
+type Transaction struct {
+	ID        string    `json:"id"`
+	StartDate time.Time `json:"startDate"`
+	EndDate   time.Time `json:"endDate"`
+	URIInfos  []URIInfo `json:"uriInfos"`
+	Errors    []string  `json:"errors"`
+}
+
+// URIInfo is the record of a single uri published.
+type URIInfo struct {
+	URI    string    `json:"uri"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Action string    `json:"action"`
+	Status string    `json:"status"`
+	Sha    string    `json:"sha"`
+}
+
+// Publish simulates a Zebedee publish of a reviewed collection without Zebedee. The collection must be approved, not
+// encrypted, and every file in it must be in reviewed. The reviewed content is copied into master - archiving the
+// existing version of each page changed under previous/vN - a publish-log entry is written and the collection is moved
+// into the publish-log.
+func Publish(col *Collection, masterDir string, publishLogDir string) (*PublishedCollection, error) {
+	data := log.Data{"collection": col.Name}
+	log.Event(nil, "publishing collection", data)
+
+	// the content of an encrypted collection can only be decrypted by Zebedee, copying it would put ciphertext in master.
+	if col.IsEncrypted {
+		return nil, errs.New("cannot publish collection as it is encrypted", nil, data)
+	}
+
+	if col.ApprovalStatus != ApprovalComplete {
+		data["approval_status"] = col.ApprovalStatus
+		return nil, errs.New("cannot publish collection as it is not approved", nil, data)
+	}
+
+	for _, dir := range []string{col.GetInProgress(), col.GetComplete()} {
+		files, err := listFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(files) > 0 {
+			data["dir"] = dir
+			data["files"] = files
+			return nil, errs.New("cannot publish collection as not all files are reviewed", nil, data)
+		}
+	}
+
+	uris, err := listFiles(col.GetReviewed())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(uris) == 0 {
+		return nil, errs.New("cannot publish collection as it has no reviewed content", nil, data)
+	}
+
+	start := time.Now()
+	if err := archivePreviousVersions(masterDir, uris); err != nil {
+		return nil, err
+	}
+
+	infos := make([]URIInfo, 0)
+	for _, uri := range uris {
+		dest := path.Join(masterDir, uri)
+		action := "created"
+		if Exists(dest) {
+			action = "updated"
+		}
+
+		uriStart := time.Now()
+		src := path.Join(col.GetReviewed(), uri)
+		if err := moveContent(src, dest); err != nil {
+			return nil, errs.New("failed to publish file to master", err, log.Data{"uri": uri, "collection": col.Name})
+		}
+
+		sha, err := hashFile(dest)
+		if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, URIInfo{
+			URI:    uri,
+			Start:  uriStart,
+			End:    time.Now(),
+			Action: action,
+			Status: "committed",
+			Sha:    sha,
+		})
+	}
+	end := time.Now()
+
+	col.PublishComplete = true
+	published := &PublishedCollection{
+		Collection:       col,
+		PublishDate:      start,
+		PublishStartDate: start,
+		PublishEndDate:   end,
+		PublishResults: []PublishResult{{
+			Transaction: Transaction{
+				ID:        uuid.NewV4().String(),
+				StartDate: start,
+				EndDate:   end,
+				URIInfos:  infos,
+				Errors:    []string{},
+			},
+		}},
+	}
+
+	if err := writePublishLog(published, publishLogDir); err != nil {
+		return nil, err
+	}
+
+	log.Event(nil, "collection published successfully", log.Data{"collection": col.Name, "uris": len(uris)})
+	return published, nil
+}
+
+// PublishLogName returns the name, without extension, of the publish-log entry for a collection published at date.
+func PublishLogName(date time.Time, collectionName string) string {
+	return date.Format(publishLogDateFmt) + "-" + collectionName
+}
+
+// writePublishLog writes the publish-log json and moves the collection files into the publish-log, removing the
+// collection.
+func writePublishLog(published *PublishedCollection, publishLogDir string) error {
+	col := published.Collection
+	name := PublishLogName(published.PublishDate, col.Name)
+
+	if err := os.MkdirAll(publishLogDir, filePerm); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(published, "", "	")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path.Join(publishLogDir, name+".json"), b, 0644); err != nil {
+		return errs.New("failed to write publish-log entry", err, log.Data{"collection": col.Name})
+	}
+
+	if err := os.Rename(col.GetRootPath(), path.Join(publishLogDir, name)); err != nil {
+		return errs.New("failed to move collection into publish-log", err, log.Data{"collection": col.Name})
+	}
+
+	// the collection no longer exists so any journal of changes to it can no longer be reverted.
+	journal := JournalPath(filepath.Dir(col.Metadata.CollectionJSON), col.Name)
+	if err := os.Remove(journal); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Remove(col.Metadata.CollectionJSON)
+}
+
+// archivePreviousVersions copies the current files of every page being published into a new previous/vN dir.
+func archivePreviousVersions(masterDir string, uris []string) error {
+	pageDirs := make(map[string]bool)
+	for _, uri := range uris {
+		if Exists(path.Join(masterDir, uri)) {
+			pageDirs[path.Dir(uri)] = true
+		}
+	}
+
+	for dir := range pageDirs {
+		pageDir := path.Join(masterDir, dir)
+		version, err := nextVersion(path.Join(pageDir, previousDir))
+		if err != nil {
+			return err
+		}
+
+		versionDir := path.Join(pageDir, previousDir, version)
+		infos, err := ioutil.ReadDir(pageDir)
+		if err != nil {
+			return err
+		}
+
+		for _, info := range infos {
+			if info.IsDir() {
+				continue
+			}
+
+			if err := moveContent(path.Join(pageDir, info.Name()), path.Join(versionDir, info.Name())); err != nil {
+				return errs.New("failed to archive previous version", err, log.Data{"page": dir, "version": version})
+			}
+		}
+		log.Event(nil, "archived previous version", log.Data{"page": dir, "version": version})
+	}
+	return nil
+}
+
+// nextVersion returns the name of the next version dir e.g. v3 if v1 and v2 exist.
+func nextVersion(previous string) (string, error) {
+	latest := 0
+	if Exists(previous) {
+		infos, err := ioutil.ReadDir(previous)
+		if err != nil {
+			return "", err
+		}
+
+		for _, info := range infos {
+			if n, err := strconv.Atoi(strings.TrimPrefix(info.Name(), "v")); err == nil && info.IsDir() && n > latest {
+				latest = n
+			}
+		}
+	}
+	return fmt.Sprintf("v%d", latest+1), nil
+}
+
+// listFiles returns the uris of all the files under dir relative to dir.
+func listFiles(dir string) ([]string, error) {
+	uris := make([]string, 0)
+	if !Exists(dir) {
+		return uris, nil
+	}
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dir, p)
+		uris = append(uris, path.Join("/", filepath.ToSlash(rel)))
+		return nil
+	})
+	sort.Strings(uris)
+	return uris, err
+}
@@ -0,0 +1,169 @@
+package collections
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+// reviewedCollection writes the files to the reviewed dir of col and approves it.
+func reviewedCollection(t *testing.T, col *Collection, files map[string]string) *Collection {
+	for uri, content := range files {
+		if err := WriteContent(path.Join(col.GetReviewed(), uri), []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	col.ApprovalStatus = ApprovalComplete
+	return col
+}
+
+func TestPublish(t *testing.T) {
+	root, col := testRoot(t, map[string]string{
+		"/a/data.json": `{"uri":"/a","title":"old"}`,
+		"/a/chart.png": "old chart",
+	})
+	defer os.RemoveAll(root)
+
+	col = reviewedCollection(t, col, map[string]string{
+		"/a/data.json": `{"uri":"/a","title":"new"}`,
+		"/b/data.json": `{"uri":"/b"}`,
+	})
+
+	masterDir := path.Join(root, "master")
+	publishLogDir := path.Join(root, "publish-log")
+	published, err := Publish(col, masterDir, publishLogDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	infos := published.PublishResults[0].Transaction.URIInfos
+	if len(infos) != 2 || infos[0].URI != "/a/data.json" || infos[0].Action != "updated" || infos[1].Action != "created" {
+		t.Errorf("expected /a/data.json updated and /b/data.json created, got %+v", infos)
+	}
+
+	expected := map[string]string{
+		"/a/data.json":             `{"uri":"/a","title":"new"}`,
+		"/a/previous/v1/data.json": `{"uri":"/a","title":"old"}`,
+		"/a/previous/v1/chart.png": "old chart",
+		"/b/data.json":             `{"uri":"/b"}`,
+	}
+	for uri, content := range expected {
+		if actual := readFile(t, path.Join(masterDir, uri)); actual != content {
+			t.Errorf("%s: expected %s, got %s", uri, content, actual)
+		}
+	}
+
+	name := PublishLogName(published.PublishDate, col.Name)
+	if !Exists(path.Join(publishLogDir, name+".json")) || !Exists(path.Join(publishLogDir, name)) {
+		t.Error("expected the publish-log entry and collection files to be written")
+	}
+
+	if Exists(col.Metadata.CollectionJSON) || Exists(col.GetRootPath()) {
+		t.Error("expected the collection to be removed")
+	}
+}
+
+func TestPublishRejects(t *testing.T) {
+	cases := map[string]func(col *Collection){
+		"encrypted": func(col *Collection) {
+			col.IsEncrypted = true
+		},
+		"approval failed": func(col *Collection) {
+			col.ApprovalStatus = ApprovalError
+		},
+		"not approved": func(col *Collection) {
+			col.ApprovalStatus = ApprovalNotStarted
+		},
+		"content in progress": func(col *Collection) {
+			if err := col.AddContent("/c/data.json", []byte(`{"uri":"/c"}`)); err != nil {
+				t.Fatal(err)
+			}
+		},
+	}
+
+	for name, change := range cases {
+		root, col := testRoot(t, map[string]string{"/a/data.json": `{"uri":"/a","title":"old"}`})
+		col = reviewedCollection(t, col, map[string]string{"/a/data.json": `{"uri":"/a","title":"new"}`})
+		change(col)
+
+		masterDir := path.Join(root, "master")
+		if _, err := Publish(col, masterDir, path.Join(root, "publish-log")); err == nil {
+			t.Errorf("%s: expected the collection not to be published", name)
+		}
+
+		if actual := readFile(t, path.Join(masterDir, "/a/data.json")); actual != `{"uri":"/a","title":"old"}` {
+			t.Errorf("%s: expected master to be unchanged, got %s", name, actual)
+		}
+		os.RemoveAll(root)
+	}
+}
+
+func TestPublishNoContent(t *testing.T) {
+	root, col := testRoot(t, map[string]string{})
+	defer os.RemoveAll(root)
+
+	col.ApprovalStatus = ApprovalComplete
+	if _, err := Publish(col, path.Join(root, "master"), path.Join(root, "publish-log")); err == nil {
+		t.Error("expected a collection without reviewed content not to be published")
+	}
+}
+
+func TestArchivePreviousVersions(t *testing.T) {
+	root, _ := testRoot(t, map[string]string{
+		"/a/data.json":             `{"uri":"/a"}`,
+		"/a/previous/v1/data.json": `{"uri":"/a","v":1}`,
+		"/a/previous/v2/data.json": `{"uri":"/a","v":2}`,
+		"/a/child/data.json":       `{"uri":"/a/child"}`,
+	})
+	defer os.RemoveAll(root)
+
+	masterDir := path.Join(root, "master")
+	if err := archivePreviousVersions(masterDir, []string{"/a/data.json", "/new/data.json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := readFile(t, path.Join(masterDir, "/a/previous/v3/data.json")); actual != `{"uri":"/a"}` {
+		t.Errorf("expected the current version to be archived as v3, got %s", actual)
+	}
+
+	if !Exists(path.Join(masterDir, "/a/data.json")) {
+		t.Error("expected the current version to be copied, it is replaced when the page is published")
+	}
+
+	if !Exists(path.Join(masterDir, "/a/child/data.json")) {
+		t.Error("expected child pages not to be archived")
+	}
+
+	if Exists(path.Join(masterDir, "/new")) {
+		t.Error("expected nothing to be archived for a new page")
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collections")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	previous := path.Join(dir, previousDir)
+	if v, err := nextVersion(previous); err != nil || v != "v1" {
+		t.Errorf("expected v1 without a previous dir, got %s %v", v, err)
+	}
+
+	for _, name := range []string{"v1", "v2", "v10", "other"} {
+		if err := os.MkdirAll(path.Join(previous, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// files are not versions.
+	if err := ioutil.WriteFile(path.Join(previous, "v20"), []byte("not a version"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := nextVersion(previous); err != nil || v != "v11" {
+		t.Errorf("expected v11, got %s %v", v, err)
+	}
+}
@@ -31,6 +31,10 @@ func Save(c *Collection) error {
 		return errs.New("cannot create collection as a collection with this name already exists", nil, log.Data{"name": c.Name})
 	}
 
+	if err := journalCreateCollection(c); err != nil {
+		return errs.New("error journaling collection creation", err, log.Data{"name": c.Name})
+	}
+
 	if err := createCollectionDirectories(c); err != nil {
 		return errs.New("error creating collection directories", err, log.Data{"name": c.Name})
 	}
@@ -111,6 +115,10 @@ func WriteContent(uri string, fileBytes []byte) error {
 	if err := os.MkdirAll(dirs, filePerm); err != nil {
 		return err
 	}
+
+	if err := journalWrite(uri, hashBytes(fileBytes)); err != nil {
+		return errs.New("error journaling write", err, log.Data{"uri": uri})
+	}
 	return ioutil.WriteFile(uri, fileBytes, filePerm)
 }
 
@@ -121,6 +129,17 @@ func moveContent(srcFilePath string, collectionURI string) error {
 		return err
 	}
 
+	if activeJournal != nil {
+		newHash, err := hashFile(srcFilePath)
+		if err != nil {
+			return err
+		}
+
+		if err := journalWrite(collectionURI, newHash); err != nil {
+			return errs.New("error journaling write", err, log.Data{"uri": collectionURI})
+		}
+	}
+
 	destFile, err := os.Create(collectionURI)
 	if err != nil {
 		return err
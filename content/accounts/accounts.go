@@ -0,0 +1,310 @@
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Zebedee permission groups.
+const (
+	Administrators        = "administrators"
+	DigitalPublishingTeam = "digitalPublishingTeam"
+)
+
+const (
+	accessMappingFile = "accessMapping.json"
+	dateFormat        = "2006-01-02T15:04:05.000Z"
+)
+
+// DefaultPermissions maps admins to both Zebedee permission groups and editors to the publishing team. Users with
+// any other role, e.g. viewer, are in neither group and only see the collections their teams are given.
+var DefaultPermissions = map[string][]string{
+	"admin":  {Administrators, DigitalPublishingTeam},
+	"editor": {DigitalPublishingTeam},
+	"viewer": {},
+}
+
+var filenameRegex = regexp.MustCompile(`[^a-z0-9]`)
+
+// Seed is the users, teams and sessions to create in a new zebedee root. Permissions maps each user role to the
+// Zebedee permission groups its users are added to, if empty DefaultPermissions is used.
+type Seed struct {
+	Users       []User              `json:"users"`
+	Teams       []Team              `json:"teams"`
+	Sessions    []Session           `json:"sessions"`
+	Permissions map[string][]string `json:"permissions"`
+}
+
+// User is a user to create with a plain text password, it is hashed before being written.
+type User struct {
+	Name              string `json:"name"`
+	Email             string `json:"email"`
+	Password          string `json:"password"`
+	Role              string `json:"role"`
+	TemporaryPassword bool   `json:"temporary_password"`
+}
+
+// Team is a team to create and the emails of its members.
+type Team struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+}
+
+// Session is a logged in session to create for a user so it can be used without logging in e.g. by load tests. If
+// ID is empty a random id is generated.
+type Session struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+// Dirs is the zebedee dirs accounts are written to.
+type Dirs struct {
+	Users       string
+	Teams       string
+	Sessions    string
+	Permissions string
+}
+
+// Summary is what was written to the zebedee dirs.
+type Summary struct {
+	Users          int      `json:"users"`
+	Teams          int      `json:"teams"`
+	Sessions       []string `json:"sessions"`
+	Administrators []string `json:"administrators"`
+	Publishers     []string `json:"publishers"`
+}
+
+// zebedeeUser is the Zebedee on disk format of a user. Zebedee creates the user keyring the first time they log in if
+// the user does not have one.
+type zebedeeUser struct {
+	Name              string   `json:"name"`
+	Email             string   `json:"email"`
+	PasswordHash      string   `json:"passwordHash"`
+	Inactive          bool     `json:"inactive"`
+	TemporaryPassword bool     `json:"temporaryPassword"`
+	LastAdmin         string   `json:"lastAdmin"`
+	Keyring           *Keyring `json:"keyring,omitempty"`
+}
+
+type zebedeeTeam struct {
+	ID      int      `json:"id"`
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+}
+
+type zebedeeSession struct {
+	ID         string `json:"id"`
+	Email      string `json:"email"`
+	Start      string `json:"start"`
+	LastAccess string `json:"lastAccess"`
+}
+
+type accessMapping struct {
+	Administrators        []string         `json:"administrators"`
+	DigitalPublishingTeam []string         `json:"digitalPublishingTeam"`
+	Collections           map[string][]int `json:"collections"`
+}
+
+// Load reads a seed file and checks it is valid.
+func Load(filename string) (*Seed, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("error reading accounts seed file: %s", filename))
+	}
+
+	var s Seed
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("error unmarshalling accounts seed file: %s", filename))
+	}
+
+	if len(s.Permissions) == 0 {
+		s.Permissions = DefaultPermissions
+	}
+
+	if err := s.validate(); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("invalid accounts seed file: %s", filename))
+	}
+	return &s, nil
+}
+
+func (s *Seed) validate() error {
+	for role, groups := range s.Permissions {
+		for _, g := range groups {
+			if g != Administrators && g != DigitalPublishingTeam {
+				return errors.New(fmt.Sprintf("role %q has unknown permission group %q expected %s or %s", role, g, Administrators, DigitalPublishingTeam))
+			}
+		}
+	}
+
+	emails := make(map[string]bool)
+	for i, u := range s.Users {
+		if u.Email == "" || u.Password == "" {
+			return errors.New(fmt.Sprintf("user %d is missing an email or password", i))
+		}
+
+		if emails[strings.ToLower(u.Email)] {
+			return errors.New(fmt.Sprintf("user email is not unique: %s", u.Email))
+		}
+		emails[strings.ToLower(u.Email)] = true
+
+		if _, ok := s.Permissions[u.Role]; !ok {
+			return errors.New(fmt.Sprintf("user %s has unknown role %q", u.Email, u.Role))
+		}
+	}
+
+	teams := make(map[string]bool)
+	for i, t := range s.Teams {
+		if t.Name == "" {
+			return errors.New(fmt.Sprintf("team %d is missing a name", i))
+		}
+
+		if teams[filename(t.Name)] {
+			return errors.New(fmt.Sprintf("team name is not unique: %s", t.Name))
+		}
+		teams[filename(t.Name)] = true
+
+		for _, m := range t.Members {
+			if !emails[strings.ToLower(m)] {
+				return errors.New(fmt.Sprintf("team %s member is not a user: %s", t.Name, m))
+			}
+		}
+	}
+
+	for _, session := range s.Sessions {
+		if !emails[strings.ToLower(session.Email)] {
+			return errors.New(fmt.Sprintf("session user is not a user: %s", session.Email))
+		}
+	}
+	return nil
+}
+
+// Write creates the users, teams, sessions and permissions of the seed in the Zebedee on disk formats. If there are
+// collectionKeys, a map of collection ids to collection keys, every user is given a keyring and the keys are added to
+// the keyrings of the users in either permission group, as Zebedee does for the collections it creates. newID returns
+// a random id for sessions without one.
+func Write(s *Seed, dirs Dirs, collectionKeys map[string][]byte, newID func() (string, error)) (*Summary, error) {
+	summary := &Summary{
+		Sessions:       make([]string, 0),
+		Administrators: make([]string, 0),
+		Publishers:     make([]string, 0),
+	}
+
+	admin := ""
+	mapping := accessMapping{
+		Administrators:        make([]string, 0),
+		DigitalPublishingTeam: make([]string, 0),
+		Collections:           make(map[string][]int),
+	}
+
+	publishers := make(map[string]bool)
+	for _, u := range s.Users {
+		for _, g := range s.Permissions[u.Role] {
+			publishers[u.Email] = true
+			if g == Administrators {
+				mapping.Administrators = append(mapping.Administrators, u.Email)
+				if admin == "" {
+					admin = u.Email
+				}
+			} else {
+				mapping.DigitalPublishingTeam = append(mapping.DigitalPublishingTeam, u.Email)
+			}
+		}
+	}
+
+	for _, u := range s.Users {
+		hash, err := HashPassword(u.Password)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("error hashing password for user: %s", u.Email))
+		}
+
+		zu := zebedeeUser{
+			Name:              u.Name,
+			Email:             u.Email,
+			PasswordHash:      hash,
+			TemporaryPassword: u.TemporaryPassword,
+			LastAdmin:         admin,
+		}
+
+		if len(collectionKeys) > 0 {
+			keys := collectionKeys
+			if !publishers[u.Email] {
+				keys = nil
+			}
+
+			if zu.Keyring, err = GenerateKeyring(u.Password, keys); err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("error generating keyring for user: %s", u.Email))
+			}
+		}
+
+		if err := writeJSON(filepath.Join(dirs.Users, filename(u.Email)+".json"), zu); err != nil {
+			return nil, err
+		}
+		summary.Users++
+	}
+
+	for i, t := range s.Teams {
+		zt := zebedeeTeam{ID: i + 1, Name: t.Name, Members: t.Members}
+		if zt.Members == nil {
+			zt.Members = make([]string, 0)
+		}
+
+		if err := writeJSON(filepath.Join(dirs.Teams, filename(t.Name)+".json"), zt); err != nil {
+			return nil, err
+		}
+		summary.Teams++
+	}
+
+	now := time.Now().UTC().Format(dateFormat)
+	for _, session := range s.Sessions {
+		id := session.ID
+		if id == "" {
+			var err error
+			if id, err = newID(); err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("error generating session id for user: %s", session.Email))
+			}
+		}
+
+		zs := zebedeeSession{ID: id, Email: session.Email, Start: now, LastAccess: now}
+		if err := writeJSON(filepath.Join(dirs.Sessions, id+".json"), zs); err != nil {
+			return nil, err
+		}
+		summary.Sessions = append(summary.Sessions, id)
+	}
+
+	if err := writeJSON(filepath.Join(dirs.Permissions, accessMappingFile), mapping); err != nil {
+		return nil, err
+	}
+	summary.Administrators = mapping.Administrators
+	summary.Publishers = mapping.DigitalPublishingTeam
+	return summary, nil
+}
+
+// filename returns the name Zebedee stores a user or team under, the lowercase letters and digits of its email or
+// name.
+func filename(s string) string {
+	return filenameRegex.ReplaceAllString(strings.ToLower(s), "")
+}
+
+func writeJSON(filename string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error marshalling %s", filepath.Base(filename)))
+	}
+
+	if _, err := os.Stat(filename); err == nil {
+		return errors.New(fmt.Sprintf("refusing to overwrite existing file: %s", filename))
+	}
+
+	if err := ioutil.WriteFile(filename, b, 0644); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error writing %s", filename))
+	}
+	return nil
+}
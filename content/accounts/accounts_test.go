@@ -0,0 +1,101 @@
+package accounts
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testDirs(t *testing.T) (string, Dirs) {
+	root, err := ioutil.TempDir("", "accounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dirs := Dirs{
+		Users:       filepath.Join(root, "users"),
+		Teams:       filepath.Join(root, "teams"),
+		Sessions:    filepath.Join(root, "sessions"),
+		Permissions: filepath.Join(root, "permissions"),
+	}
+
+	for _, dir := range []string{dirs.Users, dirs.Teams, dirs.Sessions, dirs.Permissions} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root, dirs
+}
+
+func readUser(t *testing.T, dirs Dirs, email string) zebedeeUser {
+	b, err := ioutil.ReadFile(filepath.Join(dirs.Users, filename(email)+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var u zebedeeUser
+	if err := json.Unmarshal(b, &u); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestWriteKeyrings(t *testing.T) {
+	seed := &Seed{
+		Users: []User{
+			{Name: "Admin", Email: "admin@ons.gov.uk", Password: "admin password", Role: "admin"},
+			{Name: "Editor", Email: "editor@ons.gov.uk", Password: "editor password", Role: "editor"},
+			{Name: "Viewer", Email: "viewer@ons.gov.uk", Password: "viewer password", Role: "viewer"},
+		},
+		Permissions: DefaultPermissions,
+	}
+
+	root, dirs := testDirs(t)
+	defer os.RemoveAll(root)
+
+	key, err := NewCollectionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Write(seed, dirs, map[string][]byte{"col-1": key}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range seed.Users[:2] {
+		zu := readUser(t, dirs, u.Email)
+		if zu.Keyring == nil {
+			t.Fatalf("%s: expected a keyring", u.Email)
+		}
+
+		if _, keys := unlock(t, zu.Keyring, u.Password); !bytes.Equal(keys["col-1"], key) {
+			t.Errorf("%s: expected the collection key in the keyring, got %v", u.Email, keys)
+		}
+	}
+
+	viewer := readUser(t, dirs, "viewer@ons.gov.uk")
+	if viewer.Keyring == nil || len(viewer.Keyring.Keys) != 0 {
+		t.Errorf("expected the viewer to have a keyring without collection keys, got %+v", viewer.Keyring)
+	}
+}
+
+func TestWriteWithoutCollectionKeys(t *testing.T) {
+	seed := &Seed{
+		Users:       []User{{Name: "Admin", Email: "admin@ons.gov.uk", Password: "admin password", Role: "admin"}},
+		Permissions: DefaultPermissions,
+	}
+
+	root, dirs := testDirs(t)
+	defer os.RemoveAll(root)
+
+	if _, err := Write(seed, dirs, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if u := readUser(t, dirs, "admin@ons.gov.uk"); u.Keyring != nil {
+		t.Error("expected Zebedee to be left to create the keyring")
+	}
+}
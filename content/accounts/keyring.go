@@ -0,0 +1,178 @@
+package accounts
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// The parameters of the cryptolite classes Zebedee encrypts collections and user keyrings with. Keys derived from a
+// password use the same salt size and PBKDF2WithHmacSHA1 parameters as password hashes.
+const (
+	CollectionKeySize = 32
+	ivSize            = aes.BlockSize
+	privateKeySize    = 2048
+)
+
+// Keyring is the Zebedee on disk format of a user keyring. PrivateKey is encrypted with a key derived from the user's
+// password and PrivateKeySalt, Keys maps collection ids to their collection keys encrypted with PublicKey.
+type Keyring struct {
+	PrivateKeySalt string            `json:"privateKeySalt"`
+	PrivateKey     string            `json:"privateKey"`
+	PublicKey      string            `json:"publicKey"`
+	Keys           map[string]string `json:"keys"`
+}
+
+// NewCollectionKey returns a random collection key.
+func NewCollectionKey() ([]byte, error) {
+	key := make([]byte, CollectionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "error generating collection key")
+	}
+	return key, nil
+}
+
+// EncryptContent returns b encrypted with key as Zebedee encrypts the content of a collection: a random IV followed by b
+// encrypted with AES/CTR.
+func EncryptContent(key []byte, b []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating content cipher")
+	}
+
+	encrypted := make([]byte, ivSize+len(b))
+	if _, err := rand.Read(encrypted[:ivSize]); err != nil {
+		return nil, errors.Wrap(err, "error generating content iv")
+	}
+
+	cipher.NewCTR(block, encrypted[:ivSize]).XORKeyStream(encrypted[ivSize:], b)
+	return encrypted, nil
+}
+
+// DecryptContent returns the content b encrypted by EncryptContent.
+func DecryptContent(key []byte, b []byte) ([]byte, error) {
+	if len(b) < ivSize {
+		return nil, errors.New("encrypted content is shorter than its iv")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating content cipher")
+	}
+
+	decrypted := make([]byte, len(b)-ivSize)
+	cipher.NewCTR(block, b[:ivSize]).XORKeyStream(decrypted, b[ivSize:])
+	return decrypted, nil
+}
+
+// GenerateKeyring returns a new keyring unlocked by password holding keys, a map of collection ids to collection keys.
+func GenerateKeyring(password string, keys map[string][]byte) (*Keyring, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, privateKeySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating keyring key pair")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "error generating keyring salt")
+	}
+
+	privateKey, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding keyring private key")
+	}
+
+	wrapped, err := EncryptContent(pbkdf2SHA1([]byte(password), salt, hashIterations, hashSize), privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encrypting keyring private key")
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding keyring public key")
+	}
+
+	k := &Keyring{
+		PrivateKeySalt: base64.StdEncoding.EncodeToString(salt),
+		PrivateKey:     base64.StdEncoding.EncodeToString(wrapped),
+		PublicKey:      base64.StdEncoding.EncodeToString(publicKey),
+		Keys:           make(map[string]string),
+	}
+
+	for id, key := range keys {
+		encrypted, err := encryptOAEP(&priv.PublicKey, key)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("error encrypting collection key: %s", id))
+		}
+		k.Keys[id] = base64.StdEncoding.EncodeToString(encrypted)
+	}
+	return k, nil
+}
+
+// encryptOAEP encrypts msg as Java's RSA/ECB/OAEPWithSHA-256AndMGF1Padding cipher does, with SHA-256 as the OAEP hash
+// and SHA-1 as the MGF1 hash. The crypto/rsa OAEP functions use the same hash for both.
+func encryptOAEP(pub *rsa.PublicKey, msg []byte) ([]byte, error) {
+	k := pub.Size()
+	hLen := sha256.Size
+	if len(msg) > k-2*hLen-2 {
+		return nil, errors.New("message too long for RSA key size")
+	}
+
+	em := make([]byte, k)
+	seed := em[1 : 1+hLen]
+	db := em[1+hLen:]
+
+	lHash := sha256.Sum256(nil)
+	copy(db, lHash[:])
+	db[len(db)-len(msg)-1] = 1
+	copy(db[len(db)-len(msg):], msg)
+
+	if _, err := rand.Read(seed); err != nil {
+		return nil, errors.Wrap(err, "error generating OAEP seed")
+	}
+
+	mgf1XOR(db, sha1.New(), seed)
+	mgf1XOR(seed, sha1.New(), db)
+
+	m := new(big.Int).SetBytes(em)
+	c := new(big.Int).Exp(m, big.NewInt(int64(pub.E)), pub.N)
+
+	out := make([]byte, k)
+	b := c.Bytes()
+	copy(out[k-len(b):], b)
+	return out, nil
+}
+
+// mgf1XOR XORs out with the MGF1 mask generated from seed as described in RFC 8017 appendix B.2.1.
+func mgf1XOR(out []byte, h hash.Hash, seed []byte) {
+	counter := make([]byte, 4)
+	done := 0
+	for done < len(out) {
+		h.Reset()
+		h.Write(seed)
+		h.Write(counter)
+		digest := h.Sum(nil)
+
+		for i := 0; i < len(digest) && done < len(out); i++ {
+			out[done] ^= digest[i]
+			done++
+		}
+
+		for i := 3; i >= 0; i-- {
+			counter[i]++
+			if counter[i] != 0 {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package accounts
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"testing"
+)
+
+// unlock returns the private key of the keyring and its collection keys as Zebedee reads them when the user logs in.
+func unlock(t *testing.T, k *Keyring, password string) (*rsa.PrivateKey, map[string][]byte) {
+	salt, err := base64.StdEncoding.DecodeString(k.PrivateKeySalt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(k.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := DecryptContent(pbkdf2SHA1([]byte(password), salt, hashIterations, hashSize), wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(b)
+	if err != nil {
+		return nil, nil
+	}
+	priv := parsed.(*rsa.PrivateKey)
+
+	keys := make(map[string][]byte)
+	for id, encrypted := range k.Keys {
+		c, err := base64.StdEncoding.DecodeString(encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[id] = decryptOAEP(t, priv, c)
+	}
+	return priv, keys
+}
+
+// decryptOAEP reverses encryptOAEP, SHA-256 as the OAEP hash and SHA-1 as the MGF1 hash.
+func decryptOAEP(t *testing.T, priv *rsa.PrivateKey, c []byte) []byte {
+	em := make([]byte, priv.Size())
+	b := new(big.Int).Exp(new(big.Int).SetBytes(c), priv.D, priv.N).Bytes()
+	copy(em[len(em)-len(b):], b)
+
+	seed := em[1 : 1+sha256.Size]
+	db := em[1+sha256.Size:]
+	mgf1XOR(seed, sha1.New(), db)
+	mgf1XOR(db, sha1.New(), seed)
+
+	lHash := sha256.Sum256(nil)
+	if em[0] != 0 || !bytes.Equal(db[:sha256.Size], lHash[:]) {
+		t.Fatal("invalid OAEP padding")
+	}
+
+	i := bytes.IndexByte(db[sha256.Size:], 1)
+	if i < 0 {
+		t.Fatal("invalid OAEP padding")
+	}
+	return db[sha256.Size+i+1:]
+}
+
+func TestGenerateKeyring(t *testing.T) {
+	key, err := NewCollectionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := GenerateKeyring("one two three", map[string][]byte{"col-1": key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, keys := unlock(t, k, "one two three")
+	if priv == nil {
+		t.Fatal("expected the password to unlock the private key")
+	}
+
+	public, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if base64.StdEncoding.EncodeToString(public) != k.PublicKey {
+		t.Error("expected the public key to match the private key")
+	}
+
+	if len(keys) != 1 || !bytes.Equal(keys["col-1"], key) {
+		t.Errorf("expected the collection key to be in the keyring, got %v", keys)
+	}
+
+	if priv, _ := unlock(t, k, "wrong"); priv != nil {
+		t.Error("expected the wrong password not to unlock the private key")
+	}
+}
+
+func TestEncryptContent(t *testing.T) {
+	key, err := NewCollectionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte(`{"uri":"/a"}`)
+	encrypted, err := EncryptContent(key, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(encrypted) != ivSize+len(content) || bytes.Contains(encrypted, content) {
+		t.Errorf("expected the content to be encrypted after an iv, got %v", encrypted)
+	}
+
+	again, err := EncryptContent(key, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(encrypted, again) {
+		t.Error("expected a new iv each time the content is encrypted")
+	}
+
+	decrypted, err := DecryptContent(key, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, content) {
+		t.Errorf("expected %s, got %s", content, decrypted)
+	}
+}
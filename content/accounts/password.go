@@ -0,0 +1,81 @@
+package accounts
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// The parameters of the cryptolite Password class Zebedee hashes and verifies passwords with.
+const (
+	saltSize       = 16
+	hashSize       = 32
+	hashIterations = 1024
+)
+
+// saltLength is the length of the base64 encoded salt at the start of a password hash.
+var saltLength = base64.StdEncoding.EncodedLen(saltSize)
+
+// HashPassword returns the password hashed in the format Zebedee verifies: the base64 encoded random salt followed by
+// the base64 encoded PBKDF2WithHmacSHA1 hash of the password.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrap(err, "error generating password salt")
+	}
+	return hashPassword(password, salt), nil
+}
+
+// VerifyPassword returns true if the password matches the hash, as Zebedee checks it when a user logs in.
+func VerifyPassword(password string, hash string) bool {
+	if len(hash) <= saltLength {
+		return false
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(hash[:saltLength])
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashPassword(password, salt)), []byte(hash)) == 1
+}
+
+func hashPassword(password string, salt []byte) string {
+	key := pbkdf2SHA1([]byte(password), salt, hashIterations, hashSize)
+	return base64.StdEncoding.EncodeToString(salt) + base64.StdEncoding.EncodeToString(key)
+}
+
+// pbkdf2SHA1 derives a key of keyLen bytes from the password as described in RFC 8018 section 5.2 using HMAC-SHA1
+// as the pseudorandom function.
+func pbkdf2SHA1(password []byte, salt []byte, iterations int, keyLen int) []byte {
+	prf := hmac.New(sha1.New, password)
+	blocks := (keyLen + prf.Size() - 1) / prf.Size()
+
+	key := make([]byte, 0, blocks*prf.Size())
+	counter := make([]byte, 4)
+	for block := 1; block <= blocks; block++ {
+		binary.BigEndian.PutUint32(counter, uint32(block))
+
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter)
+		u := prf.Sum(nil)
+
+		t := make([]byte, len(u))
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
@@ -0,0 +1,80 @@
+package accounts
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// The cryptolite format: a 16 byte salt and a 256 bit PBKDF2WithHmacSHA1 hash of 1024 iterations, both base64
+// encoded. The salt is bytes 0 to 15 and the hash was produced with an independent PBKDF2WithHmacSHA1 implementation.
+const (
+	knownPassword = "one two three"
+	knownHash     = "AAECAwQFBgcICQoLDA0ODw==fSay6ETgNu8l+CYOcFm95jNLAkVEBcX9a+T9+uN/3II="
+)
+
+func TestVerifyPasswordKnownHash(t *testing.T) {
+	if !VerifyPassword(knownPassword, knownHash) {
+		t.Error("expected known password to match known hash")
+	}
+
+	if VerifyPassword("one two four", knownHash) {
+		t.Error("expected wrong password not to match known hash")
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword(knownPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hash) != len(knownHash) {
+		t.Errorf("expected hash of length %d, got %q", len(knownHash), hash)
+	}
+
+	if !VerifyPassword(knownPassword, hash) {
+		t.Error("expected password to match its hash")
+	}
+
+	other, err := HashPassword(knownPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.HasPrefix(other, hash[:saltLength]) {
+		t.Error("expected each hash to have a random salt")
+	}
+}
+
+func TestVerifyPasswordInvalidHash(t *testing.T) {
+	for _, hash := range []string{"", "AAECAwQFBgcICQoLDA0ODw==", "not base64 at all!!!!!!!hash"} {
+		if VerifyPassword(knownPassword, hash) {
+			t.Errorf("expected invalid hash %q not to match", hash)
+		}
+	}
+}
+
+// RFC 6070 PBKDF2 HMAC-SHA1 test vectors.
+func TestPBKDF2SHA1(t *testing.T) {
+	cases := []struct {
+		password   string
+		salt       string
+		iterations int
+		keyLen     int
+		expected   string
+	}{
+		{"password", "salt", 1, 20, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{"password", "salt", 2, 20, "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+		{"password", "salt", 4096, 20, "4b007901b765489abead49d926f721d065a429c1"},
+		{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 25, "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038"},
+		{"pass\x00word", "sa\x00lt", 4096, 16, "56fa6aa75548099dcc37d7f03425e0c3"},
+	}
+
+	for _, c := range cases {
+		actual := hex.EncodeToString(pbkdf2SHA1([]byte(c.password), []byte(c.salt), c.iterations, c.keyLen))
+		if actual != c.expected {
+			t.Errorf("%q %q %d: expected %s, got %s", c.password, c.salt, c.iterations, c.expected, actual)
+		}
+	}
+}
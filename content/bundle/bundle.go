@@ -0,0 +1,22 @@
+// Package bundle holds the run script templates and default content built into the content generator so it can be run
+// from any directory. Run go generate after changing anything in templates/ or default-content/.
+package bundle
+
+//go:generate go run gen.go
+
+// RunCMSTemplate returns the bundled run-cms.sh template.
+func RunCMSTemplate() string {
+	return runTemplates["cms"]
+}
+
+// RunTemplate returns the bundled run script template of an app in the local publishing stack, e.g. "florence" for
+// templates/run.florence.template.txt.
+func RunTemplate(name string) (string, bool) {
+	t, ok := runTemplates[name]
+	return t, ok
+}
+
+// DefaultContent returns the bundled default content zip.
+func DefaultContent() []byte {
+	return []byte(defaultContentZip)
+}
@@ -0,0 +1,14 @@
+// Code generated by go generate; DO NOT EDIT.
+// Sources: templates/run.*.template.txt, default-content/
+
+package bundle
+
+var runTemplates = map[string]string{
+	"babbage":          "#!/bin/bash\n\n###########################################\n## generated by dp-zebedee-utils/content ##\n###########################################\n\n# Babbage renders pages, in publishing mode it reads the content from Zebedee.\n{{range .Env}}export {{.Name}}={{printf \"%q\" .Value}}\n{{end}}\nexport JAVA_OPTS=\" -Xmx1204m -Xdebug -Xrunjdwp:transport=dt_socket,address=8000,server=y,suspend=n\"\n\ncd {{.Dir}} && \\\nmvn clean package dependency:copy-dependencies -Dmaven.test.skip=true && \\\njava $JAVA_OPTS \\\n -Drestolino.files=target/web \\\n -Drestolino.classes=target/classes \\\n -Drestolino.packageprefix=com.github.onsdigital.babbage.api \\\n -cp \"target/classes:target/dependency/*\" \\\n com.github.davidcarboni.restolino.Main\n",
+	"cms":              "#!/bin/bash\n\n###########################################\n## generated by dp-zebedee-utils/content ##\n###########################################\n\n# Sets the root Zebedee directory required to run Zebedee in publishing / CMS mode.\nexport zebedee_root={{.ZebedeeRoot}}\n\n# Zebedee runs by default on port :8082\nexport PORT=\"${PORT:-8082}\"\n\nexport JAVA_OPTS=\" -Xmx1204m -Xdebug -Xrunjdwp:transport=dt_socket,address=8002,server=y,suspend=n\"\n\n# Restolino configuration\nexport RESTOLINO_STATIC=\"src/main/resources/files\"\nexport RESTOLINO_CLASSES=\"zebedee-cms/target/classes\"\nexport PACKAGE_PREFIX=com.github.onsdigital.zebedee\n\n# If enabled on start up Zebedee will attempt to connect to the audit database. Generally this isn't required for dev\n# local unless you require \"working\" audit logging it can be disabled. If enabled=false then a NOP database stub is used\n# instead allowing the app to start without a database connection.\nexport audit_db_enabled=false\n\n# File contains connection parameters for the audit and collection history database. Can be ignored if audit_db_enabled=false\nsource ./export-default-env-vars.sh\n\n# Pretty format JSON log output\nexport FORMAT_LOGGING=true\n\n###################################\n## CMD config (dev local values) ##\n###################################\n\n# feature flag to enabled/disabled the CMD features in Zebedee\nexport ENABLE_DATASET_IMPORT={{.EnableDatasetImport}}\n\n# The dp-dataset-api url\nexport DATASET_API_URL={{.DatasetAPIURL}}\n\n# The dp-dataset api auth token\nexport DATASET_API_AUTH_TOKEN={{.DatasetAPIAuthToken}}\n\n# The service auth token\nexport SERVICE_AUTH_TOKEN={{.ServiceAuthToken}}\n\nmvn clean package dependency:copy-dependencies -Dmaven.test.skip=true && \\\njava $JAVA_OPTS \\\n -Dlogback.configurationFile=zebedee-cms/target/classes/logback.xml \\\n -Ddb_audit_url=$db_audit_url \\\n -Daudit_db_enabled=$audit_db_enabled \\\n -Ddb_audit_username=$db_audit_username \\\n -Ddb_audit_password=$db_audit_password \\\n -Drestolino.files=$RESTOLINO_STATIC \\\n -Drestolino.files=$RESTOLINO_STATIC \\\n -Drestolino.classes=$RESTOLINO_CLASSES \\\n -Drestolino.packageprefix=$PACKAGE_PREFIX \\\n -DSTART_EMBEDDED_SERVER=N \\\n -cp \"zebedee-cms/target/classes:zebedee-cms/target/dependency/*\" \\\n com.github.davidcarboni.restolino.Main\n\n",
+	"dataset-api-stub": "#!/bin/bash\n\n###########################################\n## generated by dp-zebedee-utils/content ##\n###########################################\n\n# A stub of the dp-dataset-api for Zebedee to talk to when the CMD features are enabled.\n{{range .Env}}export {{.Name}}={{printf \"%q\" .Value}}\n{{end}}\ncd {{.Dir}} && go run ./content/datasetapistub\n",
+	"florence":         "#!/bin/bash\n\n###########################################\n## generated by dp-zebedee-utils/content ##\n###########################################\n\n# Florence, the publishing UI, talks to Zebedee and previews pages through Babbage.\n{{range .Env}}export {{.Name}}={{printf \"%q\" .Value}}\n{{end}}\ncd {{.Dir}} && make debug\n",
+	"zebedee-reader":   "#!/bin/bash\n\n###########################################\n## generated by dp-zebedee-utils/content ##\n###########################################\n\n# The Zebedee reader serves the published content in master, as the website does.\n{{range .Env}}export {{.Name}}={{printf \"%q\" .Value}}\n{{end}}\nexport JAVA_OPTS=\" -Xmx1204m -Xdebug -Xrunjdwp:transport=dt_socket,address=8003,server=y,suspend=n\"\n\n# Pretty format JSON log output\nexport FORMAT_LOGGING=true\n\ncd {{.Dir}} && \\\nmvn clean package dependency:copy-dependencies -Dmaven.test.skip=true && \\\njava $JAVA_OPTS \\\n -Dlogback.configurationFile=zebedee-reader/target/classes/logback.xml \\\n -Drestolino.classes=zebedee-reader/target/classes \\\n -Drestolino.packageprefix=com.github.onsdigital.zebedee.reader.api \\\n -DSTART_EMBEDDED_SERVER=N \\\n -cp \"zebedee-reader/target/classes:zebedee-reader/target/dependency/*\" \\\n com.github.davidcarboni.restolino.Main\n",
+}
+
+const defaultContentZip = "PK\x03\x04\x14\x00\b\x00\b\x00\x00\x00!P\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\"\x00\t\x00businessindustryandtrade/data.jsonUT\x05\x00\x01\x00\xe1\v^Đ\xbfN\xc3@\f\xc6\xf7<\x85us\x05{6\x10\x1b\xac\x9d\x10\xaa\x8e\xb3I\xac&\xbe\xe8\xec+D\xa8\uf39c6\x04x\x01\xd6\xf3\xf7\xe7w\xdfg\x03\x10\x94\x92q\x16\r-<\xbf\xec\xfc\xa5\xe7\xae\x1f\xb8\xeb\x8d\xf0\x89\xe5\xf8\xe3b\xf3D\xa1\x85`\xf1#K\x1e\xe7\xc3\x10\x05Y\xba\xc3\x14;\n\x8b\xa4\x16v\xc5\xedkU\x16Re\xc1\xaaV\xe6(h%\xe2U\x85\xa4\xa9\xf0\xe4š\x05\xe7\x00\b\xc66,\xf1\xf7W\xef\x0eV7DA\xd8\xfc\x8e]\xc71\x96\xd9\xe5w\xc9\xf8\xc4Ƥ\x90\xdf`-&]L\xdf\t,`=\xc1\xfe\xd1S\xd3P\x9d\x1b0Z\x84|\xb9L%c]\xb6\xd8\xda<\xb0\xcb\x19/YJ\xe5ĉ\xf4f\x858\xd2\xfc\x9e\vn\v\x01\x84\x91,>\xfc\xfa\xde?\x02Va\xf3\x89V\xe0\xa9\xd0\xfeϓ\xe6Z\xd22{h\x00\xce\u0379\xf9\x1a\x00PK\a\bl\v\xbc\xdc\xeb\x00\x00\x00\x17\x02\x00\x00PK\x03\x04\x14\x00\b\x00\b\x00\x00\x00!P\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\t\x00\t\x00data.jsonUT\x05\x00\x01\x00\xe1\v^\xacR\xc1j\x1c1\f\xbd\xcfW\b_z\t\xe9}\xcf=\x14B\xdbC\xbb\xf4PBql\xed\xae\x88-\x19In\x18\xca\xfe{\xf1\xec\xceІ\x1eB\xc8\\\x06\xd9\xd2\xf3{O\xef\xf7\x04\x10\x88]%\xec`\x14\x00\xc1\xc9\v\x86\x1d\x84\xefX\x92T\x04\x17\xf0\x13\u0097Á\x12\xc2A\x14>G'\xe1X\xe0\xabG'sJ\x16n.\xd35\xeac\x96'\x1e\x00\xdfN\b\xfb\xbbw\x06%\xea\x11́8cC\xce\xc8\x0eM%\xf7\x84\nr\x00\x19\xd0\x14\v؆\a\x913\x90\x1b(&92\x19f\xe0\xf5٭-\x16 6'\uf3b7a\x028\x0f\x1a\xc10\x8dN\v;\xf8\xb1кH\x1b\xe2NXq\xd3:\xbeЕ\x06\xd9\xf7\x98\x84\xa5\xce\xe1\xdaz^\xfe\v\xde\v\xe7k+2Wd\x8f\x9cK|\x90\xae\xc3\f\xf4W#6\x94V\xb0I\xeb墜s\x92Z;\x93\xbf\x9e\xe6C7b4#\xce\xdd\\\xe7\xc8\xd95f|\x068\x01\xdc_\xbd\xd4_\x94\xf0\x13\x9a\xc5\xe3\x90\x1e\x96U\a\x9f\xdbR\x9d\xa4\xe2\xcf6\xaen\xa6\xbf\x9e\xb9T\x19-)\xb5A\xfe?\x01\xfb(\x15\xd7\xdcX\xaf5\xea\xfc&\xb1\x19i}qlF\xfe\xc6\xc0\xfe\xeev\xe5\xf2\x88\xf3\x93h^\xe2s\x7f=\xab\xe8\xf1\xc3?j\xc2\xda>\xf6\xb1\xf9\x02\x10\x9a\xe2\xfeّI״\xd8\x15&\x80\xf3t\x9e\xfe\f\x00PK\a\b\x03I\x17\ni\x01\x00\x00z\x03\x00\x00PK\x03\x04\x14\x00\b\x00\b\x00\x00\x00!P\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x11\x00\t\x00economy/data.jsonUT\x05\x00\x01\x00\xe1\v^\xdcR\xc1N\xeb0\x10\xbc\xe7+F>G}\xf7\x9e\x1f\a\x04מ\x10\xaa\\{묚ؑw\x1d\x88P\xff\x1d١\xa2\xf0\t\x1c=3\x9e\xd9\x1d\xfb\xa3\x03\x8c\x90SNQ\xcc\x1e/\xaf}E\x06\x0e\xc3\xc8aP\xf2\xcf\x1c/w\x8c\xae3\x99=\x8c\xda\xf7\x14Ӵ\x1eG\x1b=\xc7p\x9cm \xd3$%sU\xfc#\xd7\x14\x1b\xe8I\\\xe6\xb9\xe6\x98=j,`\x94uln\x0fw\xd2:P\x99&\x9b\xd7\xca\x1c\x9e\xb0\xf9\xb0\x83u\xca\v\xeb\n\x97\x16\xca\x1c\x03\xe6\x9c|i\xc3\xf7\xf0,\x9a\xf9T\xb6\x93KQ\xca\xd4\xf2`\xa3\x87f\xeb\t錐\x92\x97\x06\t\xe5\x85\x1d\xc9\x0e\x8f\xd1\xf3¾\xd8Qz\x9c\x8ap$\x11\x92\x1e)\a\x1bYl\xb5\xd9.\x85\x1a\x1d'\x8a*\xb0\xe3\b{>\x93S\xe8@\xf0\xb4И\xe6\xcaՠ\n}U\xb0\xbb-v\xa1\xf5-e\xff\xdd'`&R\xfb\xffG;\x7fl\xe9\x12Y\xebS\xdeJ\x983\x1d~A\x92Jv\xed'\x98\x0e\xb8v\xd7\xees\x00PK\a\b\x0eg\x13\xf9\x11\x01\x00\x00\x99\x02\x00\x00PK\x03\x04\x14\x00\b\x00\b\x00\x00\x00!P\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00#\x00\t\x00employmentandlabourmarket/data.jsonUT\x05\x00\x01\x00\xe1\v^̑\xb1N;1\f\xc6\xf7{\n+\xf3\xe9\xff\xdf;\xc3\xc6\xc0\u0084Pe.\xe6j]bG\x8eC9\xa1\xbe;\xcaU\xa5\x85'`\xf4\x97\xefs~\xb6?\a\x80PirV\xa9a\a\xcf/cW\x0e<\x1f\x12\xcf\a\xa7\xf8\xc0\xb2ܼ\xf8Z(\xec 8~\xa8h^\xf7\t%\xb2\xcc\xfb\x823\x85\xcdҌ\xbb\xe3?\xe5\x92t\xcd$\x8e\x12\x13\xbej\xb3\x8c\xb6\x90\x9fm\x91\xead\\\xfa\xcfa\a\x1d\x04 8{\xda\xfa\xdf\x7f\x87\x01%\xc29\x0e7\xf9\xce\xddrF[\xbb\xfd\x91\xb4$\x02\x96ͭ\xcdA\xdfਸ਼\xc0\xa4\xefd,3\\qFhr[\xf5\x99\xea%0\x02\xa1\t\xcb\\ǭ\xeeт\xeedR\xb7\xe6],\t'\x82ȵ4\xa7\xfa\xef\x02\xb4\xd0zT\x8b\xd7u\x01\x84L\x8ew?F\xfd#\xb0M\xd8\xfb\xea.\xf0\xc5\xe8\xe9\x97T\xb5ٴ\x9d#\f\x00\xa7\xe14|\r\x00PK\a\bH\xca6T\xf5\x00\x00\x000\x02\x00\x00PK\x03\x04\x14\x00\b\x00\b\x00\x00\x00!P\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00&\x00\t\x00peoplepopulationandcommunity/data.jsonUT\x05\x00\x01\x00\xe1\v^̑?O31\f\xc6\xf7\xfb\x14V\xe6{_\xf6\xcel00\xd0\t\xa1*ML\xcej\xfe\xc9q('\xd4\uf39c\xaaj\xcb'`\xba\xcbc\xfb\xe7\xc7\xf6\xf7\x04`\x1a:\xa1\x92\x9b\xd9\xc0\xdb\xfb\xac\xcaBa\x89\x14\x16A\xffL\xf9p\x13\x91\xb5\xa2ـ\x11\xfbUrI\xeb.\xda\xec)\x87]\xb5\x01\xcdH\xe9L\x9a\xf1P\xb1Ԉ\xb5\xd4\x1e\xad\xf2m\xf6\xae\xa4\xd43\xc9z\xce\xf4\xd8\x1cSՠـz\x010B\x12G\x8b\x97Q?Õ\x006{\xb8g\xa8\xfd\x9e\x92\xe5UK^K%׀1ZA\x0fR\xe0lb\x14ʂ\xb7\xac\xf21\x94\xed\x13Pv\xb1\xeb\x10\xb0'\x96\xa5\xcd\xe0юo\xb2\xccd\x03\xaaD\x9f\x85\x9d\xfe%\n<\x103\xb8\x1e\xa53\xce\xe0\x98\x12ΰ\x94ޔ\xa3\xed\x8e\x18\xe3\xbf=R\x0e\xff/N\x0f\xb8\x1e\v\xfb\xeb:\x01LB\xb1\x8fw{\xf8\xebS\xe8\x01uٗwe\xdc\xfe\x92Z\xe9\xec\xc6\x11\xcd\x04p\x9aN\xd3\xcf\x00PK\a\bGD\x81\xc9\n\x01\x00\x00i\x02\x00\x00PK\x01\x02\x14\x00\x14\x00\b\x00\b\x00\x00\x00!Pl\v\xbc\xdc\xeb\x00\x00\x00\x17\x02\x00\x00\"\x00\t\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00businessindustryandtrade/data.jsonUT\x05\x00\x01\x00\xe1\v^PK\x01\x02\x14\x00\x14\x00\b\x00\b\x00\x00\x00!P\x03I\x17\ni\x01\x00\x00z\x03\x00\x00\t\x00\t\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00D\x01\x00\x00data.jsonUT\x05\x00\x01\x00\xe1\v^PK\x01\x02\x14\x00\x14\x00\b\x00\b\x00\x00\x00!P\x0eg\x13\xf9\x11\x01\x00\x00\x99\x02\x00\x00\x11\x00\t\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xed\x02\x00\x00economy/data.jsonUT\x05\x00\x01\x00\xe1\v^PK\x01\x02\x14\x00\x14\x00\b\x00\b\x00\x00\x00!PH\xca6T\xf5\x00\x00\x000\x02\x00\x00#\x00\t\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00F\x04\x00\x00employmentandlabourmarket/data.jsonUT\x05\x00\x01\x00\xe1\v^PK\x01\x02\x14\x00\x14\x00\b\x00\b\x00\x00\x00!PGD\x81\xc9\n\x01\x00\x00i\x02\x00\x00&\x00\t\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x95\x05\x00\x00peoplepopulationandcommunity/data.jsonUT\x05\x00\x01\x00\xe1\v^PK\x05\x06\x00\x00\x00\x00\x05\x00\x05\x00\x98\x01\x00\x00\xfc\x06\x00\x00\x00\x00"
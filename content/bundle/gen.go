@@ -0,0 +1,112 @@
+//go:build ignore
+// +build ignore
+
+// gen writes bundle_gen.go, embedding the run script templates and a zip of the default content in the builder.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	templateGlob  = "../templates/run.*.template.txt"
+	contentDir    = "../default-content"
+	generatedFile = "bundle_gen.go"
+)
+
+// modified is used for every zip entry so regenerating unchanged content gives an identical file.
+var modified = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func main() {
+	templates, err := filepath.Glob(templateGlob)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Strings(templates)
+
+	content, err := zipDir(contentDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "// Code generated by go generate; DO NOT EDIT.")
+	fmt.Fprintln(&buf, "// Sources: templates/run.*.template.txt, default-content/")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, "package bundle")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, "var runTemplates = map[string]string{")
+	for _, t := range templates {
+		b, err := ioutil.ReadFile(t)
+		if err != nil {
+			log.Fatal(err)
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(t), "run."), ".template.txt")
+		fmt.Fprintf(&buf, "\t%q: %q,\n", name, b)
+	}
+	fmt.Fprintln(&buf, "}")
+	fmt.Fprintln(&buf)
+	fmt.Fprintf(&buf, "const defaultContentZip = %q\n", content)
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(generatedFile, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// zipDir returns a zip of the files under dir with paths relative to dir. filepath.Walk visits files in lexical order
+// so the zip is the same for the same files.
+func zipDir(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		f, err := w.CreateHeader(&zip.FileHeader{
+			Name:     filepath.ToSlash(rel),
+			Method:   zip.Deflate,
+			Modified: modified,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = f.Write(b)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
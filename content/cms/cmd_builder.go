@@ -1,13 +1,18 @@
 package cms
 
 import (
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"time"
 
+	"github.com/ONSdigital/dp-zebedee-utils/content/accounts"
+	"github.com/ONSdigital/dp-zebedee-utils/content/bundle"
+	"github.com/ONSdigital/dp-zebedee-utils/content/files"
+	"github.com/ONSdigital/dp-zebedee-utils/content/samples"
+	"github.com/ONSdigital/dp-zebedee-utils/content/services"
+	"github.com/ONSdigital/dp-zebedee-utils/content/synthetic"
 	"github.com/ONSdigital/log.go/log"
 	"github.com/pkg/errors"
 )
@@ -23,17 +28,17 @@ func (b *Builder) GenerateCMSContent() error {
 		return err
 	}
 
-	err := b.copyContentZipToMaster()
+	err := b.populateMaster()
 	if err != nil {
 		return err
 	}
 
-	err = b.unzipContentInMaster()
+	err = b.createSampleCollections()
 	if err != nil {
 		return err
 	}
 
-	err = b.removeContentZipFromMaster()
+	err = b.seedAccounts()
 	if err != nil {
 		return err
 	}
@@ -43,7 +48,9 @@ func (b *Builder) GenerateCMSContent() error {
 		return err
 	}
 
-	b.setDatasetAPIAuthToken()
+	if err := b.setDatasetAPIAuthToken(); err != nil {
+		return err
+	}
 	b.datasetAPIURL = "http://localhost:22000"
 	return nil
 }
@@ -51,11 +58,7 @@ func (b *Builder) GenerateCMSContent() error {
 func (b *Builder) createDirs() error {
 	log.Event(nil, "creating zebedee directories")
 	for _, dir := range b.dirs() {
-		cmd := exec.Command("mkdir", dir)
-		cmd.Stderr = b.Out
-		cmd.Stdout = b.OutErr
-
-		if err := cmd.Run(); err != nil {
+		if err := os.Mkdir(dir, 0755); err != nil {
 			return errors.Wrap(err, fmt.Sprintf("error while attempting to create zebedee directory: %s", dir))
 		}
 	}
@@ -66,14 +69,58 @@ func (b *Builder) createDirs() error {
 	return nil
 }
 
-func (b *Builder) copyContentZipToMaster() error {
-	log.Event(nil, "copying default content zip to master dir", log.Data{
+func (b *Builder) populateMaster() error {
+	if b.synthetic != nil {
+		return b.generateSyntheticContent()
+	}
+
+	if err := b.copyContentZipToMaster(); err != nil {
+		return err
+	}
+
+	if err := b.unzipContentInMaster(); err != nil {
+		return err
+	}
+	return b.removeContentZipFromMaster()
+}
+
+func (b *Builder) generateSyntheticContent() error {
+	log.Event(nil, "generating synthetic content in master", log.Data{
 		"master": b.masterDir,
+		"config": b.synthetic,
+	})
+
+	summary, err := synthetic.Generate(b.masterDir, *b.synthetic)
+	if err != nil {
+		return errors.Wrap(err, "error generating synthetic content")
+	}
+
+	log.Event(nil, "successfully generated synthetic content", log.Data{
+		"pages": summary.Pages,
+		"files": summary.Files,
+	})
+	return nil
+}
+
+func (b *Builder) copyContentZipToMaster() error {
+	if b.contentZip == "" {
+		log.Event(nil, "writing bundled default content zip to master dir", log.Data{
+			"master": b.masterDir,
+		})
+
+		if err := ioutil.WriteFile(b.masterContentZip(), bundle.DefaultContent(), 0644); err != nil {
+			return errors.Wrap(err, "error writing bundled default content zip to master")
+		}
+		return nil
+	}
+
+	log.Event(nil, "copying content zip to master dir", log.Data{
+		"master":      b.masterDir,
+		"content_zip": b.contentZip,
 	})
-	cmd := newCommand("cp", "", defaultContentZip, b.masterDir)
 
-	if err := cmd.Run(); err != nil {
-		return errors.Wrap(err, "error executing command: copyContentZipToMaster")
+	if err := files.Copy(b.contentZip, b.masterContentZip()); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error copying content zip to master: %s", b.contentZip))
 	}
 	return nil
 }
@@ -82,21 +129,94 @@ func (b *Builder) unzipContentInMaster() error {
 	log.Event(nil, "unzipping default content into master", log.Data{
 		"master": b.masterDir,
 	})
-	cmd := newCommand("unzip", b.masterDir, "-q", defaultContentZip)
 
-	if err := cmd.Run(); err != nil {
-		return errors.Wrap(err, "error executing command: unzipContentInMaster")
+	// log roughly every 10% rather than for every file as the default content has thousands of them.
+	logged := 0
+	progress := func(extracted int, total int) {
+		percent := extracted * 100 / total
+		if percent/10 > logged/10 || extracted == total {
+			logged = percent
+			log.Event(nil, "unzipping default content", log.Data{
+				"extracted": extracted,
+				"total":     total,
+				"percent":   percent,
+			})
+		}
+	}
+
+	if err := files.Unzip(b.masterContentZip(), b.masterDir, progress); err != nil {
+		return errors.Wrap(err, "error unzipping default content in master")
 	}
 	return nil
 }
 
 func (b *Builder) removeContentZipFromMaster() error {
 	log.Event(nil, "cleaning up default content zip")
-	cmd := newCommand("rm", b.masterDir, defaultContentZip)
 
-	if err := cmd.Run(); err != nil {
-		return errors.Wrap(err, "error executing command: removeContentZipFromMaster")
+	if err := os.Remove(b.masterContentZip()); err != nil {
+		return errors.Wrap(err, "error removing default content zip from master")
+	}
+	return nil
+}
+
+func (b *Builder) masterContentZip() string {
+	return filepath.Join(b.masterDir, defaultContentZip)
+}
+
+func (b *Builder) createSampleCollections() error {
+	if !b.sampleCollections {
+		return nil
 	}
+
+	log.Event(nil, "creating sample collections", log.Data{
+		"collections": b.collectionsDir,
+	})
+
+	publishDate := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	created, err := samples.CreateCollections(b.masterDir, b.collectionsDir, publishDate, b.accounts != nil)
+	if err != nil {
+		return errors.Wrap(err, "error creating sample collections")
+	}
+
+	b.collectionKeys = make(map[string][]byte)
+	for _, c := range created {
+		if c.Key != nil {
+			b.collectionKeys[c.ID] = c.Key
+		}
+	}
+
+	log.Event(nil, "successfully created sample collections", log.Data{
+		"collections":  created,
+		"publish_date": publishDate,
+	})
+	return nil
+}
+
+func (b *Builder) seedAccounts() error {
+	if b.accounts == nil {
+		return nil
+	}
+
+	log.Event(nil, "creating users, teams, sessions and permissions")
+	summary, err := accounts.Write(b.accounts, accounts.Dirs{
+		Users:       b.usersDir,
+		Teams:       b.teamsDir,
+		Sessions:    b.sessionsDir,
+		Permissions: b.permissionsDir,
+	}, b.collectionKeys, func() (string, error) {
+		return services.NewToken(services.TokenSize)
+	})
+	if err != nil {
+		return errors.Wrap(err, "error creating accounts")
+	}
+
+	log.Event(nil, "successfully created accounts", log.Data{
+		"users":          summary.Users,
+		"teams":          summary.Teams,
+		"sessions":       summary.Sessions,
+		"administrators": summary.Administrators,
+		"publishers":     summary.Publishers,
+	})
 	return nil
 }
 
@@ -111,15 +231,8 @@ func (b *Builder) createServiceAccount() error {
 		"serviceAccountID": b.serviceAccountID,
 	})
 
-	jsonB, err := json.Marshal(map[string]interface{}{"id": "Weyland-Yutani Corporation"})
-	if err != nil {
-		return errors.Wrap(err, "error marshaling service account JSON")
-	}
-
-	filename := filepath.Join(b.servicesDir, b.serviceAccountID+".json")
-	err = ioutil.WriteFile(filename, jsonB, 0644)
-	if err != nil {
-		return errors.Wrap(err, "error writing service account JSON to file")
+	if err := b.writeServiceAccount(b.serviceAccountID); err != nil {
+		return err
 	}
 
 	log.Event(nil, "successfully generated service account", log.Data{
@@ -128,6 +241,11 @@ func (b *Builder) createServiceAccount() error {
 	return nil
 }
 
+func (b *Builder) writeServiceAccount(id string) error {
+	_, err := services.Create(b.servicesDir, services.Account{Token: id, ID: b.serviceID, Name: services.GeneratedName})
+	return err
+}
+
 func getServiceTokenID() (string, error) {
 	if serviceAuthToken := os.Getenv(ServiceAuthTokenEnv); serviceAuthToken != "" {
 		log.Event(nil, fmt.Sprintf("found existing environment variable for %s using this id for generated service account", ServiceAuthTokenEnv))
@@ -136,17 +254,23 @@ func getServiceTokenID() (string, error) {
 
 	log.Event(nil, fmt.Sprintf("no existing environment variable %s found, generating new ID for generated service account", ServiceAuthTokenEnv))
 
-	return newRandomID(64), nil
+	return services.NewToken(services.TokenSize)
 }
 
-func (b *Builder) setDatasetAPIAuthToken() {
+func (b *Builder) setDatasetAPIAuthToken() error {
 	if datasetAPIAuthToken := os.Getenv(DatasetAPIAuthTokenEnv); datasetAPIAuthToken != "" {
 		log.Event(nil, fmt.Sprintf("found existing environment variable for %s using this token value for generated run script", DatasetAPIAuthTokenEnv))
 		b.datasetAPIAuthToken = datasetAPIAuthToken
-	} else {
-		log.Event(nil, fmt.Sprintf("no existing environment variable %s found generating new token for generated run script", DatasetAPIAuthTokenEnv))
-		b.datasetAPIAuthToken = "FD0108EA-825D-411C-9B1D-41EF7727F465"
+		return nil
+	}
+
+	log.Event(nil, fmt.Sprintf("no existing environment variable %s found generating new token for generated run script", DatasetAPIAuthTokenEnv))
+	token, err := newToken()
+	if err != nil {
+		return err
 	}
+	b.datasetAPIAuthToken = token
+	return nil
 }
 
 func (b *Builder) setDatasetAPIURL() {
@@ -174,13 +298,3 @@ func (b *Builder) dirs() []string {
 		b.servicesDir,
 	}
 }
-
-func newCommand(name string, dir string, args ...string) *exec.Cmd {
-	cmd := exec.Command(name, args...)
-	if dir != "" {
-		cmd.Dir = dir
-	}
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd
-}
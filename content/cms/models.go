@@ -2,12 +2,12 @@ package cms
 
 import (
 	"errors"
-	"io"
-	"math/rand"
 	"path/filepath"
-	"time"
 
+	"github.com/ONSdigital/dp-zebedee-utils/content/accounts"
 	"github.com/ONSdigital/dp-zebedee-utils/content/files"
+	"github.com/ONSdigital/dp-zebedee-utils/content/services"
+	"github.com/ONSdigital/dp-zebedee-utils/content/synthetic"
 )
 
 const (
@@ -29,19 +29,7 @@ const (
 	DatasetAPIURLEnv       = "DATASET_API_URL"
 )
 
-var (
-	serviceIDChars = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
-	r              *rand.Rand
-)
-
-func init() {
-	s1 := rand.NewSource(time.Now().UnixNano())
-	r = rand.New(s1)
-}
-
 type Builder struct {
-	Out                 io.Writer
-	OutErr              io.Writer
 	rootDir             string
 	zebedeeDir          string
 	masterDir           string
@@ -55,7 +43,13 @@ type Builder struct {
 	launchPadDir        string
 	appKeysDir          string
 	enableCMD           bool
+	contentZip          string
+	synthetic           *synthetic.Config
+	accounts            *accounts.Seed
+	sampleCollections   bool
+	collectionKeys      map[string][]byte
 	serviceAccountID    string
+	serviceID           string
 	datasetAPIAuthToken string
 	datasetAPIURL       string
 }
@@ -68,8 +62,9 @@ type RunTemplate struct {
 	ServiceAuthToken    string
 }
 
-// New construct a new cmd.Builder
-func New(root string, isCMD bool) (*Builder, error) {
+// New construct a new cmd.Builder. contentZip is the path of a zip of the content to unpack into master, if empty the
+// default content bundled in the builder is used.
+func New(root string, isCMD bool, contentZip string) (*Builder, error) {
 	zebedeeDir := filepath.Join(root, Zebedee)
 	exists, err := files.Exists(zebedeeDir)
 	if err != nil {
@@ -79,8 +74,30 @@ func New(root string, isCMD bool) (*Builder, error) {
 	if exists {
 		return nil, errors.New("cannot generate directory structure as a zebedee a dir already exists at the root location provided")
 	}
+	return newBuilder(root, zebedeeDir, isCMD, contentZip), nil
+}
+
+// Open constructs a cmd.Builder for a zebedee dir that has already been generated, to be upgraded with Upgrade.
+func Open(root string, isCMD bool, contentZip string) (*Builder, error) {
+	zebedeeDir := filepath.Join(root, Zebedee)
+	exists, err := files.Exists(zebedeeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, errors.New("cannot upgrade as there is no zebedee dir at the root location provided")
+	}
+	return newBuilder(root, zebedeeDir, isCMD, contentZip), nil
+}
 
-	b := &Builder{
+// Dirs returns the dirs a zebedee dir is expected to have, starting with the zebedee dir itself.
+func Dirs(zebedeeDir string) []string {
+	return newBuilder(filepath.Dir(zebedeeDir), zebedeeDir, false, "").dirs()
+}
+
+func newBuilder(root string, zebedeeDir string, isCMD bool, contentZip string) *Builder {
+	return &Builder{
 		rootDir:             root,
 		zebedeeDir:          zebedeeDir,
 		masterDir:           filepath.Join(zebedeeDir, Master),
@@ -94,11 +111,33 @@ func New(root string, isCMD bool) (*Builder, error) {
 		launchPadDir:        filepath.Join(zebedeeDir, LaunchPad),
 		appKeysDir:          filepath.Join(zebedeeDir, AppKeys),
 		enableCMD:           isCMD,
+		contentZip:          contentZip,
 		datasetAPIURL:       "",
 		datasetAPIAuthToken: "",
 		serviceAccountID:    "",
+		serviceID:           services.DefaultID,
 	}
-	return b, nil
+}
+
+// UseSyntheticContent generates content in master from the config instead of unpacking a content zip.
+func (b *Builder) UseSyntheticContent(cfg synthetic.Config) {
+	b.synthetic = &cfg
+}
+
+// UseAccounts creates the users, teams, sessions and permissions of the seed in the new zebedee root.
+func (b *Builder) UseAccounts(seed *accounts.Seed) {
+	b.accounts = seed
+}
+
+// UseServiceAccountID sets the id of the service accounts the builder generates, the default is services.DefaultID.
+func (b *Builder) UseServiceAccountID(id string) {
+	b.serviceID = id
+}
+
+// UseSampleCollections creates a collection in each lifecycle state with pages from master. The encrypted collection is
+// only created if there are accounts to give its key to.
+func (b *Builder) UseSampleCollections() {
+	b.sampleCollections = true
 }
 
 func (b *Builder) GetRunTemplate() *RunTemplate {
@@ -110,12 +149,3 @@ func (b *Builder) GetRunTemplate() *RunTemplate {
 		ServiceAuthToken:    b.serviceAccountID,
 	}
 }
-
-func newRandomID(size int) string {
-	id := ""
-	for len(id) < size {
-		id += string(serviceIDChars[r.Intn(len(serviceIDChars))])
-	}
-
-	return id
-}
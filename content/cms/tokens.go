@@ -0,0 +1,85 @@
+package cms
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/ONSdigital/dp-zebedee-utils/content/services"
+	"github.com/ONSdigital/log.go/log"
+	"github.com/pkg/errors"
+)
+
+// TokenRotation is the tokens a rotation generated and the service accounts it removed.
+type TokenRotation struct {
+	ServiceAccount         string   `json:"service_account"`
+	RemovedServiceAccounts []string `json:"removed_service_accounts"`
+	DatasetAPIAuthToken    string   `json:"dataset_api_auth_token"`
+}
+
+// RotateTokens replaces the generated service account with a new one and generates a new dataset API auth token. The
+// old generated service accounts are removed, service accounts not generated by the builder are left alone. Unlike an
+// upgrade the token env vars are ignored, the new tokens are always random.
+func (b *Builder) RotateTokens() (*TokenRotation, error) {
+	existing, err := services.Generated(b.servicesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := services.NewToken(services.TokenSize)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.writeServiceAccount(id); err != nil {
+		return nil, err
+	}
+
+	removed, err := b.removeServiceAccounts(existing, id)
+	if err != nil {
+		return nil, err
+	}
+
+	b.serviceAccountID = id
+	b.datasetAPIAuthToken = token
+	b.datasetAPIURL = "http://localhost:22000"
+
+	log.Event(nil, "rotated tokens", log.Data{
+		"serviceAccountID": id,
+		"removed":          removed,
+	})
+	return &TokenRotation{ServiceAccount: id, RemovedServiceAccounts: removed, DatasetAPIAuthToken: token}, nil
+}
+
+// removeServiceAccounts removes the service account files of ids other than keep, returning the ids removed.
+func (b *Builder) removeServiceAccounts(ids []string, keep string) ([]string, error) {
+	removed := make([]string, 0)
+	for _, id := range ids {
+		if id == keep {
+			continue
+		}
+
+		if err := services.Revoke(b.servicesDir, id); err != nil {
+			return nil, errors.Wrap(err, "error removing rotated service account")
+		}
+		removed = append(removed, id)
+	}
+	return removed, nil
+}
+
+// newToken returns a random token in the uppercase UUID format of the dataset API auth token.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "error generating random token")
+	}
+
+	// set the version 4 and variant bits so the token is a valid UUID
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,66 @@
+package cms
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ONSdigital/dp-zebedee-utils/content/services"
+)
+
+func TestRotateTokensKeepsAccountsCreatedByHand(t *testing.T) {
+	root, err := ioutil.TempDir("", "cms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	b, err := New(root, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.UseServiceAccountID("builder")
+
+	if err := os.MkdirAll(b.servicesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.writeServiceAccount("old"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a generated account in the format written before generated accounts were named.
+	if err := ioutil.WriteFile(filepath.Join(b.servicesDir, "baseline.json"), []byte(`{"id":"Weyland-Yutani Corporation"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// an account created by hand with the default id of the generated accounts.
+	if _, err := services.Create(b.servicesDir, services.Account{Token: "byhand", ID: services.DefaultID, Name: "dataset api"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rotation, err := b.RotateTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed := rotation.RemovedServiceAccounts
+	if len(removed) != 2 || removed[0] != "baseline" || removed[1] != "old" {
+		t.Errorf("expected only the old generated accounts to be removed, got %v", removed)
+	}
+
+	accounts, _, err := services.List(b.servicesDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[string]string)
+	for _, a := range accounts {
+		ids[a.Token] = a.ID
+	}
+
+	if len(ids) != 2 || ids["byhand"] != services.DefaultID || ids[rotation.ServiceAccount] != "builder" {
+		t.Errorf("expected the account created by hand and the new account with the flag id, got %v", ids)
+	}
+}
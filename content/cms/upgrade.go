@@ -0,0 +1,178 @@
+package cms
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ONSdigital/dp-zebedee-utils/content/files"
+	"github.com/ONSdigital/dp-zebedee-utils/content/services"
+	"github.com/ONSdigital/log.go/log"
+	"github.com/pkg/errors"
+)
+
+// Service account upgrade actions.
+const (
+	ServiceAccountAdded   = "added"
+	ServiceAccountKept    = "kept"
+	ServiceAccountRotated = "rotated"
+)
+
+// UpgradeOptions is what an upgrade changes in addition to creating missing dirs. RotateServiceAccount replaces the
+// generated service account with a new one, RefreshMaster replaces master with the builder's content.
+// DatasetAPIAuthToken and ServiceAuthToken are the tokens the existing run script was generated with, they are kept
+// unless their env vars are set. If DatasetAPIAuthToken is empty a new token is generated, if ServiceAuthToken is empty
+// or not a generated service account see upgradeServiceAccount.
+type UpgradeOptions struct {
+	RotateServiceAccount bool
+	RefreshMaster        bool
+	DatasetAPIAuthToken  string
+	ServiceAuthToken     string
+}
+
+// UpgradeSummary is the changes made by an upgrade.
+type UpgradeSummary struct {
+	CreatedDirs            []string `json:"created_dirs"`
+	ServiceAccount         string   `json:"service_account"`
+	ServiceAccountAction   string   `json:"service_account_action"`
+	RemovedServiceAccounts []string `json:"removed_service_accounts"`
+	MasterRefreshed        bool     `json:"master_refreshed"`
+}
+
+// Upgrade brings an existing zebedee dir up to date without touching collections, users, teams, permissions or
+// sessions. Missing dirs are created and the generated service account is added if there isn't one, kept or rotated.
+// Master is only changed if RefreshMaster is set.
+func (b *Builder) Upgrade(opts UpgradeOptions) (*UpgradeSummary, error) {
+	log.Event(nil, "upgrading CMS file structure and content", log.Data{
+		"root":                   b.zebedeeDir,
+		"enable_cmd":             b.enableCMD,
+		"rotate_service_account": opts.RotateServiceAccount,
+		"refresh_master":         opts.RefreshMaster,
+	})
+
+	summary := &UpgradeSummary{
+		CreatedDirs:            make([]string, 0),
+		RemovedServiceAccounts: make([]string, 0),
+	}
+
+	if err := b.createMissingDirs(summary); err != nil {
+		return nil, err
+	}
+
+	if opts.RefreshMaster {
+		if err := b.refreshMaster(); err != nil {
+			return nil, err
+		}
+		summary.MasterRefreshed = true
+	}
+
+	if err := b.upgradeServiceAccount(opts.RotateServiceAccount, opts.ServiceAuthToken, summary); err != nil {
+		return nil, err
+	}
+
+	if opts.DatasetAPIAuthToken != "" && os.Getenv(DatasetAPIAuthTokenEnv) == "" {
+		b.datasetAPIAuthToken = opts.DatasetAPIAuthToken
+	} else if err := b.setDatasetAPIAuthToken(); err != nil {
+		return nil, err
+	}
+	b.datasetAPIURL = "http://localhost:22000"
+	return summary, nil
+}
+
+func (b *Builder) createMissingDirs(summary *UpgradeSummary) error {
+	for _, dir := range b.dirs() {
+		exists, err := files.Exists(dir)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			continue
+		}
+
+		if err := os.Mkdir(dir, 0755); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("error while attempting to create zebedee directory: %s", dir))
+		}
+		summary.CreatedDirs = append(summary.CreatedDirs, dir)
+	}
+
+	log.Event(nil, "created missing zebedee directories", log.Data{
+		"dirs": summary.CreatedDirs,
+	})
+	return nil
+}
+
+func (b *Builder) refreshMaster() error {
+	log.Event(nil, "removing master content to refresh it", log.Data{
+		"master": b.masterDir,
+	})
+
+	if err := os.RemoveAll(b.masterDir); err != nil {
+		return errors.Wrap(err, "error removing master content")
+	}
+
+	if err := os.Mkdir(b.masterDir, 0755); err != nil {
+		return errors.Wrap(err, "error recreating master dir")
+	}
+	return b.populateMaster()
+}
+
+// upgradeServiceAccount keeps the generated service account the existing run script uses, current, unless rotate is
+// set or the service auth token env var names another. The env var account is kept if it exists, otherwise it is
+// added. Without either the only generated service account is kept, if there are several which one Zebedee is run with
+// is not known so it is an error. Service accounts not generated by the builder are left alone.
+func (b *Builder) upgradeServiceAccount(rotate bool, current string, summary *UpgradeSummary) error {
+	existing, err := services.Generated(b.servicesDir)
+	if err != nil {
+		return err
+	}
+
+	if envID := os.Getenv(ServiceAuthTokenEnv); envID != "" {
+		current = envID
+	} else if current == "" && len(existing) == 1 {
+		current = existing[0]
+	} else if current == "" && len(existing) > 1 && !rotate {
+		return errors.New(fmt.Sprintf("cannot tell which of the %d generated service accounts to keep as there is no run script, set %s or rotate the service account", len(existing), ServiceAuthTokenEnv))
+	}
+
+	if !rotate && contains(existing, current) {
+		summary.ServiceAccount = current
+		summary.ServiceAccountAction = ServiceAccountKept
+	} else {
+		id, err := getServiceTokenID()
+		if err != nil {
+			return err
+		}
+
+		if !contains(existing, id) {
+			if err := b.writeServiceAccount(id); err != nil {
+				return err
+			}
+		}
+		summary.ServiceAccount = id
+		summary.ServiceAccountAction = ServiceAccountAdded
+
+		if rotate {
+			summary.ServiceAccountAction = ServiceAccountRotated
+			if summary.RemovedServiceAccounts, err = b.removeServiceAccounts(existing, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	b.serviceAccountID = summary.ServiceAccount
+	log.Event(nil, "upgraded service account", log.Data{
+		"serviceAccountID": b.serviceAccountID,
+		"action":           summary.ServiceAccountAction,
+		"removed":          summary.RemovedServiceAccounts,
+	})
+	return nil
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package cms
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dp-zebedee-utils/content/services"
+)
+
+// upgradeRoot returns a root with an empty zebedee dir and generated service accounts for tokens. The token env vars
+// are unset until the returned func is called.
+func upgradeRoot(t *testing.T, tokens ...string) (string, func()) {
+	root, err := ioutil.TempDir("", "cms")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := make(map[string]string)
+	for _, name := range []string{ServiceAuthTokenEnv, DatasetAPIAuthTokenEnv} {
+		env[name] = os.Getenv(name)
+		os.Unsetenv(name)
+	}
+
+	b := newBuilder(root, filepath.Join(root, Zebedee), false, "")
+	if err := os.MkdirAll(b.servicesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range tokens {
+		if err := b.writeServiceAccount(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root, func() {
+		for name, value := range env {
+			os.Setenv(name, value)
+		}
+		os.RemoveAll(root)
+	}
+}
+
+func upgrade(t *testing.T, root string, opts UpgradeOptions) (*UpgradeSummary, *RunTemplate) {
+	b, err := Open(root, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := b.Upgrade(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return summary, b.GetRunTemplate()
+}
+
+func generated(t *testing.T, root string) []string {
+	tokens, err := services.Generated(filepath.Join(root, Zebedee, Services))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
+
+func TestUpgradeIdempotent(t *testing.T) {
+	root, cleanup := upgradeRoot(t)
+	defer cleanup()
+
+	first, run := upgrade(t, root, UpgradeOptions{})
+	if len(first.CreatedDirs) == 0 || first.ServiceAccountAction != ServiceAccountAdded {
+		t.Fatalf("expected the missing dirs and service account to be added, got %+v", first)
+	}
+
+	// upgrades with the tokens of the run script each upgrade writes.
+	for i := 0; i < 2; i++ {
+		opts := UpgradeOptions{ServiceAuthToken: run.ServiceAuthToken, DatasetAPIAuthToken: run.DatasetAPIAuthToken}
+		summary, again := upgrade(t, root, opts)
+
+		if len(summary.CreatedDirs) != 0 || len(summary.RemovedServiceAccounts) != 0 || summary.MasterRefreshed {
+			t.Errorf("upgrade %d: expected nothing to be changed, got %+v", i+2, summary)
+		}
+
+		if summary.ServiceAccount != first.ServiceAccount || summary.ServiceAccountAction != ServiceAccountKept {
+			t.Errorf("upgrade %d: expected the service account to be kept, got %+v", i+2, summary)
+		}
+
+		if !reflect.DeepEqual(again, run) {
+			t.Errorf("upgrade %d: expected the same run script, got %+v and %+v", i+2, run, again)
+		}
+
+		if tokens := generated(t, root); len(tokens) != 1 || tokens[0] != first.ServiceAccount {
+			t.Errorf("upgrade %d: expected only the first service account, got %v", i+2, tokens)
+		}
+	}
+}
+
+func TestUpgradeKeepsRunScriptServiceAccount(t *testing.T) {
+	root, cleanup := upgradeRoot(t, "aaa", "bbb")
+	defer cleanup()
+
+	summary, run := upgrade(t, root, UpgradeOptions{ServiceAuthToken: "bbb"})
+	if summary.ServiceAccount != "bbb" || summary.ServiceAccountAction != ServiceAccountKept || run.ServiceAuthToken != "bbb" {
+		t.Errorf("expected the service account of the run script to be kept, got %+v", summary)
+	}
+
+	if tokens := generated(t, root); !reflect.DeepEqual(tokens, []string{"aaa", "bbb"}) {
+		t.Errorf("expected the service accounts to be unchanged, got %v", tokens)
+	}
+}
+
+func TestUpgradeRunScriptServiceAccountMissing(t *testing.T) {
+	root, cleanup := upgradeRoot(t, "aaa")
+	defer cleanup()
+
+	summary, run := upgrade(t, root, UpgradeOptions{ServiceAuthToken: "gone"})
+	if summary.ServiceAccount == "aaa" || summary.ServiceAccount == "gone" || summary.ServiceAccountAction != ServiceAccountAdded {
+		t.Errorf("expected a new service account to be added, got %+v", summary)
+	}
+
+	if run.ServiceAuthToken != summary.ServiceAccount {
+		t.Errorf("expected the run script to use the new service account, got %s", run.ServiceAuthToken)
+	}
+
+	if tokens := generated(t, root); len(tokens) != 2 {
+		t.Errorf("expected the existing service account to be left, got %v", tokens)
+	}
+}
+
+func TestUpgradeServiceAccountEnv(t *testing.T) {
+	root, cleanup := upgradeRoot(t, "aaa", "bbb")
+	defer cleanup()
+
+	os.Setenv(ServiceAuthTokenEnv, "aaa")
+	summary, _ := upgrade(t, root, UpgradeOptions{ServiceAuthToken: "bbb"})
+	if summary.ServiceAccount != "aaa" || summary.ServiceAccountAction != ServiceAccountKept {
+		t.Errorf("expected the env var service account to be kept, got %+v", summary)
+	}
+}
+
+func TestUpgradeWithoutRunScript(t *testing.T) {
+	root, cleanup := upgradeRoot(t, "aaa")
+	defer cleanup()
+
+	summary, _ := upgrade(t, root, UpgradeOptions{})
+	if summary.ServiceAccount != "aaa" || summary.ServiceAccountAction != ServiceAccountKept {
+		t.Errorf("expected the only service account to be kept, got %+v", summary)
+	}
+
+	b := newBuilder(root, filepath.Join(root, Zebedee), false, "")
+	if err := b.writeServiceAccount("bbb"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.Upgrade(UpgradeOptions{}); err == nil {
+		t.Fatal("expected an upgrade without a run script to refuse to choose between service accounts")
+	}
+
+	summary, _ = upgrade(t, root, UpgradeOptions{RotateServiceAccount: true})
+	if summary.ServiceAccountAction != ServiceAccountRotated || !reflect.DeepEqual(summary.RemovedServiceAccounts, []string{"aaa", "bbb"}) {
+		t.Errorf("expected both service accounts to be rotated, got %+v", summary)
+	}
+
+	if tokens := generated(t, root); len(tokens) != 1 || tokens[0] != summary.ServiceAccount {
+		t.Errorf("expected only the new service account, got %v", tokens)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ONSdigital/log.go/log"
+)
+
+// emptyList is the response to every list request, the stub has no datasets.
+var emptyList = map[string]interface{}{
+	"items":       []interface{}{},
+	"count":       0,
+	"offset":      0,
+	"limit":       20,
+	"total_count": 0,
+}
+
+func main() {
+	log.Namespace = "dataset-api-stub"
+	bindAddr := os.Getenv("BIND_ADDR")
+	if bindAddr == "" {
+		bindAddr = ":22000"
+	}
+
+	serviceAuthToken := os.Getenv("SERVICE_AUTH_TOKEN")
+	datasetAPIAuthToken := os.Getenv("DATASET_API_AUTH_TOKEN")
+
+	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "OK"})
+	})
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		log.Event(r.Context(), "dataset api stub request", log.Data{"method": r.Method, "path": r.URL.Path})
+
+		if r.Method != http.MethodGet && !authorised(r, serviceAuthToken, datasetAPIAuthToken) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "unauthenticated request"})
+			return
+		}
+
+		// a list is any path with an odd number of segments e.g. /datasets or /datasets/{id}/editions
+		segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if r.Method == http.MethodGet && len(segments)%2 == 1 {
+			writeJSON(w, http.StatusOK, emptyList)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{})
+	})
+
+	log.Event(nil, "starting dataset api stub", log.Data{"bind_addr": bindAddr})
+	if err := http.ListenAndServe(bindAddr, nil); err != nil {
+		log.Event(nil, "dataset api stub stopped", log.Error(err))
+		os.Exit(1)
+	}
+}
+
+// authorised returns true if the request has the service auth token or the dataset api auth token the stack was
+// generated with. If neither is set every request is authorised.
+func authorised(r *http.Request, serviceAuthToken string, datasetAPIAuthToken string) bool {
+	if serviceAuthToken == "" && datasetAPIAuthToken == "" {
+		return true
+	}
+
+	if serviceAuthToken != "" && strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") == serviceAuthToken {
+		return true
+	}
+	return datasetAPIAuthToken != "" && r.Header.Get("Internal-Token") == datasetAPIAuthToken
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Event(nil, "error writing response", log.Error(err))
+	}
+}
@@ -1,6 +1,9 @@
 package files
 
-import "os"
+import (
+	"io"
+	"os"
+)
 
 func Exists(path string) (bool, error) {
 	_, err := os.Stat(path)
@@ -12,3 +15,28 @@ func Exists(path string) (bool, error) {
 	}
 	return true, err
 }
+
+// Copy copies the file src to dst, creating or truncating dst.
+func Copy(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
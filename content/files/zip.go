@@ -0,0 +1,106 @@
+package files
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Progress is called after each entry of a zip is extracted with the number of entries extracted so far and the total.
+type Progress func(extracted int, total int)
+
+// Unzip extracts the zip file src into the dir dest, streaming each entry to disk. Entries that would be written
+// outside of dest, such as ../ paths, absolute paths and symlinks, are rejected before anything is written for them.
+// progress may be nil.
+func Unzip(src string, dest string, progress Progress) error {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error opening zip file: %s", src))
+	}
+	defer r.Close()
+
+	dest, err = filepath.Abs(dest)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error resolving zip destination dir: %s", dest))
+	}
+
+	total := len(r.File)
+	for i, f := range r.File {
+		if err := extract(f, dest); err != nil {
+			return err
+		}
+
+		if progress != nil {
+			progress(i+1, total)
+		}
+	}
+	return nil
+}
+
+// extract writes a single zip entry to its path under dest.
+func extract(f *zip.File, dest string) error {
+	target, err := entryPath(f.Name, dest)
+	if err != nil {
+		return err
+	}
+
+	mode := f.Mode()
+	if mode&os.ModeSymlink != 0 {
+		return errors.New(fmt.Sprintf("zip entry is a symlink: %s", f.Name))
+	}
+
+	if f.FileInfo().IsDir() {
+		if err := os.MkdirAll(target, 0755); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("error creating dir for zip entry: %s", f.Name))
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error creating parent dir for zip entry: %s", f.Name))
+	}
+
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error opening zip entry: %s", f.Name))
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error creating file for zip entry: %s", f.Name))
+	}
+
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return errors.Wrap(err, fmt.Sprintf("error writing zip entry: %s", f.Name))
+	}
+
+	if err := out.Close(); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error closing file for zip entry: %s", f.Name))
+	}
+	return nil
+}
+
+// entryPath returns the path a zip entry should be extracted to, or an error if it would be outside of dest.
+func entryPath(name string, dest string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") || strings.HasPrefix(name, `\`) {
+		return "", errors.New(fmt.Sprintf("zip entry has an absolute path: %s", name))
+	}
+
+	target := filepath.Join(dest, name)
+	if target != dest && !strings.HasPrefix(target, dest+string(os.PathSeparator)) {
+		return "", errors.New(fmt.Sprintf("zip entry is outside of the destination dir: %s", name))
+	}
+	return target, nil
+}
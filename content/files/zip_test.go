@@ -0,0 +1,152 @@
+package files
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type entry struct {
+	name    string
+	content string
+	mode    os.FileMode
+}
+
+// writeZip creates a zip of the entries in dir and returns its path.
+func writeZip(t *testing.T, dir string, entries []entry) string {
+	filename := filepath.Join(dir, "test.zip")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		if e.mode != 0 {
+			h.SetMode(e.mode)
+		}
+
+		fw, err := w.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := fw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestUnzip(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	src := writeZip(t, dir, []entry{
+		{name: "zebedee/"},
+		{name: "zebedee/master/data.json", content: `{"type":"home_page"}`},
+		{name: "zebedee/master/economy/data.json", content: `{"type":"taxonomy_landing_page"}`},
+	})
+
+	dest := filepath.Join(dir, "out")
+	if err := Unzip(src, dest, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"zebedee/master/data.json":         `{"type":"home_page"}`,
+		"zebedee/master/economy/data.json": `{"type":"taxonomy_landing_page"}`,
+	}
+	for name, content := range expected {
+		b, err := ioutil.ReadFile(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != content {
+			t.Errorf("%s: expected %s, got %s", name, content, b)
+		}
+	}
+}
+
+func TestUnzipProgress(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	src := writeZip(t, dir, []entry{
+		{name: "a.json", content: "{}"},
+		{name: "b/"},
+		{name: "b/c.json", content: "{}"},
+	})
+
+	calls := make([][2]int, 0)
+	err := Unzip(src, filepath.Join(dir, "out"), func(extracted int, total int) {
+		calls = append(calls, [2]int{extracted, total})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][2]int{{1, 3}, {2, 3}, {3, 3}}
+	if len(calls) != len(expected) {
+		t.Fatalf("expected %d progress calls, got %v", len(expected), calls)
+	}
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Errorf("progress call %d: expected %v, got %v", i, expected[i], calls[i])
+		}
+	}
+}
+
+func TestUnzipRejectsZipSlip(t *testing.T) {
+	for _, name := range []string{"../evil.json", "a/../../evil.json", "/tmp/evil.json"} {
+		dir := tempDir(t)
+
+		src := writeZip(t, dir, []entry{{name: name, content: "evil"}})
+		dest := filepath.Join(dir, "out")
+
+		err := Unzip(src, dest, nil)
+		if err == nil {
+			t.Errorf("%s: expected entry outside of the destination to be rejected", name)
+		}
+
+		if _, statErr := os.Stat(filepath.Join(dir, "evil.json")); statErr == nil {
+			t.Errorf("%s: expected nothing to be written outside of the destination", name)
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func TestUnzipRejectsSymlinks(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	src := writeZip(t, dir, []entry{{name: "link", content: "/etc/passwd", mode: os.ModeSymlink | 0777}})
+	dest := filepath.Join(dir, "out")
+
+	err := Unzip(src, dest, nil)
+	if err == nil || !strings.Contains(err.Error(), "symlink") {
+		t.Fatalf("expected symlink entry to be rejected, got %v", err)
+	}
+
+	if _, err := os.Lstat(filepath.Join(dest, "link")); err == nil {
+		t.Error("expected symlink not to be created")
+	}
+}
@@ -2,18 +2,60 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/ONSdigital/dp-zebedee-utils/content/accounts"
 	"github.com/ONSdigital/dp-zebedee-utils/content/cms"
+	"github.com/ONSdigital/dp-zebedee-utils/content/files"
 	"github.com/ONSdigital/dp-zebedee-utils/content/scripts"
+	"github.com/ONSdigital/dp-zebedee-utils/content/services"
+	"github.com/ONSdigital/dp-zebedee-utils/content/synthetic"
 	"github.com/ONSdigital/log.go/log"
 )
 
 func main() {
 	log.Namespace = "zebedee-content-generator"
+	if len(os.Args) > 1 && os.Args[1] == "tokens" {
+		tokens(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "service-accounts" {
+		serviceAccounts(os.Args[2:])
+		return
+	}
+
 	root := flag.String("r", "", "the root directory in which to build zebedee directory structure and unpack the default content")
 	zebDir := flag.String("zeb-dir", "", "the root directory path of your zebedee project")
 	enableCMD := flag.Bool("enable_cmd", false, "enabled or disabled the CMD features in Zebedee")
+	contentZip := flag.String("content", "", "the path of a zip of content to unpack into master, defaults to the content bundled in the builder")
+	templateFile := flag.String("template", "", "the path of a run-cms.sh template, defaults to the template bundled in the builder")
+	accountsFile := flag.String("accounts", "", "the path of a seed file of users, teams, sessions and permissions to create")
+	sampleCollections := flag.Bool("sample_collections", false, "create a sample collection in each lifecycle state with pages from master")
+
+	stackDir := flag.String("stack_dir", "", "the dir to write the local publishing stack settings to, if empty only run-cms.sh is written")
+	stackFormats := flag.String("stack_formats", scripts.FormatScripts, "comma separated stack formats to write: "+strings.Join(scripts.Formats, ", "))
+	projectsDir := flag.String("projects_dir", "", "the dir the stack projects are checked out in, defaults to the parent of -zeb-dir")
+
+	upgrade := flag.Bool("upgrade", false, "upgrade an existing zebedee dir, creating missing dirs and regenerating the run script")
+	serviceAccountID := flag.String("service_account_id", services.DefaultID, "the id of the service account the builder generates")
+	rotateServiceAccount := flag.Bool("rotate_service_account", false, "upgrade: replace the generated service account with a new one")
+	refreshMaster := flag.Bool("refresh_master", false, "upgrade: replace master with the bundled content, or the -content zip or -synthetic content")
+
+	useSynthetic := flag.Bool("synthetic", false, "generate synthetic content in master instead of unpacking the default content")
+	cfg := synthetic.DefaultConfig
+	flag.Int64Var(&cfg.Seed, "seed", cfg.Seed, "synthetic content: the random seed, the same seed and counts always generate the same content")
+	flag.IntVar(&cfg.Topics, "topics", cfg.Topics, "synthetic content: the number of topics")
+	flag.IntVar(&cfg.Products, "products", cfg.Products, "synthetic content: the number of product pages in each topic")
+	flag.IntVar(&cfg.Bulletins, "bulletins", cfg.Bulletins, "synthetic content: the number of bulletin series in each product page")
+	flag.IntVar(&cfg.Articles, "articles", cfg.Articles, "synthetic content: the number of article series in each product page")
+	flag.IntVar(&cfg.Datasets, "datasets", cfg.Datasets, "synthetic content: the number of datasets in each product page")
+	flag.IntVar(&cfg.Timeseries, "timeseries", cfg.Timeseries, "synthetic content: the number of timeseries in each product page")
+	flag.IntVar(&cfg.Editions, "editions", cfg.Editions, "synthetic content: the number of editions of each bulletin and article series")
+	flag.IntVar(&cfg.Years, "years", cfg.Years, "synthetic content: the number of years of data in each timeseries")
 	flag.Parse()
 
 	if *root == "" {
@@ -26,32 +68,160 @@ func main() {
 		os.Exit(1)
 	}
 
-	generateCMSContent(*root, *enableCMD, *zebDir)
+	var stack *stackOptions
+	if *stackDir != "" {
+		stack = &stackOptions{dir: *stackDir, formats: strings.Split(*stackFormats, ","), projectsDir: *projectsDir}
+		for _, format := range stack.formats {
+			if !isStackFormat(format) {
+				log.Event(nil, "unknown stack format, use -h to see the help menu", log.Data{"format": format})
+				os.Exit(1)
+			}
+		}
+
+		if stack.projectsDir == "" {
+			stack.projectsDir = filepath.Dir(filepath.Clean(*zebDir))
+		}
+	}
+
+	var syntheticCfg *synthetic.Config
+	if *useSynthetic {
+		syntheticCfg = &cfg
+	}
+
+	var seed *accounts.Seed
+	if *accountsFile != "" {
+		var err error
+		if seed, err = accounts.Load(*accountsFile); err != nil {
+			errorAndExit(err)
+		}
+	}
+
+	if *upgrade {
+		if seed != nil || *sampleCollections {
+			log.Event(nil, "-accounts and -sample_collections cannot be used with -upgrade as existing users and collections are left unchanged")
+			os.Exit(1)
+		}
+
+		opts := cms.UpgradeOptions{RotateServiceAccount: *rotateServiceAccount, RefreshMaster: *refreshMaster}
+		upgradeCMSContent(*root, *enableCMD, *zebDir, *contentZip, *templateFile, *serviceAccountID, syntheticCfg, stack, opts)
+		return
+	}
+
+	generateCMSContent(*root, *enableCMD, *zebDir, *contentZip, *templateFile, *serviceAccountID, syntheticCfg, stack, seed, *sampleCollections)
 }
 
-func generateCMSContent(root string, enableCMD bool, zebDir string) {
-	builder, err := cms.New(root, enableCMD)
+// stackOptions is where and in which formats the local publishing stack settings are written.
+type stackOptions struct {
+	dir         string
+	formats     []string
+	projectsDir string
+}
+
+func upgradeCMSContent(root string, enableCMD bool, zebDir string, contentZip string, templateFile string, serviceAccountID string, syntheticCfg *synthetic.Config, stack *stackOptions, opts cms.UpgradeOptions) {
+	builder, err := cms.Open(root, enableCMD, contentZip)
 	if err != nil {
 		errorAndExit(err)
 	}
+	builder.UseServiceAccountID(serviceAccountID)
 
-	err = builder.GenerateCMSContent()
+	if syntheticCfg != nil {
+		builder.UseSyntheticContent(*syntheticCfg)
+	}
+
+	// keep the tokens of the existing run script so Zebedee, the dataset api and the other apps still agree
+	existing := filepath.Join(zebDir, "run-cms.sh")
+	if exists, err := files.Exists(existing); err != nil {
+		errorAndExit(err)
+	} else if exists {
+		if opts.DatasetAPIAuthToken, err = scripts.ReadSetting(existing, cms.DatasetAPIAuthTokenEnv); err != nil {
+			errorAndExit(err)
+		}
+
+		if opts.ServiceAuthToken, err = scripts.ReadSetting(existing, cms.ServiceAuthTokenEnv); err != nil {
+			errorAndExit(err)
+		}
+	}
+
+	summary, err := builder.Upgrade(opts)
 	if err != nil {
 		errorAndExit(err)
 	}
 
 	t := builder.GetRunTemplate()
 
-	var file string
-	file, err = scripts.GenerateCMSRunScript(t)
+	scriptLocation, err := scripts.GenerateCMSRunScript(zebDir, templateFile, t)
+	if err != nil {
+		errorAndExit(err)
+	}
+
+	if stack != nil {
+		if _, err := scripts.GenerateStack(stack.dir, scripts.NewStack(t, stack.projectsDir), stack.formats); err != nil {
+			errorAndExit(err)
+		}
+	}
+
+	log.Event(nil, "successfully upgraded zebedee file structure", log.Data{
+		"run_script_location":   scriptLocation,
+		"summary":               summary,
+		cms.EnableCMDEnv:        t.EnableDatasetImport,
+		cms.ServiceAuthTokenEnv: t.ServiceAuthToken,
+	})
+
+	fmt.Println("Upgrade summary")
+	if len(summary.CreatedDirs) == 0 {
+		fmt.Println("  directories:     none missing")
+	}
+	for _, dir := range summary.CreatedDirs {
+		fmt.Printf("  created dir:     %s\n", dir)
+	}
+	master := "unchanged"
+	if summary.MasterRefreshed {
+		master = "refreshed"
+	}
+	fmt.Printf("  master:          %s\n", master)
+	fmt.Printf("  service account: %s (%s)\n", summary.ServiceAccount, summary.ServiceAccountAction)
+	for _, id := range summary.RemovedServiceAccounts {
+		fmt.Printf("  removed account: %s\n", id)
+	}
+	fmt.Printf("  run script:      %s\n", scriptLocation)
+}
+
+func generateCMSContent(root string, enableCMD bool, zebDir string, contentZip string, templateFile string, serviceAccountID string, syntheticCfg *synthetic.Config, stack *stackOptions, seed *accounts.Seed, sampleCollections bool) {
+	builder, err := cms.New(root, enableCMD, contentZip)
+	if err != nil {
+		errorAndExit(err)
+	}
+	builder.UseServiceAccountID(serviceAccountID)
+
+	if syntheticCfg != nil {
+		builder.UseSyntheticContent(*syntheticCfg)
+	}
+
+	if seed != nil {
+		builder.UseAccounts(seed)
+	}
+
+	if sampleCollections {
+		builder.UseSampleCollections()
+	}
+
+	err = builder.GenerateCMSContent()
 	if err != nil {
 		errorAndExit(err)
 	}
 
-	scriptLocation, err := scripts.CopyToProjectDir(zebDir, file)
+	t := builder.GetRunTemplate()
+
+	scriptLocation, err := scripts.GenerateCMSRunScript(zebDir, templateFile, t)
 	if err != nil {
 		errorAndExit(err)
 	}
+
+	if stack != nil {
+		if _, err := scripts.GenerateStack(stack.dir, scripts.NewStack(t, stack.projectsDir), stack.formats); err != nil {
+			errorAndExit(err)
+		}
+	}
 	log.Event(nil, "successfully generated zebedee file structure and default content you can use the generated run-cms.sh file to run the application", log.Data{
 		"run_script_location":      scriptLocation,
 		cms.EnableCMDEnv:           t.EnableDatasetImport,
@@ -61,6 +231,84 @@ func generateCMSContent(root string, enableCMD bool, zebDir string) {
 	})
 }
 
+// tokens runs the tokens subcommands, only rotate at the moment.
+func tokens(args []string) {
+	if len(args) == 0 || args[0] != "rotate" {
+		log.Event(nil, "unknown tokens command, usage: tokens rotate -r=[ROOT] -zeb-dir=[ZEBEDEE_PROJECT_PATH]")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("tokens rotate", flag.ExitOnError)
+	root := fs.String("r", "", "the root directory of the zebedee directory structure to rotate the tokens of")
+	zebDir := fs.String("zeb-dir", "", "the root directory path of your zebedee project, its run-cms.sh is updated")
+	stackDir := fs.String("stack_dir", "", "the dir the local publishing stack settings were written to, they are updated if set")
+	serviceAccountID := fs.String("service_account_id", services.DefaultID, "the id of the new service account")
+	fs.Parse(args[1:])
+
+	if *root == "" || *zebDir == "" {
+		log.Event(nil, "please specify a root dir and the path to the root of you zebedee project, use -h to see the help menu")
+		os.Exit(1)
+	}
+
+	rotation, updated, err := rotateTokens(*root, *zebDir, *stackDir, *serviceAccountID)
+	if err != nil {
+		errorAndExit(err)
+	}
+
+	log.Event(nil, "successfully rotated tokens", log.Data{
+		"updated_files":            updated,
+		"removed_service_accounts": rotation.RemovedServiceAccounts,
+		cms.ServiceAuthTokenEnv:    rotation.ServiceAccount,
+		cms.DatasetAPIAuthTokenEnv: rotation.DatasetAPIAuthToken,
+	})
+
+	fmt.Println("Token rotation summary")
+	fmt.Printf("  service account:   %s\n", rotation.ServiceAccount)
+	for _, id := range rotation.RemovedServiceAccounts {
+		fmt.Printf("  removed account:   %s\n", id)
+	}
+	fmt.Printf("  dataset api token: %s\n", rotation.DatasetAPIAuthToken)
+	for _, filename := range updated {
+		fmt.Printf("  updated:           %s\n", filename)
+	}
+}
+
+// rotateTokens rotates the tokens of the zebedee root and updates them in run-cms.sh and the stack files. The files
+// are checked before the service accounts are changed so a wrong -zeb-dir or -stack_dir can't leave run-cms.sh using
+// a revoked account. Returns the rotation and the files updated.
+func rotateTokens(root string, zebDir string, stackDir string, serviceAccountID string) (*cms.TokenRotation, []string, error) {
+	filenames, err := scripts.TokenFiles(zebDir, stackDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	builder, err := cms.Open(root, false, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	builder.UseServiceAccountID(serviceAccountID)
+
+	rotation, err := builder.RotateTokens()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updated, err := scripts.UpdateTokenFiles(filenames, builder.GetRunTemplate())
+	if err != nil {
+		return nil, nil, err
+	}
+	return rotation, updated, nil
+}
+
+func isStackFormat(format string) bool {
+	for _, f := range scripts.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
 func errorAndExit(err error) {
 	log.Event(nil, "unexpected error", log.Error(err))
 	os.Exit(1)
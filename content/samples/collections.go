@@ -0,0 +1,226 @@
+package samples
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/content/accounts"
+	"github.com/ONSdigital/dp-zebedee-utils/pages"
+	"github.com/pkg/errors"
+)
+
+// Collection content dirs.
+const (
+	inProgress = "inprogress"
+	complete   = "complete"
+	reviewed   = "reviewed"
+)
+
+// State is a collection lifecycle state. Content is the dir the collection's pages are in, empty for no pages.
+// Encrypted collections have their content encrypted with a new collection key.
+type State struct {
+	Name           string
+	ApprovalStatus string
+	Type           string
+	Content        string
+	Encrypted      bool
+}
+
+// States is the sample collections created, one in each lifecycle state Florence displays. Zebedee can only read an
+// encrypted collection if its key is in the keyring of the user, so it is only created if there are users to give the
+// key to.
+var States = []State{
+	{Name: "sample-empty", ApprovalStatus: collections.ApprovalNotStarted, Type: collections.TypeManual},
+	{Name: "sample-in-progress", ApprovalStatus: collections.ApprovalNotStarted, Type: collections.TypeManual, Content: inProgress},
+	{Name: "sample-awaiting-review", ApprovalStatus: collections.ApprovalNotStarted, Type: collections.TypeManual, Content: complete},
+	{Name: "sample-approved", ApprovalStatus: collections.ApprovalComplete, Type: collections.TypeManual, Content: reviewed},
+	{Name: "sample-scheduled", ApprovalStatus: collections.ApprovalComplete, Type: collections.TypeScheduled, Content: reviewed},
+	{Name: "sample-failed-approval", ApprovalStatus: collections.ApprovalError, Type: collections.TypeManual, Content: reviewed},
+	{Name: "sample-encrypted", ApprovalStatus: collections.ApprovalNotStarted, Type: collections.TypeManual, Content: inProgress, Encrypted: true},
+}
+
+// Created is a sample collection that was created and the uris of the pages in it. Key is the collection key of an
+// encrypted collection.
+type Created struct {
+	Collection string   `json:"collection"`
+	ID         string   `json:"id"`
+	Pages      []string `json:"pages"`
+	Key        []byte   `json:"-"`
+}
+
+// CreateCollections creates a collection in each of the States, the encrypted states only if encrypted is true. Each
+// collection with content gets an edited copy of a different master page, so no page is in more than one collection,
+// and a new static page under the edited page. Scheduled collections are scheduled to publish at publishDate.
+func CreateCollections(masterDir string, collectionsDir string, publishDate time.Time, encrypted bool) ([]Created, error) {
+	uris, err := masterPages(masterDir)
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]Created, 0, len(States))
+	for _, s := range States {
+		if s.Encrypted && !encrypted {
+			continue
+		}
+
+		c := collections.New(collectionsDir, s.Name)
+		c.ApprovalStatus = s.ApprovalStatus
+		c.Type = s.Type
+		c.IsEncrypted = s.Encrypted
+		if s.Type == collections.TypeScheduled {
+			c.PublishDate = publishDate.UTC().Format("2006-01-02T15:04:05.000Z")
+		}
+
+		if err := collections.Save(c); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("error creating sample collection: %s", s.Name))
+		}
+
+		result := Created{Collection: c.Name, ID: c.ID, Pages: make([]string, 0)}
+		if s.Encrypted {
+			if result.Key, err = accounts.NewCollectionKey(); err != nil {
+				return nil, err
+			}
+		}
+
+		if s.Content != "" {
+			var edited string
+			if len(uris) > 0 {
+				edited, uris = uris[0], uris[1:]
+			}
+
+			if result.Pages, err = addPages(masterDir, c, s.Content, edited, result.Key); err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("error adding pages to sample collection: %s", s.Name))
+			}
+		}
+		created = append(created, result)
+	}
+	return created, nil
+}
+
+// addPages adds an edited copy of the master page at uri, if there is one, and a new static page to the content dir of
+// the collection, encrypted with key if it is not nil. Returns the uris of the pages added.
+func addPages(masterDir string, c *collections.Collection, dir string, uri string, key []byte) ([]string, error) {
+	added := make([]string, 0, 2)
+	parent := "/"
+
+	if uri != "" {
+		b, err := ioutil.ReadFile(filepath.Join(masterDir, uri, "data.json"))
+		if err != nil {
+			return nil, err
+		}
+
+		if b, err = edit(b, c.Name); err != nil {
+			return nil, err
+		}
+
+		if err := writePage(path.Join(c.Metadata.CollectionRoot, dir, uri, "data.json"), b, key); err != nil {
+			return nil, err
+		}
+		added = append(added, uri)
+		parent = uri
+	}
+
+	newURI := path.Join(parent, "about"+slug(c.Name))
+	page := &pages.StaticPage{
+		Base: pages.Base{
+			Type: pages.TypeStaticPage,
+			URI:  newURI,
+			Description: &pages.Description{
+				Title:   "About " + c.Name,
+				Summary: "A new page created in the " + c.Name + " collection.",
+			},
+		},
+		Markdown:  []string{"This page was created in the sample collection " + c.Name + " and has not been published."},
+		Links:     make([]pages.Link, 0),
+		Downloads: make([]pages.Download, 0),
+	}
+
+	encoded, err := pages.Encode(page)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := json.Indent(&b, encoded, "", "  "); err != nil {
+		return nil, err
+	}
+
+	if err := writePage(path.Join(c.Metadata.CollectionRoot, dir, newURI, "data.json"), b.Bytes(), key); err != nil {
+		return nil, err
+	}
+	return append(added, newURI), nil
+}
+
+// writePage writes the page to filename, encrypted with key if it is not nil.
+func writePage(filename string, b []byte, key []byte) error {
+	if key != nil {
+		var err error
+		if b, err = accounts.EncryptContent(key, b); err != nil {
+			return err
+		}
+	}
+	return collections.WriteContent(filename, b)
+}
+
+// edit returns the page with its summary changed to show the collection it was edited in.
+func edit(b []byte, collection string) ([]byte, error) {
+	p, err := pages.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if d := p.GetDescription(); d != nil {
+		d.Summary = fmt.Sprintf("%s (edited in %s)", d.Summary, collection)
+	}
+	return pages.Encode(p)
+}
+
+// masterPages returns the uris of the pages in master, sorted so the same master always gives the same collections.
+// Pages inside previous versions are left out.
+func masterPages(masterDir string) ([]string, error) {
+	uris := make([]string, 0)
+	err := filepath.Walk(masterDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() && info.Name() == "previous" {
+			return filepath.SkipDir
+		}
+
+		if info.IsDir() || info.Name() != "data.json" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(masterDir, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		uris = append(uris, path.Join("/", filepath.ToSlash(rel)))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing master pages")
+	}
+
+	sort.Strings(uris)
+	return uris, nil
+}
+
+func slug(name string) string {
+	s := make([]rune, 0, len(name))
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			s = append(s, r)
+		}
+	}
+	return string(s)
+}
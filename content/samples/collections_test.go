@@ -0,0 +1,135 @@
+package samples
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/content/accounts"
+	"github.com/ONSdigital/dp-zebedee-utils/pages"
+)
+
+// testMaster creates a zebedee root with a master of count static pages and an empty collections dir.
+func testMaster(t *testing.T, count int) (string, string, string) {
+	root, err := ioutil.TempDir("", "samples")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	masterDir := filepath.Join(root, "master")
+	collectionsDir := filepath.Join(root, "collections")
+	if err := os.MkdirAll(collectionsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < count; i++ {
+		uri := "/page" + string(rune('a'+i))
+		b := `{"type":"static_page","uri":"` + uri + `","description":{"title":"Page","summary":"A page"}}`
+		if err := collections.WriteContent(filepath.Join(masterDir, uri, "data.json"), []byte(b)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root, masterDir, collectionsDir
+}
+
+func TestCreateCollections(t *testing.T) {
+	root, masterDir, collectionsDir := testMaster(t, len(States))
+	defer os.RemoveAll(root)
+
+	publishDate := time.Date(2020, 6, 1, 9, 30, 0, 0, time.UTC)
+	created, err := CreateCollections(masterDir, collectionsDir, publishDate, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(created) != len(States) {
+		t.Fatalf("expected a collection in each state, got %d", len(created))
+	}
+
+	edited := make(map[string]bool)
+	for i, s := range States {
+		c, err := collections.GetCollection(collectionsDir, s.Name)
+		if err != nil || c == nil {
+			t.Fatalf("%s: expected the collection to be saved: %v", s.Name, err)
+		}
+
+		if c.ID != created[i].ID || c.ApprovalStatus != s.ApprovalStatus || c.Type != s.Type || c.IsEncrypted != s.Encrypted {
+			t.Errorf("%s: expected %+v, got %+v", s.Name, s, c)
+		}
+
+		if s.Type == collections.TypeScheduled && c.PublishDate != "2020-06-01T09:30:00.000Z" {
+			t.Errorf("%s: expected the publish date to be set, got %q", s.Name, c.PublishDate)
+		}
+
+		if s.Encrypted != (created[i].Key != nil) {
+			t.Errorf("%s: expected a collection key only for an encrypted collection", s.Name)
+		}
+
+		if s.Content == "" {
+			if len(created[i].Pages) != 0 {
+				t.Errorf("%s: expected no pages, got %v", s.Name, created[i].Pages)
+			}
+			continue
+		}
+
+		if len(created[i].Pages) != 2 {
+			t.Fatalf("%s: expected an edited and a new page, got %v", s.Name, created[i].Pages)
+		}
+
+		if edited[created[i].Pages[0]] {
+			t.Errorf("%s: expected %s not to be in another collection", s.Name, created[i].Pages[0])
+		}
+		edited[created[i].Pages[0]] = true
+
+		for _, uri := range created[i].Pages {
+			b, err := ioutil.ReadFile(path.Join(c.GetRootPath(), s.Content, uri, "data.json"))
+			if err != nil {
+				t.Fatalf("%s: expected %s in %s: %v", s.Name, uri, s.Content, err)
+			}
+
+			if s.Encrypted {
+				if b, err = accounts.DecryptContent(created[i].Key, b); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			p, err := pages.Decode(b)
+			if err != nil {
+				t.Fatalf("%s: expected %s to be a page: %v", s.Name, uri, err)
+			}
+
+			if p.GetURI() != uri {
+				t.Errorf("%s: expected the page uri to be %s, got %s", s.Name, uri, p.GetURI())
+			}
+		}
+	}
+}
+
+func TestCreateCollectionsWithoutEncrypted(t *testing.T) {
+	root, masterDir, collectionsDir := testMaster(t, 1)
+	defer os.RemoveAll(root)
+
+	created, err := CreateCollections(masterDir, collectionsDir, time.Now(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range created {
+		if c.Key != nil || c.Collection == "sample-encrypted" {
+			t.Errorf("expected no encrypted collection, got %s", c.Collection)
+		}
+	}
+
+	if collections.Exists(filepath.Join(collectionsDir, "sample-encrypted.json")) {
+		t.Error("expected the encrypted collection not to be saved")
+	}
+
+	// collections after the first with content have no master page left to edit and only get a new page.
+	if len(created[1].Pages) != 2 || len(created[2].Pages) != 1 {
+		t.Errorf("expected only the first collection with content to edit a master page, got %+v", created)
+	}
+}
@@ -2,84 +2,53 @@ package scripts
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
-	"os"
-	"path"
+	"path/filepath"
 	"text/template"
 
+	"github.com/ONSdigital/dp-zebedee-utils/content/bundle"
 	"github.com/ONSdigital/dp-zebedee-utils/content/cms"
-	"github.com/ONSdigital/dp-zebedee-utils/content/files"
 	"github.com/ONSdigital/log.go/log"
 	"github.com/pkg/errors"
 )
 
-var (
-	templateFile = "templates/run.cms.template.txt"
-	output       = "generated"
-	cmsRunFile   = output + "/run-cms.sh"
-)
-
-func GenerateCMSRunScript(t *cms.RunTemplate) (string, error) {
-	if err := houseKeeping(); err != nil {
-		return "", err
-	}
+const cmsRunFile = "run-cms.sh"
 
-	tmpl, err := template.ParseFiles(templateFile)
+// GenerateCMSRunScript writes run-cms.sh to the zebedee project dir, returning its path. templateFile is the path of
+// the template to use, if empty the template bundled in the builder is used.
+func GenerateCMSRunScript(zebDir string, templateFile string, t *cms.RunTemplate) (string, error) {
+	tmpl, err := loadTemplate(templateFile)
 	if err != nil {
-		return "", errors.Wrap(err, "error loading template file")
+		return "", err
 	}
 
 	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, t)
-	if err != nil {
-		log.Event(nil, "error generating run.sh from template", log.Error(err))
-		os.Exit(1)
+	if err := tmpl.Execute(&buf, t); err != nil {
+		return "", errors.Wrap(err, "error generating run-cms.sh from template")
 	}
 
-	err = ioutil.WriteFile(cmsRunFile, buf.Bytes(), 0644)
-	if err != nil {
-		return "", errors.Wrap(err, "error generating run.sh from template")
-	}
-	return cmsRunFile, nil
-}
+	target := filepath.Join(zebDir, cmsRunFile)
+	log.Event(nil, "writing run-cms.sh to zebedee project dir", log.Data{"target": target})
 
-func houseKeeping() error {
-	exists, err := files.Exists(output)
-	if err != nil {
-		return err
-	}
-
-	if !exists {
-		if err := os.Mkdir(output, 0700); err != nil {
-			return errors.Wrap(err, "error creating generated dir")
-		}
-	}
-
-	exists, err = files.Exists(cmsRunFile)
-	if err != nil {
-		return err
+	if err := ioutil.WriteFile(target, buf.Bytes(), 0700); err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("error writing run-cms.sh: %s", target))
 	}
+	return target, nil
+}
 
-	if exists {
-		log.Event(nil, "removing existing run-cms.sh file")
-		if err := os.Remove(cmsRunFile); err != nil {
-			return err
+func loadTemplate(templateFile string) (*template.Template, error) {
+	if templateFile == "" {
+		tmpl, err := template.New(cmsRunFile).Parse(bundle.RunCMSTemplate())
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing bundled template")
 		}
+		return tmpl, nil
 	}
 
-	return nil
-}
-
-func CopyToProjectDir(zebDir string, generatedFile string) (string, error) {
-	target := path.Join(zebDir, "run-cms.sh")
-	log.Event(nil, "copying run-cms.sh to zebedee project dir", log.Data{"target": target})
-	b, err := ioutil.ReadFile(generatedFile)
+	tmpl, err := template.ParseFiles(templateFile)
 	if err != nil {
-		return "", err
-	}
-
-	if err := ioutil.WriteFile(target, b, 0700); err != nil {
-		return "", err
+		return nil, errors.Wrap(err, fmt.Sprintf("error loading template file: %s", templateFile))
 	}
-	return target, nil
+	return tmpl, nil
 }
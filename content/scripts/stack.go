@@ -0,0 +1,357 @@
+package scripts
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ONSdigital/dp-zebedee-utils/content/bundle"
+	"github.com/ONSdigital/dp-zebedee-utils/content/cms"
+	"github.com/ONSdigital/log.go/log"
+	"github.com/pkg/errors"
+)
+
+// Stack output formats.
+const (
+	FormatScripts = "scripts"
+	FormatDotenv  = "dotenv"
+	FormatEnvFile = "env-file"
+	FormatCompose = "compose"
+)
+
+// Apps in the local publishing stack.
+const (
+	Zebedee        = "zebedee"
+	ZebedeeReader  = "zebedee-reader"
+	Florence       = "florence"
+	Babbage        = "babbage"
+	DatasetAPIStub = "dataset-api-stub"
+)
+
+const (
+	dotenvFile  = ".env"
+	envFile     = "docker.env"
+	composeFile = "docker-compose.yml"
+	dockerRoot  = "/content"
+	dockerSrc   = "/src"
+)
+
+// composeRun is how an app without a Dockerfile of its own is run by docker-compose: its project dir is mounted in
+// an image with its build tools and the command is run in it.
+type composeRun struct {
+	image   string
+	command string
+}
+
+// composeRuns is the apps that can't be built from their project dir. The reader is in the zebedee project but its
+// Dockerfile only runs the CMS, and the dataset api stub is a cmd of this project which has no Dockerfile.
+var composeRuns = map[string]composeRun{
+	ZebedeeReader: {
+		image: "maven:3-jdk-8",
+		command: "mvn -pl zebedee-reader -am package dependency:copy-dependencies -Dmaven.test.skip=true && " +
+			"java -Dlogback.configurationFile=zebedee-reader/target/classes/logback.xml " +
+			"-Drestolino.classes=zebedee-reader/target/classes " +
+			"-Drestolino.packageprefix=com.github.onsdigital.zebedee.reader.api " +
+			"-DSTART_EMBEDDED_SERVER=N " +
+			"-cp 'zebedee-reader/target/classes:zebedee-reader/target/dependency/*' " +
+			"com.github.davidcarboni.restolino.Main",
+	},
+	DatasetAPIStub: {
+		image:   "golang:1.13",
+		command: "go run ./content/datasetapistub",
+	},
+}
+
+// Ports of the apps in the local publishing stack. Zebedee and the dataset api stub use the ports the run-cms.sh
+// template is generated with.
+var Ports = map[string]int{
+	Zebedee:        8082,
+	ZebedeeReader:  8083,
+	Florence:       8081,
+	Babbage:        8080,
+	DatasetAPIStub: 22000,
+}
+
+// Formats is the stack output formats.
+var Formats = []string{FormatScripts, FormatDotenv, FormatEnvFile, FormatCompose}
+
+// Stack is the settings of the apps in the local publishing stack. Every app is configured from the same ports and
+// tokens so they can talk to each other.
+type Stack struct {
+	ZebedeeRoot         string
+	ProjectsDir         string
+	EnableDatasetImport bool
+	ServiceAuthToken    string
+	DatasetAPIAuthToken string
+	docker              bool
+}
+
+// Service is an app in the stack. Dir is its project dir and Env the env vars it is run with.
+type Service struct {
+	Name string
+	Dir  string
+	Port int
+	Env  []EnvVar
+}
+
+// EnvVar is an environment variable.
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+// NewStack returns the stack for the zebedee root and tokens of the run template. projectsDir is the dir the app
+// projects are checked out in, e.g. projectsDir/florence.
+func NewStack(t *cms.RunTemplate, projectsDir string) *Stack {
+	return &Stack{
+		ZebedeeRoot:         t.ZebedeeRoot,
+		ProjectsDir:         projectsDir,
+		EnableDatasetImport: t.EnableDatasetImport,
+		ServiceAuthToken:    t.ServiceAuthToken,
+		DatasetAPIAuthToken: t.DatasetAPIAuthToken,
+	}
+}
+
+// Services returns the apps in the stack.
+func (s *Stack) Services() []Service {
+	enableDatasetImport := strconv.FormatBool(s.EnableDatasetImport)
+	zebedeeRoot := s.ZebedeeRoot
+	if s.docker {
+		zebedeeRoot = dockerRoot
+	}
+
+	return []Service{
+		{
+			Name: Zebedee,
+			Dir:  filepath.Join(s.ProjectsDir, "zebedee"),
+			Port: Ports[Zebedee],
+			Env: []EnvVar{
+				{"zebedee_root", zebedeeRoot},
+				{"PORT", s.port(Zebedee)},
+				{cms.EnableCMDEnv, enableDatasetImport},
+				{cms.DatasetAPIURLEnv, s.url(DatasetAPIStub)},
+				{cms.DatasetAPIAuthTokenEnv, s.DatasetAPIAuthToken},
+				{cms.ServiceAuthTokenEnv, s.ServiceAuthToken},
+			},
+		},
+		{
+			Name: ZebedeeReader,
+			Dir:  filepath.Join(s.ProjectsDir, "zebedee"),
+			Port: Ports[ZebedeeReader],
+			Env: []EnvVar{
+				{"zebedee_root", zebedeeRoot},
+				{"PORT", s.port(ZebedeeReader)},
+			},
+		},
+		{
+			Name: Florence,
+			Dir:  filepath.Join(s.ProjectsDir, "florence"),
+			Port: Ports[Florence],
+			Env: []EnvVar{
+				{"BIND_ADDR", ":" + s.port(Florence)},
+				{"ZEBEDEE_URL", s.url(Zebedee)},
+				// there is no router in the stack so Florence previews pages straight from Babbage
+				{"ROUTER_URL", s.url(Babbage)},
+				{cms.EnableCMDEnv, enableDatasetImport},
+			},
+		},
+		{
+			Name: Babbage,
+			Dir:  filepath.Join(s.ProjectsDir, "babbage"),
+			Port: Ports[Babbage],
+			Env: []EnvVar{
+				{"PORT", s.port(Babbage)},
+				{"CONTENT_SERVICE_URL", s.url(Zebedee)},
+			},
+		},
+		{
+			Name: DatasetAPIStub,
+			Dir:  filepath.Join(s.ProjectsDir, "dp-zebedee-utils"),
+			Port: Ports[DatasetAPIStub],
+			Env: []EnvVar{
+				{"BIND_ADDR", ":" + s.port(DatasetAPIStub)},
+				{cms.DatasetAPIAuthTokenEnv, s.DatasetAPIAuthToken},
+				{cms.ServiceAuthTokenEnv, s.ServiceAuthToken},
+			},
+		},
+	}
+}
+
+// Settings returns the settings shared by the stack: the zebedee root, tokens and the port and url of each app.
+func (s *Stack) Settings() []EnvVar {
+	settings := []EnvVar{
+		{"ZEBEDEE_ROOT", s.ZebedeeRoot},
+		{cms.EnableCMDEnv, strconv.FormatBool(s.EnableDatasetImport)},
+		{cms.DatasetAPIAuthTokenEnv, s.DatasetAPIAuthToken},
+		{cms.ServiceAuthTokenEnv, s.ServiceAuthToken},
+	}
+
+	for _, svc := range s.Services() {
+		prefix := envName(svc.Name)
+		settings = append(settings, EnvVar{prefix + "_PORT", s.port(svc.Name)}, EnvVar{prefix + "_URL", s.url(svc.Name)})
+	}
+	return settings
+}
+
+// GenerateStack writes the stack in each of the formats to dir, returning the paths of the files written. The
+// scripts format writes a run script for each app other than Zebedee, which is run with run-cms.sh.
+func GenerateStack(dir string, s *Stack, formats []string) ([]string, error) {
+	written := make([]string, 0)
+	for _, format := range formats {
+		var files []string
+		var err error
+
+		switch format {
+		case FormatScripts:
+			files, err = writeRunScripts(dir, s)
+		case FormatDotenv:
+			files, err = writeFile(filepath.Join(dir, dotenvFile), envFileContent(s.Settings(), true), 0644)
+		case FormatEnvFile:
+			files, err = writeFile(filepath.Join(dir, envFile), envFileContent(s.Settings(), false), 0644)
+		case FormatCompose:
+			files, err = writeFile(filepath.Join(dir, composeFile), composeContent(s), 0644)
+		default:
+			return nil, errors.New(fmt.Sprintf("unknown stack format %q expected one of %s", format, strings.Join(Formats, ", ")))
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		written = append(written, files...)
+	}
+
+	log.Event(nil, "generated local publishing stack", log.Data{"formats": formats, "files": written})
+	return written, nil
+}
+
+func writeRunScripts(dir string, s *Stack) ([]string, error) {
+	written := make([]string, 0)
+	for _, svc := range s.Services() {
+		if svc.Name == Zebedee {
+			continue
+		}
+
+		name := runScriptName(svc.Name)
+		text, ok := bundle.RunTemplate(svc.Name)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("no bundled run script template for %s", svc.Name))
+		}
+
+		tmpl, err := template.New(name).Parse(text)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("error parsing bundled template: %s", name))
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, svc); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("error generating %s from template", name))
+		}
+
+		files, err := writeFile(filepath.Join(dir, name), buf.Bytes(), 0700)
+		if err != nil {
+			return nil, err
+		}
+		written = append(written, files...)
+	}
+	return written, nil
+}
+
+// stackFiles returns the names of the files GenerateStack can write.
+func stackFiles() []string {
+	names := []string{dotenvFile, envFile, composeFile}
+	for name := range Ports {
+		if name != Zebedee {
+			names = append(names, runScriptName(name))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func runScriptName(name string) string {
+	return "run-" + name + ".sh"
+}
+
+// envFileContent returns the settings one per line. Dotenv values are quoted, Docker env-file values are not as Docker
+// uses everything after the = as the value.
+func envFileContent(settings []EnvVar, quote bool) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("# generated by dp-zebedee-utils/content\n")
+	for _, v := range settings {
+		value := v.Value
+		if quote {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&buf, "%s=%s\n", v.Name, value)
+	}
+	return buf.Bytes()
+}
+
+// composeContent returns a docker-compose file building each app from its project dir. In the compose file the apps
+// reach each other by service name and the zebedee root is mounted at /content.
+func composeContent(s *Stack) []byte {
+	docker := *s
+	docker.docker = true
+
+	var buf bytes.Buffer
+	buf.WriteString("# generated by dp-zebedee-utils/content\n")
+	buf.WriteString("version: \"3\"\n")
+	buf.WriteString("services:\n")
+	for _, svc := range docker.Services() {
+		volumes := make([]string, 0)
+		fmt.Fprintf(&buf, "  %s:\n", svc.Name)
+		if run, ok := composeRuns[svc.Name]; ok {
+			fmt.Fprintf(&buf, "    image: %s\n", strconv.Quote(run.image))
+			fmt.Fprintf(&buf, "    working_dir: %s\n", strconv.Quote(dockerSrc))
+			fmt.Fprintf(&buf, "    command: [\"sh\", \"-c\", %s]\n", strconv.Quote(run.command))
+			volumes = append(volumes, svc.Dir+":"+dockerSrc)
+		} else {
+			fmt.Fprintf(&buf, "    build: %s\n", strconv.Quote(svc.Dir))
+		}
+		fmt.Fprintf(&buf, "    ports:\n      - \"%d:%d\"\n", svc.Port, svc.Port)
+		if svc.Name == Zebedee || svc.Name == ZebedeeReader {
+			volumes = append(volumes, s.ZebedeeRoot+":"+dockerRoot)
+		}
+		if len(volumes) > 0 {
+			buf.WriteString("    volumes:\n")
+			for _, v := range volumes {
+				fmt.Fprintf(&buf, "      - %s\n", strconv.Quote(v))
+			}
+		}
+		buf.WriteString("    environment:\n")
+		for _, v := range svc.Env {
+			fmt.Fprintf(&buf, "      %s: %s\n", v.Name, strconv.Quote(v.Value))
+		}
+	}
+	return buf.Bytes()
+}
+
+func writeFile(filename string, b []byte, perm os.FileMode) ([]string, error) {
+	if err := ioutil.WriteFile(filename, b, perm); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("error writing %s", filename))
+	}
+	return []string{filename}, nil
+}
+
+// url returns the url of the app, by service name when the stack is run with docker-compose.
+func (s *Stack) url(name string) string {
+	host := "localhost"
+	if s.docker {
+		host = name
+	}
+	return fmt.Sprintf("http://%s:%d", host, Ports[name])
+}
+
+func (s *Stack) port(name string) string {
+	return strconv.Itoa(Ports[name])
+}
+
+func envName(name string) string {
+	return strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
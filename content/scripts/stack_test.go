@@ -0,0 +1,84 @@
+package scripts
+
+import (
+	"strings"
+	"testing"
+)
+
+func testStack() *Stack {
+	return &Stack{
+		ZebedeeRoot:         "/data/zebedee",
+		ProjectsDir:         "/projects",
+		EnableDatasetImport: true,
+		ServiceAuthToken:    "service-token",
+		DatasetAPIAuthToken: "dataset-token",
+	}
+}
+
+// composeService returns the lines of the service in the compose file.
+func composeService(compose string, name string) string {
+	start := strings.Index(compose, "\n  "+name+":\n")
+	if start < 0 {
+		return ""
+	}
+
+	rest := compose[start+1:]
+	end := len(rest)
+	for _, line := range strings.SplitAfter(rest, "\n")[1:] {
+		if strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "    ") {
+			end = strings.Index(rest, line)
+			break
+		}
+	}
+	return rest[:end]
+}
+
+func TestComposeContent(t *testing.T) {
+	compose := string(composeContent(testStack()))
+
+	cases := map[string][]string{
+		Zebedee: {
+			`build: "/projects/zebedee"`,
+			`- "/data/zebedee:/content"`,
+			`zebedee_root: "/content"`,
+			`DATASET_API_URL: "http://dataset-api-stub:22000"`,
+		},
+		ZebedeeReader: {
+			`image: "maven:3-jdk-8"`,
+			`working_dir: "/src"`,
+			`com.github.onsdigital.zebedee.reader.api`,
+			`- "/projects/zebedee:/src"`,
+			`- "/data/zebedee:/content"`,
+		},
+		DatasetAPIStub: {
+			`image: "golang:1.13"`,
+			`command: ["sh", "-c", "go run ./content/datasetapistub"]`,
+			`- "/projects/dp-zebedee-utils:/src"`,
+			`SERVICE_AUTH_TOKEN: "service-token"`,
+		},
+		Florence: {
+			`build: "/projects/florence"`,
+			`ZEBEDEE_URL: "http://zebedee:8082"`,
+		},
+	}
+
+	for name, expected := range cases {
+		svc := composeService(compose, name)
+		if svc == "" {
+			t.Errorf("expected compose file to have service %s", name)
+			continue
+		}
+
+		for _, e := range expected {
+			if !strings.Contains(svc, e) {
+				t.Errorf("%s: expected %s in\n%s", name, e, svc)
+			}
+		}
+	}
+
+	for _, name := range []string{ZebedeeReader, DatasetAPIStub} {
+		if strings.Contains(composeService(compose, name), "build:") {
+			t.Errorf("%s: expected service not to be built from its project dir", name)
+		}
+	}
+}
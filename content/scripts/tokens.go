@@ -0,0 +1,133 @@
+package scripts
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ONSdigital/dp-zebedee-utils/content/cms"
+	"github.com/ONSdigital/dp-zebedee-utils/content/files"
+	"github.com/ONSdigital/log.go/log"
+	"github.com/pkg/errors"
+)
+
+// UpdateTokens sets the service auth token and dataset API auth token in run-cms.sh in zebDir and, if stackDir is not
+// empty, in each of the stack files found in it. The files are updated in place so anything else in them, including
+// changes made since they were generated, is kept. Returns the paths of the files updated.
+func UpdateTokens(zebDir string, stackDir string, t *cms.RunTemplate) ([]string, error) {
+	filenames, err := TokenFiles(zebDir, stackDir)
+	if err != nil {
+		return nil, err
+	}
+	return UpdateTokenFiles(filenames, t)
+}
+
+// TokenFiles returns the files UpdateTokens updates, run-cms.sh in zebDir, which must exist, and the stack files found
+// in stackDir. Each file is checked to be writable so the tokens can be changed knowing the files can be updated.
+func TokenFiles(zebDir string, stackDir string) ([]string, error) {
+	runScript := filepath.Join(zebDir, cmsRunFile)
+	exists, err := files.Exists(runScript)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, errors.New(fmt.Sprintf("no %s to update in the zebedee project dir: %s", cmsRunFile, zebDir))
+	}
+
+	filenames := []string{runScript}
+	if stackDir != "" {
+		for _, name := range stackFiles() {
+			filename := filepath.Join(stackDir, name)
+			if exists, err := files.Exists(filename); err != nil {
+				return nil, err
+			} else if exists {
+				filenames = append(filenames, filename)
+			}
+		}
+	}
+
+	for _, filename := range filenames {
+		f, err := os.OpenFile(filename, os.O_WRONLY, 0)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("cannot update the tokens in %s", filename))
+		}
+		f.Close()
+	}
+	return filenames, nil
+}
+
+// UpdateTokenFiles sets the service auth token and dataset API auth token in each of the files, as returned by
+// TokenFiles. Returns the paths of the files updated.
+func UpdateTokenFiles(filenames []string, t *cms.RunTemplate) ([]string, error) {
+	tokens := map[string]string{
+		cms.ServiceAuthTokenEnv:    t.ServiceAuthToken,
+		cms.DatasetAPIAuthTokenEnv: t.DatasetAPIAuthToken,
+	}
+
+	updated := make([]string, 0, len(filenames))
+	for _, filename := range filenames {
+		changed, err := updateSettings(filename, tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		if changed {
+			updated = append(updated, filename)
+		}
+	}
+
+	log.Event(nil, "updated tokens in generated files", log.Data{"files": updated})
+	return updated, nil
+}
+
+// ReadSetting returns the value a generated file sets name to, or empty if it does not set it.
+func ReadSetting(filename string, name string) (string, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("error reading %s", filename))
+	}
+
+	m := settingRegex(name).FindSubmatch(b)
+	if m == nil {
+		return "", nil
+	}
+	return string(m[3]), nil
+}
+
+// updateSettings sets the value of each setting in the file, keeping any quotes around the old value. Returns true if
+// the file was changed.
+func updateSettings(filename string, settings map[string]string) (bool, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return false, errors.Wrap(err, fmt.Sprintf("error reading %s", filename))
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return false, errors.Wrap(err, fmt.Sprintf("error reading %s", filename))
+	}
+
+	updated := b
+	for name, value := range settings {
+		updated = settingRegex(name).ReplaceAll(updated, []byte("${1}${2}"+strings.Replace(value, "$", "$$", -1)+"${4}"))
+	}
+
+	if string(updated) == string(b) {
+		return false, nil
+	}
+
+	if err := ioutil.WriteFile(filename, updated, info.Mode()); err != nil {
+		return false, errors.Wrap(err, fmt.Sprintf("error writing %s", filename))
+	}
+	return true, nil
+}
+
+// settingRegex matches a line setting name in any of the generated formats: "export NAME=value", "NAME=value" or the
+// docker-compose "NAME: value", with or without quotes.
+func settingRegex(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^(\s*(?:export\s+)?` + regexp.QuoteMeta(name) + `\s*[=:]\s*)("?)([^"\n]*)("?)[ \t]*$`)
+}
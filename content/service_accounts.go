@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ONSdigital/dp-zebedee-utils/content/cms"
+	"github.com/ONSdigital/dp-zebedee-utils/content/files"
+	"github.com/ONSdigital/dp-zebedee-utils/content/scripts"
+	"github.com/ONSdigital/dp-zebedee-utils/content/services"
+	"github.com/ONSdigital/log.go/log"
+)
+
+const serviceAccountsUsage = "usage: service-accounts list|create|revoke -r=[ROOT], use -h after the command to see its flags"
+
+// serviceAccounts runs the service-accounts subcommands on the services dir of a zebedee root.
+func serviceAccounts(args []string) {
+	if len(args) == 0 {
+		log.Event(nil, serviceAccountsUsage)
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("service-accounts "+args[0], flag.ExitOnError)
+	root := fs.String("r", "", "the root directory of the zebedee directory structure")
+	zebDir := fs.String("zeb-dir", "", "the root directory path of your zebedee project, its run-cms.sh is used to find the account in use")
+
+	var id, name, token, created *string
+	switch args[0] {
+	case "list":
+	case "create":
+		id = fs.String("id", "", "the id of the service account, the id Zebedee identifies the service by")
+		name = fs.String("name", "", "the name of the service account")
+		token = fs.String("token", "", "the token of the service account, a random token is generated if empty")
+		created = fs.String("created", "", "the RFC3339 creation time of the service account, defaults to now")
+	case "revoke":
+		token = fs.String("token", "", "the token of the service account to revoke")
+	default:
+		log.Event(nil, serviceAccountsUsage)
+		os.Exit(1)
+	}
+	fs.Parse(args[1:])
+
+	if *root == "" {
+		log.Event(nil, "please specify a root dir, use -h to see the help menu")
+		os.Exit(1)
+	}
+
+	servicesDir := filepath.Join(*root, cms.Zebedee, cms.Services)
+	if exists, err := files.Exists(servicesDir); err != nil {
+		errorAndExit(err)
+	} else if !exists {
+		log.Event(nil, "there is no services dir at the root location provided", log.Data{"services_dir": servicesDir})
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "create":
+		if *id == "" {
+			log.Event(nil, "please specify the -id of the service account to create")
+			os.Exit(1)
+		}
+
+		if a := (services.Account{ID: *id, Name: *name}); a.IsGenerated() {
+			log.Event(nil, "the account would be treated as generated by the builder, please specify a different -name", log.Data{"id": *id, "name": *name})
+			os.Exit(1)
+		}
+
+		a := services.Account{ID: *id, Name: *name, Token: *token}
+		if *created != "" {
+			t, err := time.Parse(time.RFC3339, *created)
+			if err != nil {
+				log.Event(nil, "invalid -created time expected RFC3339 e.g. 2020-01-15T09:30:00Z", log.Error(err))
+				os.Exit(1)
+			}
+			a.Created = t.UTC().Format(services.DateFormat)
+		}
+
+		account, err := services.Create(servicesDir, a)
+		if err != nil {
+			errorAndExit(err)
+		}
+		log.Event(nil, "created service account", log.Data{"token": account.Token, "id": account.ID, "name": account.Name})
+		fmt.Printf("created service account %s\n", account.Token)
+	case "revoke":
+		if *token == "" {
+			log.Event(nil, "please specify the -token of the service account to revoke")
+			os.Exit(1)
+		}
+
+		if err := services.Revoke(servicesDir, *token); err != nil {
+			errorAndExit(err)
+		}
+		log.Event(nil, "revoked service account", log.Data{"token": *token})
+		fmt.Printf("revoked service account %s\n", *token)
+	}
+
+	listServiceAccounts(servicesDir, *zebDir, args[0] == "list")
+}
+
+// listServiceAccounts warns about orphaned and duplicate accounts, printing every account first if all is set.
+func listServiceAccounts(servicesDir string, zebDir string, all bool) {
+	inUse := ""
+	if zebDir != "" {
+		var err error
+		if inUse, err = scripts.ReadSetting(filepath.Join(zebDir, "run-cms.sh"), cms.ServiceAuthTokenEnv); err != nil {
+			errorAndExit(err)
+		}
+	}
+
+	accounts, warnings, err := services.List(servicesDir, inUse)
+	if err != nil {
+		errorAndExit(err)
+	}
+
+	if all {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TOKEN\tID\tNAME\tCREATED\tIN USE")
+		for _, a := range accounts {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", a.Token, a.ID, a.Name, a.Created, a.Token == inUse)
+		}
+		w.Flush()
+	}
+
+	for _, warning := range warnings {
+		log.Event(nil, "service account warning", log.Data{"kind": warning.Kind, "token": warning.Token, "message": warning.Message})
+		fmt.Printf("warning: %s account %s: %s\n", warning.Kind, warning.Token, warning.Message)
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultID is the id the builder gives the service accounts it generates unless another is set.
+	DefaultID = "Weyland-Yutani Corporation"
+
+	// GeneratedName is the name of the service accounts the builder generates, it is how they are recognised.
+	GeneratedName = "dp-zebedee-utils content builder"
+
+	// TokenSize is the length of generated tokens.
+	TokenSize = 64
+
+	// DateFormat is the format of the creation time of accounts.
+	DateFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// Warning kinds.
+const (
+	Orphaned  = "orphaned"
+	Duplicate = "duplicate"
+)
+
+var (
+	tokenChars = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+	tokenRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// Account is a Zebedee service account. Zebedee authorises a service by the token it sends, which is the name of the
+// account file in the services dir, and only reads the id. Name and Created are for the people managing the accounts.
+type Account struct {
+	Token   string `json:"-"`
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Created string `json:"created,omitempty"`
+}
+
+// IsGenerated returns true if the account was generated by the builder. Accounts are recognised by their name as the
+// id is configurable, accounts generated before they were named have the default id and no name.
+func (a Account) IsGenerated() bool {
+	return a.Name == GeneratedName || (a.Name == "" && a.ID == DefaultID)
+}
+
+// Warning is a problem found with the accounts in a services dir.
+type Warning struct {
+	Kind    string `json:"kind"`
+	Token   string `json:"token"`
+	Message string `json:"message"`
+}
+
+// List returns the accounts in the services dir sorted by token, and warnings about orphaned and duplicate accounts.
+// An account is orphaned if its file can't be read as an account or, when inUse is not empty, it was generated by the
+// builder but is not the account in use. Accounts with the same id are duplicates.
+func List(dir string, inUse string) ([]Account, []Warning, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, fmt.Sprintf("error reading services dir: %s", dir))
+	}
+
+	accounts := make([]Account, 0)
+	warnings := make([]Warning, 0)
+	for _, info := range infos {
+		if info.IsDir() || filepath.Ext(info.Name()) != ".json" {
+			continue
+		}
+
+		token := strings.TrimSuffix(info.Name(), ".json")
+		a, err := read(dir, token)
+		if err != nil {
+			warnings = append(warnings, Warning{Kind: Orphaned, Token: token, Message: err.Error()})
+			continue
+		}
+
+		if inUse != "" && a.IsGenerated() && a.Token != inUse {
+			warnings = append(warnings, Warning{Kind: Orphaned, Token: token, Message: "generated account is not the account in use"})
+		}
+		accounts = append(accounts, *a)
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Token < accounts[j].Token
+	})
+
+	ids := make(map[string][]string)
+	for _, a := range accounts {
+		ids[a.ID] = append(ids[a.ID], a.Token)
+	}
+
+	for _, a := range accounts {
+		if tokens := ids[a.ID]; len(tokens) > 1 {
+			warnings = append(warnings, Warning{
+				Kind:    Duplicate,
+				Token:   a.Token,
+				Message: fmt.Sprintf("%d accounts have the id %q", len(tokens), a.ID),
+			})
+		}
+	}
+	return accounts, warnings, nil
+}
+
+// Generated returns the tokens of the accounts generated by the builder, sorted.
+func Generated(dir string) ([]string, error) {
+	accounts, _, err := List(dir, "")
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]string, 0)
+	for _, a := range accounts {
+		if a.IsGenerated() {
+			tokens = append(tokens, a.Token)
+		}
+	}
+	return tokens, nil
+}
+
+// Create writes the account to the services dir. If the token is empty a random token is generated and if Created is
+// empty it is set to now. Returns the account written, an existing account is never overwritten.
+func Create(dir string, a Account) (*Account, error) {
+	if a.ID == "" {
+		return nil, errors.New("service account id is required")
+	}
+
+	if a.Token == "" {
+		token, err := NewToken(TokenSize)
+		if err != nil {
+			return nil, err
+		}
+		a.Token = token
+	}
+
+	if !tokenRegex.MatchString(a.Token) {
+		return nil, errors.New(fmt.Sprintf("invalid service account token %q only letters, digits, - and _ are allowed", a.Token))
+	}
+
+	if a.Created == "" {
+		a.Created = time.Now().UTC().Format(DateFormat)
+	}
+
+	b, err := json.Marshal(a)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling service account JSON")
+	}
+
+	filename := filepath.Join(dir, a.Token+".json")
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, errors.New(fmt.Sprintf("a service account already exists with the token: %s", a.Token))
+		}
+		return nil, errors.Wrap(err, "error writing service account JSON to file")
+	}
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		return nil, errors.Wrap(err, "error writing service account JSON to file")
+	}
+	return &a, nil
+}
+
+// Revoke removes the account with the token from the services dir.
+func Revoke(dir string, token string) error {
+	if !tokenRegex.MatchString(token) {
+		return errors.New(fmt.Sprintf("invalid service account token %q", token))
+	}
+
+	if err := os.Remove(filepath.Join(dir, token+".json")); err != nil {
+		if os.IsNotExist(err) {
+			return errors.New(fmt.Sprintf("no service account with the token: %s", token))
+		}
+		return errors.Wrap(err, fmt.Sprintf("error removing service account: %s", token))
+	}
+	return nil
+}
+
+// NewToken returns a random token of letters and digits from crypto/rand.
+func NewToken(size int) (string, error) {
+	max := big.NewInt(int64(len(tokenChars)))
+	token := make([]rune, size)
+	for i := range token {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.Wrap(err, "error generating random token")
+		}
+		token[i] = tokenChars[n.Int64()]
+	}
+	return string(token), nil
+}
+
+func read(dir string, token string) (*Account, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, token+".json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading service account")
+	}
+
+	var a Account
+	if err := json.Unmarshal(b, &a); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling service account")
+	}
+
+	if a.ID == "" {
+		return nil, errors.New("service account has no id")
+	}
+	a.Token = token
+	return &a, nil
+}
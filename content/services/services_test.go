@@ -0,0 +1,153 @@
+package services
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "services")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestGeneratedByName(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	accounts := []Account{
+		{Token: "generated", ID: DefaultID, Name: GeneratedName},
+		{Token: "custom", ID: "another id", Name: GeneratedName},
+		{Token: "byhand", ID: DefaultID, Name: "dataset api"},
+		{Token: "other", ID: "dataset api"},
+	}
+	for _, a := range accounts {
+		if _, err := Create(dir, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tokens, err := Generated(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"custom", "generated"}
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, tokens)
+	}
+	for i := range expected {
+		if tokens[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, tokens)
+		}
+	}
+}
+
+func TestListWarnings(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	for _, a := range []Account{
+		{Token: "inuse", ID: DefaultID, Name: GeneratedName},
+		{Token: "old", ID: DefaultID, Name: GeneratedName},
+		{Token: "byhand", ID: "dataset api"},
+	} {
+		if _, err := Create(dir, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts, warnings, err := List(dir, "inuse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(accounts) != 3 {
+		t.Errorf("expected 3 accounts, got %+v", accounts)
+	}
+
+	found := make(map[string]string)
+	for _, w := range warnings {
+		found[w.Kind+":"+w.Token] = w.Message
+	}
+
+	for _, key := range []string{"orphaned:broken", "orphaned:old", "duplicate:inuse", "duplicate:old"} {
+		if _, ok := found[key]; !ok {
+			t.Errorf("expected a %s warning, got %+v", key, warnings)
+		}
+	}
+
+	if len(warnings) != 4 {
+		t.Errorf("expected 4 warnings, got %+v", warnings)
+	}
+}
+
+func TestCreateAndRevoke(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	a, err := Create(dir, Account{ID: "dataset api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.Token) != TokenSize || a.Created == "" {
+		t.Errorf("expected a generated token and creation time, got %+v", a)
+	}
+
+	if _, err := Create(dir, Account{Token: a.Token, ID: "other"}); err == nil {
+		t.Error("expected an existing account not to be overwritten")
+	}
+
+	if _, err := Create(dir, Account{Token: "../escape", ID: "other"}); err == nil {
+		t.Error("expected an invalid token to be rejected")
+	}
+
+	if err := Revoke(dir, a.Token); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Revoke(dir, a.Token); err == nil {
+		t.Error("expected revoking a missing account to fail")
+	}
+}
+
+func TestGeneratedBaselineAccount(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// the format of the accounts written before generated accounts were named.
+	if err := ioutil.WriteFile(filepath.Join(dir, "baseline.json"), []byte(`{"id":"Weyland-Yutani Corporation"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Create(dir, Account{Token: "inuse", ID: "builder", Name: GeneratedName}); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := Generated(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tokens) != 2 || tokens[0] != "baseline" || tokens[1] != "inuse" {
+		t.Errorf("expected the baseline account to be generated, got %v", tokens)
+	}
+
+	_, warnings, err := List(dir, "inuse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) != 1 || warnings[0].Kind != Orphaned || warnings[0].Token != "baseline" {
+		t.Errorf("expected the baseline account to be orphaned, got %+v", warnings)
+	}
+}
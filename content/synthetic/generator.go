@@ -0,0 +1,726 @@
+package synthetic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-zebedee-utils/pages"
+	"github.com/pkg/errors"
+)
+
+const (
+	typeHomePage    = "home_page"
+	typeProductPage = "product_page"
+	dateFormat      = "2006-01-02T15:04:05.000Z"
+)
+
+// releaseDate is the release date of the latest edition of everything generated, older editions are released before
+// it. It is fixed so the same seed always generates the same content.
+var releaseDate = time.Date(2020, time.January, 15, 9, 30, 0, 0, time.UTC)
+
+var slugRegex = regexp.MustCompile(`[^a-z0-9]`)
+
+// Config is the shape of the content to generate. Bulletins, Articles, Datasets and Timeseries are the number of
+// each generated for every product page.
+type Config struct {
+	Seed       int64
+	Topics     int
+	Products   int
+	Bulletins  int
+	Articles   int
+	Datasets   int
+	Timeseries int
+	Editions   int
+	Years      int
+}
+
+// DefaultConfig is a small but complete content tree.
+var DefaultConfig = Config{
+	Seed:       1,
+	Topics:     4,
+	Products:   3,
+	Bulletins:  2,
+	Articles:   2,
+	Datasets:   2,
+	Timeseries: 5,
+	Editions:   3,
+	Years:      10,
+}
+
+// Summary is the number of pages generated of each page type and the number of download files written.
+type Summary struct {
+	Pages map[string]int `json:"pages"`
+	Files int            `json:"files"`
+}
+
+type topic struct {
+	uri      string
+	title    string
+	products []*product
+}
+
+type product struct {
+	uri        string
+	title      string
+	slug       string
+	bulletins  []*series
+	articles   []*series
+	datasets   []*dataset
+	timeseries []*timeseries
+}
+
+// series is a bulletin or article series, editions are newest first.
+type series struct {
+	uri      string
+	title    string
+	editions []*edition
+}
+
+type edition struct {
+	uri   string
+	label string
+	date  time.Time
+}
+
+type dataset struct {
+	uri        string
+	title      string
+	id         string
+	timeseries []*timeseries
+}
+
+type timeseries struct {
+	uri     string
+	title   string
+	cdid    string
+	unit    string
+	dataset *dataset
+}
+
+type productPage struct {
+	pages.Base
+	Items            []pages.Link `json:"items"`
+	Datasets         []pages.Link `json:"datasets"`
+	StatsBulletins   []pages.Link `json:"statsBulletins"`
+	RelatedArticles  []pages.Link `json:"relatedArticles"`
+	HighlightedLinks []pages.Link `json:"highlightedLinks"`
+}
+
+type homePage struct {
+	pages.Base
+	Sections []homeSection `json:"sections"`
+}
+
+type homeSection struct {
+	Theme pages.Link `json:"theme"`
+}
+
+type generator struct {
+	cfg     Config
+	dir     string
+	rnd     *rand.Rand
+	used    map[string]bool
+	topics  []*topic
+	summary *Summary
+}
+
+// Generate writes a taxonomy of cfg.Topics topics to the master dir dir. Each topic has cfg.Products product pages,
+// each with its own bulletins, articles, dataset landing pages and timeseries. Pages link to other pages in the same
+// product and across the whole tree, every link is to a page that is generated. The same config always generates the
+// same content.
+func Generate(dir string, cfg Config) (*Summary, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		cfg:     cfg,
+		dir:     dir,
+		rnd:     rand.New(rand.NewSource(cfg.Seed)),
+		used:    make(map[string]bool),
+		summary: &Summary{Pages: make(map[string]int)},
+	}
+
+	// plan every page before writing any so pages can link to any other page in the tree.
+	g.plan()
+	if err := g.write(); err != nil {
+		return nil, err
+	}
+	return g.summary, nil
+}
+
+func (cfg Config) validate() error {
+	if cfg.Bulletins < 0 || cfg.Articles < 0 || cfg.Datasets < 0 || cfg.Timeseries < 0 {
+		return errors.New("synthetic content bulletins, articles, datasets and timeseries must not be negative")
+	}
+
+	if cfg.Topics < 1 || cfg.Products < 1 || cfg.Editions < 1 || cfg.Years < 1 {
+		return errors.New("synthetic content topics, products, editions and years must be at least 1")
+	}
+	return nil
+}
+
+func (g *generator) plan() {
+	productOrder := g.pick(len(productNames), g.cfg.Topics*g.cfg.Products)
+
+	for i := 0; i < g.cfg.Topics; i++ {
+		t := &topic{title: name(topicNames, i)}
+		t.uri = g.uri("/", t.title)
+		g.topics = append(g.topics, t)
+
+		for j := 0; j < g.cfg.Products; j++ {
+			p := &product{title: name(productNames, productOrder[i*g.cfg.Products+j])}
+			p.uri = g.uri(t.uri, p.title)
+			p.slug = path.Base(p.uri)
+			t.products = append(t.products, p)
+
+			p.bulletins = g.planSeries(p, "bulletins", bulletinNames, g.cfg.Bulletins)
+			p.articles = g.planSeries(p, "articles", articleNames, g.cfg.Articles)
+			g.planDatasets(p)
+			g.planTimeseries(p)
+		}
+	}
+}
+
+func (g *generator) planSeries(p *product, dir string, names []string, n int) []*series {
+	all := make([]*series, 0, n)
+	for _, i := range g.pick(len(names), n) {
+		s := &series{title: p.title + ": " + name(names, i)}
+		s.uri = g.uri(path.Join(p.uri, dir), s.title)
+
+		for e := 0; e < g.cfg.Editions; e++ {
+			date := releaseDate.AddDate(0, -e, -g.rnd.Intn(10))
+			label := date.Format("January 2006")
+			s.editions = append(s.editions, &edition{
+				uri:   g.uri(s.uri, label),
+				label: label,
+				date:  date,
+			})
+		}
+		all = append(all, s)
+	}
+	return all
+}
+
+func (g *generator) planDatasets(p *product) {
+	for _, i := range g.pick(len(datasetNames), g.cfg.Datasets) {
+		d := &dataset{title: p.title + " " + name(datasetNames, i)}
+		d.uri = g.uri(path.Join(p.uri, "datasets"), d.title)
+		d.id = g.id(strings.ToUpper(p.slug[:1]), 3)
+		p.datasets = append(p.datasets, d)
+	}
+}
+
+func (g *generator) planTimeseries(p *product) {
+	for i := 0; i < g.cfg.Timeseries; i++ {
+		ts := &timeseries{
+			title: p.title + ": " + name(measureNames, i),
+			cdid:  g.id("", 4),
+			unit:  units[g.rnd.Intn(len(units))],
+		}
+		ts.uri = g.uri(path.Join(p.uri, "timeseries"), ts.cdid)
+
+		if len(p.datasets) > 0 {
+			ts.dataset = p.datasets[i%len(p.datasets)]
+			ts.dataset.timeseries = append(ts.dataset.timeseries, ts)
+		}
+		p.timeseries = append(p.timeseries, ts)
+	}
+}
+
+func (g *generator) write() error {
+	home := &homePage{
+		Base:     g.base(typeHomePage, "/", "Home", releaseDate),
+		Sections: make([]homeSection, 0),
+	}
+
+	for _, t := range g.topics {
+		home.Sections = append(home.Sections, homeSection{Theme: pages.Link{Title: t.title, URI: t.uri}})
+		if err := g.writeTopic(t); err != nil {
+			return err
+		}
+	}
+	return g.writePage(home.URI, typeHomePage, home)
+}
+
+func (g *generator) writeTopic(t *topic) error {
+	page := &pages.TaxonomyLandingPage{
+		Base:             g.base(pages.TypeTaxonomyLandingPage, t.uri, t.title, releaseDate),
+		Sections:         make([]pages.Link, 0),
+		HighlightedLinks: make([]pages.Link, 0),
+	}
+
+	bulletins := make([]pages.Link, 0)
+	for _, p := range t.products {
+		page.Sections = append(page.Sections, pages.Link{Title: p.title, URI: p.uri})
+		bulletins = append(bulletins, latestLinks(p.bulletins)...)
+
+		if err := g.writeProduct(p); err != nil {
+			return err
+		}
+	}
+	page.HighlightedLinks = g.sample(bulletins, 3)
+
+	return g.writePage(page.URI, page.Type, page)
+}
+
+func (g *generator) writeProduct(p *product) error {
+	page := &productPage{
+		Base:             g.base(typeProductPage, p.uri, p.title, releaseDate),
+		Items:            timeseriesLinks(p.timeseries),
+		Datasets:         datasetLinks(p.datasets),
+		StatsBulletins:   latestLinks(p.bulletins),
+		RelatedArticles:  latestLinks(p.articles),
+		HighlightedLinks: make([]pages.Link, 0),
+	}
+	page.HighlightedLinks = g.sample(page.StatsBulletins, 1)
+
+	for _, s := range p.bulletins {
+		for i, e := range s.editions {
+			if err := g.writeBulletin(p, s, i, e); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, s := range p.articles {
+		for i, e := range s.editions {
+			if err := g.writeArticle(p, s, i, e); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, d := range p.datasets {
+		if err := g.writeDataset(p, d); err != nil {
+			return err
+		}
+	}
+
+	for _, ts := range p.timeseries {
+		if err := g.writeTimeseries(p, ts); err != nil {
+			return err
+		}
+	}
+	return g.writePage(page.URI, page.Type, page)
+}
+
+func (g *generator) writeBulletin(p *product, s *series, i int, e *edition) error {
+	page := &pages.Bulletin{
+		Base:            g.base(pages.TypeBulletin, e.uri, s.title, e.date),
+		Content:         g.content(p, s, e),
+		RelatedArticles: g.sample(latestLinks(p.articles), 2),
+	}
+	page.Description.Edition = e.label
+	page.Description.LatestRelease = i == 0
+	page.Description.NationalStatistic = true
+	page.Description.NextRelease = e.date.AddDate(0, 1, 0).Format("2 January 2006")
+	page.Description.Contact = contact(p)
+
+	return g.writePage(page.URI, page.Type, page)
+}
+
+func (g *generator) writeArticle(p *product, s *series, i int, e *edition) error {
+	page := &pages.Article{
+		Base:    g.base(pages.TypeArticle, e.uri, s.title, e.date),
+		Content: g.content(p, s, e),
+	}
+	page.Description.Edition = e.label
+	page.Description.LatestRelease = i == 0
+	page.Description.Contact = contact(p)
+
+	return g.writePage(page.URI, page.Type, page)
+}
+
+// content returns the sections and links of a bulletin or article edition.
+func (g *generator) content(p *product, s *series, e *edition) pages.Content {
+	c := pages.Content{
+		Sections:                  make([]pages.MarkdownSection, 0),
+		Accordion:                 make([]pages.MarkdownSection, 0),
+		RelatedBulletins:          g.sample(g.otherBulletins(s), 2),
+		RelatedData:               append(datasetLinks(p.datasets), g.sample(timeseriesLinks(p.timeseries), 2)...),
+		RelatedMethodology:        make([]pages.Link, 0),
+		RelatedMethodologyArticle: make([]pages.Link, 0),
+		Links:                     make([]pages.Link, 0),
+		Charts:                    make([]pages.Figure, 0),
+		Tables:                    make([]pages.Figure, 0),
+		Images:                    make([]pages.Figure, 0),
+		Equations:                 make([]pages.Figure, 0),
+		Alerts:                    make([]pages.Alert, 0),
+		Versions:                  make([]pages.Version, 0),
+	}
+
+	for i, section := range g.pick(len(sectionNames), 3+g.rnd.Intn(3)) {
+		c.Sections = append(c.Sections, pages.MarkdownSection{
+			Title:    fmt.Sprintf("%d. %s", i+1, sectionNames[section]),
+			Markdown: g.paragraphs(2 + g.rnd.Intn(3)),
+		})
+	}
+
+	// link to the previous edition of the series as the real content does.
+	for i, prev := range s.editions {
+		if prev == e && i+1 < len(s.editions) {
+			c.Links = append(c.Links, pages.Link{Title: s.title + ": " + s.editions[i+1].label, URI: s.editions[i+1].uri})
+		}
+	}
+	return c
+}
+
+func (g *generator) writeDataset(p *product, d *dataset) error {
+	landing := &pages.DatasetLandingPage{
+		Base:                      g.base(pages.TypeDatasetLandingPage, d.uri, d.title, releaseDate),
+		Section:                   &pages.MarkdownSection{Markdown: g.paragraphs(1)},
+		Notes:                     &pages.MarkdownSection{Markdown: g.paragraphs(1)},
+		Datasets:                  make([]pages.Link, 0),
+		Links:                     make([]pages.Link, 0),
+		RelatedDatasets:           g.sample(g.otherDatasets(d), 2),
+		RelatedDocuments:          latestLinks(p.bulletins),
+		RelatedMethodology:        make([]pages.Link, 0),
+		RelatedMethodologyArticle: make([]pages.Link, 0),
+		Alerts:                    make([]pages.Alert, 0),
+		Timeseries:                len(d.timeseries) > 0,
+	}
+	landing.Description.DatasetID = d.id
+	landing.Description.NationalStatistic = true
+	landing.Description.NextRelease = releaseDate.AddDate(0, 1, 0).Format("2 January 2006")
+	landing.Description.Contact = contact(p)
+
+	editionURI := path.Join(d.uri, "current")
+	landing.Datasets = append(landing.Datasets, pages.Link{Title: "Current", URI: editionURI})
+
+	filename := strings.ToLower(d.id) + ".csv"
+	edition := &pages.Dataset{
+		Base:               g.base(pages.TypeDataset, editionURI, d.title, releaseDate),
+		Downloads:          []pages.Download{{Title: d.title, File: filename}},
+		SupplementaryFiles: make([]pages.Download, 0),
+		Versions:           make([]pages.Version, 0),
+	}
+	edition.Description.Edition = "Current"
+	edition.Description.DatasetID = d.id
+	edition.Description.Contact = contact(p)
+
+	if err := g.writeFile(path.Join(editionURI, filename), g.csv(d)); err != nil {
+		return err
+	}
+
+	if err := g.writePage(edition.URI, edition.Type, edition); err != nil {
+		return err
+	}
+	return g.writePage(landing.URI, landing.Type, landing)
+}
+
+func (g *generator) writeTimeseries(p *product, ts *timeseries) error {
+	page := &pages.Timeseries{
+		Base:             g.base(pages.TypeTimeseries, ts.uri, ts.title, releaseDate),
+		Section:          &pages.MarkdownSection{Markdown: g.sentence()},
+		SourceDatasets:   make([]pages.Link, 0),
+		RelatedDatasets:  g.sample(datasetLinks(p.datasets), 1),
+		RelatedDocuments: g.sample(latestLinks(p.bulletins), 2),
+		RelatedData:      g.sample(g.otherTimeseries(p, ts), 2),
+		Notes:            make([]string, 0),
+		Alerts:           make([]pages.Alert, 0),
+		Versions:         make([]pages.Version, 0),
+	}
+	page.Description.CDID = ts.cdid
+	page.Description.Unit = ts.unit
+	page.Description.Contact = contact(p)
+
+	sourceDataset := ""
+	if ts.dataset != nil {
+		sourceDataset = ts.dataset.id
+		page.Description.DatasetID = ts.dataset.id
+		page.SourceDatasets = append(page.SourceDatasets, pages.Link{Title: ts.dataset.title, URI: ts.dataset.uri})
+	}
+	page.Years, page.Quarters, page.Months = g.values(ts, sourceDataset)
+
+	return g.writePage(page.URI, page.Type, page)
+}
+
+// values returns the yearly, quarterly and monthly data points of a timeseries. The months are a random walk, the
+// quarters and years are their averages.
+func (g *generator) values(ts *timeseries, sourceDataset string) ([]pages.TimeseriesValue, []pages.TimeseriesValue, []pages.TimeseriesValue) {
+	years := make([]pages.TimeseriesValue, 0, g.cfg.Years)
+	quarters := make([]pages.TimeseriesValue, 0, g.cfg.Years*4)
+	months := make([]pages.TimeseriesValue, 0, g.cfg.Years*12)
+	updated := releaseDate.Format(dateFormat)
+
+	v := 50 + g.rnd.Float64()*100
+	for y := releaseDate.Year() - g.cfg.Years; y < releaseDate.Year(); y++ {
+		year := strconv.Itoa(y)
+		yearTotal, quarterTotal := 0.0, 0.0
+
+		for m := 1; m <= 12; m++ {
+			v *= 1 + g.rnd.NormFloat64()*0.01
+			yearTotal += v
+			quarterTotal += v
+
+			month := time.Month(m).String()
+			months = append(months, pages.TimeseriesValue{
+				Date:          year + " " + strings.ToUpper(month[:3]),
+				Value:         strconv.FormatFloat(v, 'f', 1, 64),
+				Year:          year,
+				Month:         month,
+				SourceDataset: sourceDataset,
+				UpdateDate:    updated,
+			})
+
+			if m%3 == 0 {
+				quarter := fmt.Sprintf("Q%d", m/3)
+				quarters = append(quarters, pages.TimeseriesValue{
+					Date:          year + " " + quarter,
+					Value:         strconv.FormatFloat(quarterTotal/3, 'f', 1, 64),
+					Year:          year,
+					Quarter:       quarter,
+					SourceDataset: sourceDataset,
+					UpdateDate:    updated,
+				})
+				quarterTotal = 0
+			}
+		}
+
+		years = append(years, pages.TimeseriesValue{
+			Date:          year,
+			Value:         strconv.FormatFloat(yearTotal/12, 'f', 1, 64),
+			Year:          year,
+			SourceDataset: sourceDataset,
+			UpdateDate:    updated,
+		})
+	}
+	return years, quarters, months
+}
+
+// csv returns the download file of a dataset, the yearly values of each of its timeseries.
+func (g *generator) csv(d *dataset) []byte {
+	var b strings.Builder
+	b.WriteString("Title")
+	for _, ts := range d.timeseries {
+		b.WriteString(",\"" + ts.title + "\"")
+	}
+	b.WriteString("\nCDID")
+	for _, ts := range d.timeseries {
+		b.WriteString("," + ts.cdid)
+	}
+	b.WriteString("\n")
+
+	for y := releaseDate.Year() - g.cfg.Years; y < releaseDate.Year(); y++ {
+		b.WriteString(strconv.Itoa(y))
+		for range d.timeseries {
+			b.WriteString("," + strconv.FormatFloat(50+g.rnd.Float64()*100, 'f', 1, 64))
+		}
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+func (g *generator) base(pageType string, uri string, title string, date time.Time) pages.Base {
+	return pages.Base{
+		Type: pageType,
+		URI:  uri,
+		Description: &pages.Description{
+			Title:           title,
+			Summary:         g.sentence(),
+			Keywords:        g.keywords(),
+			MetaDescription: g.sentence(),
+			ReleaseDate:     date.Format(dateFormat),
+		},
+	}
+}
+
+func (g *generator) writePage(uri string, pageType string, page interface{}) error {
+	b, err := json.MarshalIndent(page, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error marshalling synthetic page: %s", uri))
+	}
+
+	if err := g.writeFile(path.Join(uri, "data.json"), b); err != nil {
+		return err
+	}
+	g.summary.Pages[pageType]++
+	return nil
+}
+
+func (g *generator) writeFile(uri string, b []byte) error {
+	filename := filepath.Join(g.dir, filepath.FromSlash(uri))
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error creating dir for synthetic content: %s", uri))
+	}
+
+	if err := ioutil.WriteFile(filename, b, 0644); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error writing synthetic content: %s", uri))
+	}
+
+	if path.Base(uri) != "data.json" {
+		g.summary.Files++
+	}
+	return nil
+}
+
+// uri returns a uri under parent for title that has not already been used.
+func (g *generator) uri(parent string, title string) string {
+	slug := slugRegex.ReplaceAllString(strings.ToLower(title), "")
+	uri := path.Join(parent, slug)
+	for i := 2; g.used[uri]; i++ {
+		uri = path.Join(parent, slug+strconv.Itoa(i))
+	}
+	g.used[uri] = true
+	return uri
+}
+
+// id returns an unused upper case id of prefix followed by random letters and digits up to size.
+func (g *generator) id(prefix string, size int) string {
+	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	for {
+		id := prefix
+		for len(id) < size {
+			id += string(chars[g.rnd.Intn(len(chars))])
+		}
+
+		if !g.used["id:"+id] {
+			g.used["id:"+id] = true
+			return id
+		}
+	}
+}
+
+// pick returns n random indexes of a list of size names. Every name is used before any is repeated, repeats are
+// returned as indexes past the end of the list which name numbers.
+func (g *generator) pick(size int, n int) []int {
+	picked := make([]int, 0, n)
+	for len(picked) < n {
+		for _, i := range g.rnd.Perm(size) {
+			if len(picked) == n {
+				break
+			}
+			picked = append(picked, i+len(picked)/size*size)
+		}
+	}
+	return picked
+}
+
+// sample returns up to n links chosen at random.
+func (g *generator) sample(links []pages.Link, n int) []pages.Link {
+	sampled := make([]pages.Link, 0, n)
+	for _, i := range g.rnd.Perm(len(links)) {
+		if len(sampled) == n {
+			break
+		}
+		sampled = append(sampled, links[i])
+	}
+	return sampled
+}
+
+func (g *generator) otherBulletins(s *series) []pages.Link {
+	links := make([]pages.Link, 0)
+	for _, t := range g.topics {
+		for _, p := range t.products {
+			for _, b := range p.bulletins {
+				if b != s {
+					links = append(links, pages.Link{Title: b.title, URI: b.editions[0].uri})
+				}
+			}
+		}
+	}
+	return links
+}
+
+func (g *generator) otherDatasets(d *dataset) []pages.Link {
+	links := make([]pages.Link, 0)
+	for _, t := range g.topics {
+		for _, p := range t.products {
+			for _, other := range p.datasets {
+				if other != d {
+					links = append(links, pages.Link{Title: other.title, URI: other.uri})
+				}
+			}
+		}
+	}
+	return links
+}
+
+func (g *generator) otherTimeseries(p *product, ts *timeseries) []pages.Link {
+	links := make([]pages.Link, 0)
+	for _, other := range p.timeseries {
+		if other != ts {
+			links = append(links, pages.Link{Title: other.title, URI: other.uri})
+		}
+	}
+	return links
+}
+
+func (g *generator) keywords() []string {
+	keywords := make([]string, 0, 3)
+	for _, i := range g.pick(len(fillerWords), 3) {
+		keywords = append(keywords, fillerWords[i])
+	}
+	return keywords
+}
+
+func (g *generator) sentence() string {
+	words := make([]string, 8+g.rnd.Intn(12))
+	for i := range words {
+		words[i] = fillerWords[g.rnd.Intn(len(fillerWords))]
+	}
+	s := strings.Join(words, " ")
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+func (g *generator) paragraphs(n int) string {
+	paragraphs := make([]string, n)
+	for i := range paragraphs {
+		sentences := make([]string, 2+g.rnd.Intn(4))
+		for j := range sentences {
+			sentences[j] = g.sentence()
+		}
+		paragraphs[i] = strings.Join(sentences, " ")
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+func latestLinks(all []*series) []pages.Link {
+	links := make([]pages.Link, 0, len(all))
+	for _, s := range all {
+		links = append(links, pages.Link{Title: s.title, URI: s.editions[0].uri})
+	}
+	return links
+}
+
+func datasetLinks(datasets []*dataset) []pages.Link {
+	links := make([]pages.Link, 0, len(datasets))
+	for _, d := range datasets {
+		links = append(links, pages.Link{Title: d.title, URI: d.uri})
+	}
+	return links
+}
+
+func timeseriesLinks(all []*timeseries) []pages.Link {
+	links := make([]pages.Link, 0, len(all))
+	for _, ts := range all {
+		links = append(links, pages.Link{Title: ts.title, URI: ts.uri})
+	}
+	return links
+}
+
+func contact(p *product) *pages.Contact {
+	return &pages.Contact{
+		Name:      p.title + " team",
+		Email:     p.slug + "@ons.gov.uk",
+		Telephone: "+44 (0)1633 456789",
+	}
+}
+
+// name returns names[i], numbering the names once i runs past the end of the list e.g. Economy 2.
+func name(names []string, i int) string {
+	if i < len(names) {
+		return names[i]
+	}
+	return fmt.Sprintf("%s %d", names[i%len(names)], i/len(names)+1)
+}
@@ -0,0 +1,147 @@
+package synthetic
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+)
+
+// generate generates the content of cfg into a new temp dir and returns the dir and the content of every file by uri.
+func generate(t *testing.T, cfg Config) (string, *Summary, map[string][]byte) {
+	dir, err := ioutil.TempDir("", "synthetic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := Generate(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string][]byte)
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[path.Join("/", filepath.ToSlash(rel))] = b
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, summary, files
+}
+
+func TestGenerateSameSeed(t *testing.T) {
+	dir1, summary1, files1 := generate(t, DefaultConfig)
+	defer os.RemoveAll(dir1)
+
+	dir2, summary2, files2 := generate(t, DefaultConfig)
+	defer os.RemoveAll(dir2)
+
+	if !reflect.DeepEqual(summary1, summary2) {
+		t.Errorf("expected the same summary, got %+v and %+v", summary1, summary2)
+	}
+
+	if len(files1) != len(files2) {
+		t.Fatalf("expected the same files, got %d and %d", len(files1), len(files2))
+	}
+
+	for uri, b := range files1 {
+		if other, ok := files2[uri]; !ok || string(other) != string(b) {
+			t.Errorf("expected %s to be generated the same by the same seed", uri)
+		}
+	}
+
+	cfg := DefaultConfig
+	cfg.Seed++
+	dir3, _, files3 := generate(t, cfg)
+	defer os.RemoveAll(dir3)
+
+	if reflect.DeepEqual(files1, files3) {
+		t.Error("expected a different seed to generate different content")
+	}
+}
+
+func TestGenerateLinksResolve(t *testing.T) {
+	dir, summary, files := generate(t, DefaultConfig)
+	defer os.RemoveAll(dir)
+
+	pageCount, linkCount := 0, 0
+	for uri, b := range files {
+		if path.Base(uri) != "data.json" {
+			continue
+		}
+		pageCount++
+
+		links, err := collections.ExtractLinks(b)
+		if err != nil {
+			t.Fatalf("%s: %v", uri, err)
+		}
+
+		for _, l := range links {
+			if strings.HasPrefix(l, "mailto:") {
+				continue
+			}
+			linkCount++
+
+			if _, ok := files[path.Join(l, "data.json")]; ok {
+				continue
+			}
+
+			if _, ok := files[l]; !ok {
+				t.Errorf("%s: link to %s does not resolve to a generated page or file", uri, l)
+			}
+		}
+	}
+
+	total := 0
+	for _, n := range summary.Pages {
+		total += n
+	}
+
+	if total != pageCount || summary.Files != len(files)-pageCount {
+		t.Errorf("expected the summary to count the %d pages and %d files, got %+v", pageCount, len(files)-pageCount, summary)
+	}
+
+	if linkCount <= pageCount {
+		t.Errorf("expected pages to link to each other, got %d links in %d pages", linkCount, pageCount)
+	}
+}
+
+func TestGenerateInvalidConfig(t *testing.T) {
+	for _, change := range []func(cfg *Config){
+		func(cfg *Config) { cfg.Topics = 0 },
+		func(cfg *Config) { cfg.Editions = 0 },
+		func(cfg *Config) { cfg.Timeseries = -1 },
+	} {
+		cfg := DefaultConfig
+		change(&cfg)
+
+		dir, err := ioutil.TempDir("", "synthetic")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Generate(dir, cfg); err == nil {
+			t.Errorf("expected %+v to be invalid", cfg)
+		}
+		os.RemoveAll(dir)
+	}
+}
@@ -0,0 +1,100 @@
+package synthetic
+
+// The words content is generated from. Names are used in order, with a number appended once they run out, so small
+// trees have realistic names.
+var (
+	topicNames = []string{
+		"Economy",
+		"Employment and labour market",
+		"People, population and community",
+		"Business, industry and trade",
+		"Health and social care",
+		"Housing",
+		"Environment",
+		"Travel and tourism",
+	}
+
+	productNames = []string{
+		"Gross domestic product",
+		"Inflation and price indices",
+		"Earnings and working hours",
+		"Productivity",
+		"Government finance",
+		"Investment",
+		"Regional accounts",
+		"Migration",
+		"Births",
+		"Deaths",
+		"Well-being",
+		"Retail industry",
+		"Construction industry",
+		"Energy use",
+		"Household income",
+		"Labour costs",
+		"International trade",
+		"Population estimates",
+		"Crime and justice",
+		"Overseas travel",
+	}
+
+	bulletinNames = []string{
+		"first estimate",
+		"monthly estimate",
+		"quarterly national accounts",
+		"annual review",
+		"regional estimates",
+		"summary",
+		"final estimate",
+		"provisional estimates",
+	}
+
+	articleNames = []string{
+		"an overview",
+		"what is driving the change",
+		"international comparisons",
+		"a historical perspective",
+		"impact of methodology changes",
+		"analysis by region",
+		"trends over the last decade",
+		"measuring the effects",
+	}
+
+	datasetNames = []string{
+		"time series",
+		"detailed tables",
+		"reference tables",
+		"regional tables",
+		"supplementary tables",
+		"historical series",
+	}
+
+	measureNames = []string{
+		"seasonally adjusted",
+		"not seasonally adjusted",
+		"index",
+		"level",
+		"year on year growth",
+		"quarter on quarter growth",
+		"per head",
+		"annual rate",
+	}
+
+	units = []string{"%", "Index", "£ million", "Thousands", "Rate"}
+
+	sectionNames = []string{
+		"Main points",
+		"Things you need to know about this release",
+		"Latest figures",
+		"Changes over time",
+		"Regional breakdown",
+		"Quality and methodology",
+		"Related links",
+	}
+
+	fillerWords = []string{
+		"the", "estimate", "quarter", "growth", "increased", "fell", "compared", "with", "previous", "year", "output",
+		"services", "production", "revised", "data", "households", "businesses", "region", "latest", "figures", "show",
+		"rate", "level", "period", "annual", "monthly", "change", "largest", "contribution", "sector", "measure",
+		"population", "survey", "release", "published", "since", "remained", "broadly", "flat", "strong", "weak",
+	}
+)
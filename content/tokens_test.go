@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-zebedee-utils/content/cms"
+	"github.com/ONSdigital/dp-zebedee-utils/content/services"
+)
+
+const testRunScript = "export SERVICE_AUTH_TOKEN=\"old\"\nexport DATASET_API_AUTH_TOKEN=\"dataset\"\n"
+
+// testRotationRoot creates a zebedee root with a generated service account called old and a zebedee project dir with
+// a run-cms.sh using it.
+func testRotationRoot(t *testing.T) (string, string, string) {
+	dir, err := ioutil.TempDir("", "content")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := filepath.Join(dir, "root")
+	servicesDir := filepath.Join(root, cms.Zebedee, cms.Services)
+	if err := os.MkdirAll(servicesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := services.Create(servicesDir, services.Account{Token: "old", ID: services.DefaultID, Name: services.GeneratedName}); err != nil {
+		t.Fatal(err)
+	}
+
+	zebDir := filepath.Join(dir, "zebedee")
+	if err := os.MkdirAll(zebDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(zebDir, "run-cms.sh"), []byte(testRunScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir, root, zebDir
+}
+
+func TestRotateTokensWrongZebDir(t *testing.T) {
+	dir, root, zebDir := testRotationRoot(t)
+	defer os.RemoveAll(dir)
+
+	if _, _, err := rotateTokens(root, filepath.Join(dir, "wrong"), "", services.DefaultID); err == nil {
+		t.Fatal("expected rotating with a zebedee dir without a run-cms.sh to fail")
+	}
+
+	tokens, err := services.Generated(filepath.Join(root, cms.Zebedee, cms.Services))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tokens) != 1 || tokens[0] != "old" {
+		t.Errorf("expected the service accounts to be unchanged, got %v", tokens)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(zebDir, "run-cms.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != testRunScript {
+		t.Errorf("expected run-cms.sh to be unchanged, got %s", b)
+	}
+}
+
+func TestRotateTokens(t *testing.T) {
+	dir, root, zebDir := testRotationRoot(t)
+	defer os.RemoveAll(dir)
+
+	rotation, updated, err := rotateTokens(root, zebDir, "", services.DefaultID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rotation.RemovedServiceAccounts) != 1 || rotation.RemovedServiceAccounts[0] != "old" {
+		t.Errorf("expected the old service account to be removed, got %v", rotation.RemovedServiceAccounts)
+	}
+
+	if len(updated) != 1 {
+		t.Errorf("expected run-cms.sh to be updated, got %v", updated)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(zebDir, "run-cms.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), "SERVICE_AUTH_TOKEN=\""+rotation.ServiceAccount+"\"") {
+		t.Errorf("expected run-cms.sh to use the new service account, got %s", b)
+	}
+}
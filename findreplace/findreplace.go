@@ -0,0 +1,448 @@
+package findreplace
+
+import (
+	"encoding/json"
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/dp-zebedee-utils/linkindex"
+	"github.com/ONSdigital/log.go/log"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Policies for pages that are blocked by being in another collection.
+const (
+	// BlockedSkip leaves blocked pages unchanged.
+	BlockedSkip = "skip"
+
+	// BlockedDefer leaves blocked pages unchanged and adds them to a deferred list that can be replayed once the
+	// blocking collection has published.
+	BlockedDefer = "defer"
+
+	// BlockedOverflow changes the blocking collection's copy of the page and adds it to an overflow collection for
+	// that blocking collection, to be published after it.
+	BlockedOverflow = "overflow"
+)
+
+// Config is the configuration of a find and replace run.
+type Config struct {
+	MasterDir      string
+	CollectionsDir string
+	Target         *collections.Collection
+	Rules          []*Rule
+	Blocked        string
+}
+
+// RuleReport is the outcome of a single rule. Outstanding is the number of files found that were not fixed because
+// they are in another collection.
+type RuleReport struct {
+	Rule         string        `json:"rule"`
+	Total        int           `json:"total_found"`
+	Replacements int           `json:"replacements"`
+	Fixed        int           `json:"fixes_applied"`
+	Blocked      []BlockedFile `json:"blocked_by_collection"`
+	Outstanding  int           `json:"outstanding"`
+}
+
+// BlockedFile is a file the rule matched that is in another collection and what was done with it.
+type BlockedFile struct {
+	URI        string `json:"uri"`
+	Collection string `json:"collection"`
+	Action     string `json:"action"`
+	Overflow   string `json:"overflow_collection,omitempty"`
+}
+
+// Deferred is a page that was not changed because it was in another collection.
+type Deferred struct {
+	URI                string   `json:"uri"`
+	BlockingCollection string   `json:"blocking_collection"`
+	Rules              []string `json:"rules"`
+}
+
+// DeferredList is the pages deferred by a run, saved so they can be replayed.
+type DeferredList struct {
+	CreatedAt  time.Time  `json:"created_at"`
+	Collection string     `json:"collection"`
+	Pages      []Deferred `json:"pages"`
+}
+
+// Tracker is the per rule report of a run, in the order of the rules, along with the pages deferred.
+type Tracker struct {
+	Reports  []*RuleReport `json:"rules"`
+	Deferred []Deferred    `json:"deferred"`
+	byRule   map[string]*RuleReport
+}
+
+type runner struct {
+	Config
+	cols      *collections.Collections
+	overflows map[string]bool
+	tracker   *Tracker
+}
+
+// OverflowName returns the name of the overflow collection of the target collection for pages blocked by the
+// blocking collection.
+func OverflowName(target string, blocking string) string {
+	return target + "-" + blocking
+}
+
+// Run applies the rules to every file in master, in order, adding each changed file to the target collection. If the
+// target collection already has a copy of a file the rules are applied to that copy rather than master. Files in
+// another collection are handled according to the blocked policy.
+func Run(cfg Config) (*Tracker, error) {
+	r, err := newRunner(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.Walk(cfg.MasterDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(cfg.MasterDir, p)
+		if err != nil {
+			return err
+		}
+		uri := path.Join("/", filepath.ToSlash(rel))
+
+		applicable := make([]*Rule, 0)
+		for _, rule := range cfg.Rules {
+			if rule.Applies(uri) {
+				applicable = append(applicable, rule)
+			}
+		}
+		return r.process(uri, applicable)
+	})
+	if err != nil {
+		return nil, errs.New("failed to apply find and replace rules", err, log.Data{"master": cfg.MasterDir})
+	}
+	return r.tracker, nil
+}
+
+// RunIndexed applies the rules, in order, to the pages the link index has a link matching a rule's pattern in rather
+// than every file in master. Only the links of .json pages are indexed - URI fields, markdown link targets and email
+// addresses - so a pattern that only appears in plain text is not found, Run should be used for those rules.
+func RunIndexed(cfg Config, idx *linkindex.Index) (*Tracker, error) {
+	if path.Clean(idx.MasterDir) != path.Clean(cfg.MasterDir) {
+		return nil, errs.New("link index was built from a different master dir", nil, log.Data{
+			"index_master": idx.MasterDir,
+			"master":       cfg.MasterDir,
+		})
+	}
+
+	r, err := newRunner(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]map[string]bool)
+	for _, link := range idx.Links() {
+		for _, rule := range cfg.Rules {
+			if !rule.re.MatchString(link) {
+				continue
+			}
+
+			for _, uri := range idx.Referrers(link, false) {
+				if candidates[uri] == nil {
+					candidates[uri] = make(map[string]bool)
+				}
+				candidates[uri][rule.Name] = true
+			}
+		}
+	}
+
+	uris := make([]string, 0, len(candidates))
+	for uri := range candidates {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	log.Event(nil, "link index candidate pages found", log.Data{"pages": len(uris), "indexed": len(idx.Files)})
+	for _, uri := range uris {
+		applicable := make([]*Rule, 0)
+		for _, rule := range cfg.Rules {
+			if candidates[uri][rule.Name] && rule.Applies(uri) {
+				applicable = append(applicable, rule)
+			}
+		}
+
+		if err := r.process(uri, applicable); err != nil {
+			return nil, errs.New("failed to apply find and replace rules", err, log.Data{"uri": uri})
+		}
+	}
+	return r.tracker, nil
+}
+
+// Replay applies the rules named in each deferred page to that page. Pages that are still blocked are handled
+// according to the blocked policy, with the defer policy they are deferred again.
+func Replay(cfg Config, deferred []Deferred) (*Tracker, error) {
+	r, err := newRunner(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make(map[string]*Rule)
+	for _, rule := range cfg.Rules {
+		rules[rule.Name] = rule
+	}
+
+	for _, d := range deferred {
+		if !collections.Exists(path.Join(cfg.MasterDir, d.URI)) {
+			log.Event(nil, "skipping deferred page as it is no longer in master", log.Data{"uri": d.URI})
+			continue
+		}
+
+		applicable := make([]*Rule, 0)
+		for _, name := range d.Rules {
+			rule, ok := rules[name]
+			if !ok {
+				return nil, errs.New("deferred page rule not found in rules file", nil, log.Data{"uri": d.URI, "rule": name})
+			}
+			applicable = append(applicable, rule)
+		}
+
+		if err := r.process(d.URI, applicable); err != nil {
+			return nil, errs.New("failed to replay deferred page", err, log.Data{"uri": d.URI})
+		}
+	}
+	return r.tracker, nil
+}
+
+func newRunner(cfg Config) (*runner, error) {
+	switch cfg.Blocked {
+	case BlockedSkip, BlockedDefer, BlockedOverflow:
+	default:
+		return nil, errs.New("unknown blocked page policy", nil, log.Data{"blocked": cfg.Blocked})
+	}
+
+	cols, err := collections.GetCollections(cfg.CollectionsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &runner{
+		Config:    cfg,
+		cols:      cols,
+		overflows: make(map[string]bool),
+		tracker: &Tracker{
+			Reports:  make([]*RuleReport, 0),
+			Deferred: make([]Deferred, 0),
+			byRule:   make(map[string]*RuleReport),
+		},
+	}
+
+	for _, rule := range cfg.Rules {
+		report := &RuleReport{Rule: rule.Name, Blocked: make([]BlockedFile, 0)}
+		r.tracker.Reports = append(r.tracker.Reports, report)
+		r.tracker.byRule[rule.Name] = report
+	}
+
+	// overflow collections from previous runs do not block the pages they hold.
+	for _, c := range cols.Collections {
+		r.overflows[OverflowName(cfg.Target.Name, c.Name)] = true
+	}
+	return r, nil
+}
+
+// process applies the rules to a single file.
+func (r *runner) process(uri string, rules []*Rule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	blocking := r.blockingCollection(uri)
+
+	// apply the rules to the latest copy of the file that the change would be made to.
+	src := path.Join(r.MasterDir, uri)
+	var overflow *collections.Collection
+	switch {
+	case blocking == nil:
+		if p, ok := r.Target.ContentPath(uri); ok {
+			src = p
+		}
+	case r.Blocked == BlockedOverflow:
+		overflow, _ = r.cols.GetByName(OverflowName(r.Target.Name, blocking.Name))
+		if p, ok := blocking.ContentPath(uri); ok {
+			src = p
+		}
+		if overflow != nil {
+			if p, ok := overflow.ContentPath(uri); ok {
+				src = p
+			}
+		}
+	}
+
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	matched := make([]*RuleReport, 0)
+	for _, rule := range rules {
+		updated, n, err := apply(rule, uri, b)
+		if err != nil {
+			log.Event(nil, "skipping file as rule could not be applied", log.Error(err), log.Data{"rule": rule.Name, "uri": uri})
+			continue
+		}
+
+		if n == 0 {
+			continue
+		}
+
+		report := r.tracker.byRule[rule.Name]
+		report.Total++
+		report.Replacements += n
+		matched = append(matched, report)
+		b = updated
+	}
+
+	if len(matched) == 0 {
+		return nil
+	}
+
+	if blocking == nil {
+		if err := r.Target.AddContent(uri, b); err != nil {
+			return err
+		}
+
+		for _, report := range matched {
+			report.Fixed++
+		}
+		return nil
+	}
+
+	blocked := BlockedFile{URI: uri, Collection: blocking.Name}
+	switch r.Blocked {
+	case BlockedOverflow:
+		if overflow == nil {
+			if overflow, err = r.createOverflow(blocking); err != nil {
+				return err
+			}
+		}
+
+		if err := overflow.AddContent(uri, b); err != nil {
+			return err
+		}
+		blocked.Action = "overflow"
+		blocked.Overflow = overflow.Name
+	case BlockedDefer:
+		d := Deferred{URI: uri, BlockingCollection: blocking.Name, Rules: make([]string, 0)}
+		for _, report := range matched {
+			d.Rules = append(d.Rules, report.Rule)
+		}
+		r.tracker.Deferred = append(r.tracker.Deferred, d)
+		blocked.Action = "deferred"
+	default:
+		blocked.Action = "skipped"
+	}
+
+	for _, report := range matched {
+		report.Blocked = append(report.Blocked, blocked)
+		if blocked.Action == "overflow" {
+			report.Fixed++
+		} else {
+			report.Outstanding++
+		}
+	}
+	return nil
+}
+
+// blockingCollection returns the collection, other than the target and its overflow collections, containing uri. nil
+// if there is none.
+func (r *runner) blockingCollection(uri string) *collections.Collection {
+	for _, c := range r.cols.Collections {
+		if c.Name == r.Target.Name || r.overflows[c.Name] {
+			continue
+		}
+
+		if _, ok := c.ContentPath(uri); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+func (r *runner) createOverflow(blocking *collections.Collection) (*collections.Collection, error) {
+	name := OverflowName(r.Target.Name, blocking.Name)
+	c := collections.New(r.CollectionsDir, name)
+	if err := collections.Save(c); err != nil {
+		return nil, err
+	}
+
+	r.cols.Add(c)
+	r.overflows[name] = true
+	log.Event(nil, "created overflow collection", log.Data{"collection": name, "blocking_collection": blocking.Name})
+	return c, nil
+}
+
+// SaveDeferred writes the deferred pages of a run to filename.
+func SaveDeferred(filename string, list *DeferredList) error {
+	b, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filename, b, 0644); err != nil {
+		return errs.New("failed to write deferred list", err, log.Data{"deferred": filename})
+	}
+	return nil
+}
+
+// LoadDeferred reads a deferred list written by SaveDeferred.
+func LoadDeferred(filename string) (*DeferredList, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errs.New("failed to read deferred list", err, log.Data{"deferred": filename})
+	}
+
+	var list DeferredList
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, errs.New("failed to unmarshal deferred list", err, log.Data{"deferred": filename})
+	}
+	return &list, nil
+}
+
+// apply returns the file with the rule applied and the number of replacements made. The string values of json files
+// are rewritten so the file stays valid json, other files are replaced as text unless the rule has a field scope.
+func apply(r *Rule, uri string, b []byte) ([]byte, int, error) {
+	if path.Ext(uri) != ".json" {
+		if len(r.Fields) > 0 {
+			return b, 0, nil
+		}
+
+		s := string(b)
+		n := len(r.re.FindAllStringIndex(s, -1))
+		if n == 0 {
+			return b, 0, nil
+		}
+		return []byte(r.replace(s)), n, nil
+	}
+
+	n := 0
+	updated, _, err := collections.RewriteStrings(uri, b, func(field string, key string, s string) string {
+		if !r.inScope(field, key) {
+			return s
+		}
+
+		matches := len(r.re.FindAllStringIndex(s, -1))
+		if matches == 0 {
+			return s
+		}
+
+		n += matches
+		return r.replace(s)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return updated, n, nil
+}
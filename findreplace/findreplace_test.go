@@ -0,0 +1,127 @@
+package findreplace
+
+import (
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/linkindex"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func testConfig(t *testing.T, files map[string]string) (Config, func()) {
+	root, err := ioutil.TempDir("", "findreplace")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	masterDir := path.Join(root, "master")
+	for uri, content := range files {
+		if err := collections.WriteContent(path.Join(masterDir, uri), []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collectionsDir := path.Join(root, "collections")
+	if err := os.MkdirAll(collectionsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	target := collections.New(collectionsDir, "fixes")
+	if err := collections.Save(target); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := []*Rule{{Name: "gsi", Pattern: "@ons.gsi.gov.uk", Replacement: "@ons.gov.uk", Exclude: []string{"**/previous/**"}}}
+	for _, r := range rules {
+		if err := r.compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := Config{
+		MasterDir:      masterDir,
+		CollectionsDir: collectionsDir,
+		Target:         target,
+		Rules:          rules,
+		Blocked:        BlockedSkip,
+	}
+	return cfg, func() { os.RemoveAll(root) }
+}
+
+var testFiles = map[string]string{
+	"/a/data.json":             `{"description":{"contact":{"email":"a@ons.gsi.gov.uk"}}}`,
+	"/b/data.json":             `{"markdown":["[email](mailto:b@ons.gsi.gov.uk)"]}`,
+	"/c/data.json":             `{"description":{"summary":"email c@ons.gsi.gov.uk"}}`,
+	"/d/data.json":             `{"description":{"contact":{"email":"d@ons.gov.uk"}}}`,
+	"/a/previous/v1/data.json": `{"description":{"contact":{"email":"a@ons.gsi.gov.uk"}}}`,
+}
+
+func TestRun(t *testing.T) {
+	cfg, cleanup := testConfig(t, testFiles)
+	defer cleanup()
+
+	tracker, err := Run(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report := tracker.Reports[0]; report.Total != 3 || report.Fixed != 3 {
+		t.Errorf("expected 3 files found and fixed, got %+v", report)
+	}
+
+	for _, uri := range []string{"/a/data.json", "/b/data.json", "/c/data.json"} {
+		if !cfg.Target.Contains(uri) {
+			t.Errorf("expected %s to be added to the collection", uri)
+		}
+	}
+}
+
+func TestRunIndexed(t *testing.T) {
+	cfg, cleanup := testConfig(t, testFiles)
+	defer cleanup()
+
+	idx := linkindex.New(cfg.MasterDir)
+	if _, err := idx.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker, err := RunIndexed(cfg, idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the plain text email in /c is not a link so is not in the index.
+	if report := tracker.Reports[0]; report.Total != 2 || report.Fixed != 2 {
+		t.Errorf("expected 2 files found and fixed, got %+v", report)
+	}
+
+	p, ok := cfg.Target.ContentPath("/a/data.json")
+	if !ok {
+		t.Fatal("expected /a/data.json to be added to the collection")
+	}
+
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `{"description":{"contact":{"email":"a@ons.gov.uk"}}}`; string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+
+	for _, uri := range []string{"/c/data.json", "/d/data.json", "/a/previous/v1/data.json"} {
+		if cfg.Target.Contains(uri) {
+			t.Errorf("expected %s not to be added to the collection", uri)
+		}
+	}
+}
+
+func TestRunIndexedDifferentMaster(t *testing.T) {
+	cfg, cleanup := testConfig(t, testFiles)
+	defer cleanup()
+
+	if _, err := RunIndexed(cfg, linkindex.New("/other/master")); err == nil {
+		t.Error("expected an index of a different master to be rejected")
+	}
+}
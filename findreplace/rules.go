@@ -0,0 +1,189 @@
+package findreplace
+
+import (
+	"encoding/json"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var defaultExtensions = []string{".json"}
+
+// Rule is a single find and replace. Pattern is a literal string unless Regex is true, in which case Replacement may
+// refer to capture groups e.g. $1. Include and Exclude are globs matched against the master relative path of each
+// file e.g. /economy/**/data.json - ** matches any number of dirs. If Fields is set only the string values of those
+// json fields are changed, a field is either a key e.g. email or a path from the root of the page with array
+// indexes left out e.g. description.contact.email.
+type Rule struct {
+	Name        string   `json:"name"`
+	Pattern     string   `json:"pattern"`
+	Regex       bool     `json:"regex"`
+	Replacement string   `json:"replacement"`
+	Include     []string `json:"include"`
+	Exclude     []string `json:"exclude"`
+	Extensions  []string `json:"extensions"`
+	Fields      []string `json:"fields"`
+
+	re      *regexp.Regexp
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// LoadRules reads a json array of rules from filename.
+func LoadRules(filename string) ([]*Rule, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errs.New("failed to read rules file", err, log.Data{"rules": filename})
+	}
+
+	var rules []*Rule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, errs.New("failed to unmarshal rules file", err, log.Data{"rules": filename})
+	}
+
+	if len(rules) == 0 {
+		return nil, errs.New("rules file contains no rules", nil, log.Data{"rules": filename})
+	}
+
+	names := make(map[string]bool)
+	for i, r := range rules {
+		if r.Name == "" {
+			return nil, errs.New("rule is missing a name", nil, log.Data{"rules": filename, "index": i})
+		}
+
+		if names[r.Name] {
+			return nil, errs.New("rule name is not unique", nil, log.Data{"rules": filename, "rule": r.Name})
+		}
+		names[r.Name] = true
+
+		if err := r.compile(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+func (r *Rule) compile() error {
+	if r.Pattern == "" {
+		return errs.New("rule is missing a pattern", nil, log.Data{"rule": r.Name})
+	}
+
+	pattern := regexp.QuoteMeta(r.Pattern)
+	if r.Regex {
+		pattern = r.Pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return errs.New("invalid rule pattern", err, log.Data{"rule": r.Name, "pattern": r.Pattern})
+	}
+	r.re = re
+
+	if len(r.Extensions) == 0 {
+		r.Extensions = defaultExtensions
+	}
+
+	if r.include, err = compileGlobs(r.Include); err != nil {
+		return errs.New("invalid rule include glob", err, log.Data{"rule": r.Name})
+	}
+
+	if r.exclude, err = compileGlobs(r.Exclude); err != nil {
+		return errs.New("invalid rule exclude glob", err, log.Data{"rule": r.Name})
+	}
+	return nil
+}
+
+// Applies returns true if the rule applies to the file at the master relative path uri.
+func (r *Rule) Applies(uri string) bool {
+	if !contains(r.Extensions, path.Ext(uri)) {
+		return false
+	}
+
+	for _, g := range r.exclude {
+		if g.MatchString(uri) {
+			return false
+		}
+	}
+
+	if len(r.include) == 0 {
+		return true
+	}
+
+	for _, g := range r.include {
+		if g.MatchString(uri) {
+			return true
+		}
+	}
+	return false
+}
+
+// replace returns s with every match of the rule pattern replaced.
+func (r *Rule) replace(s string) string {
+	if r.Regex {
+		return r.re.ReplaceAllString(s, r.Replacement)
+	}
+	return r.re.ReplaceAllLiteralString(s, r.Replacement)
+}
+
+// inScope returns true if the json field is in the field scope of the rule.
+func (r *Rule) inScope(field string, key string) bool {
+	if len(r.Fields) == 0 {
+		return true
+	}
+
+	field = arrayIndexRegex.ReplaceAllString(field, "")
+	for _, f := range r.Fields {
+		if f == key || f == field {
+			return true
+		}
+	}
+	return false
+}
+
+var arrayIndexRegex = regexp.MustCompile(`\[\d+\]`)
+
+// compileGlobs converts path globs to regular expressions. ** matches anything including /, * and ? match within a
+// single path segment.
+func compileGlobs(globs []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(globs))
+	for _, g := range globs {
+		var expr strings.Builder
+		expr.WriteString("^")
+		for i := 0; i < len(g); i++ {
+			switch {
+			case strings.HasPrefix(g[i:], "**/"):
+				expr.WriteString("(.*/)?")
+				i += 2
+			case strings.HasPrefix(g[i:], "**"):
+				expr.WriteString(".*")
+				i++
+			case g[i] == '*':
+				expr.WriteString("[^/]*")
+			case g[i] == '?':
+				expr.WriteString("[^/]")
+			default:
+				expr.WriteString(regexp.QuoteMeta(string(g[i])))
+			}
+		}
+		expr.WriteString("$")
+
+		re, err := regexp.Compile(expr.String())
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -4,5 +4,6 @@ go 1.13
 
 require (
 	github.com/ONSdigital/log.go v1.0.0
+	github.com/pkg/errors v0.9.1
 	github.com/satori/go.uuid v1.2.0
 )
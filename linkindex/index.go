@@ -0,0 +1,296 @@
+package linkindex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const indexVersion = 1
+
+// Index is an inverted index of the links in the .json pages of a zebedee master dir. For each file the links it
+// contains are stored along with the file's modification time, size and hash so the index can be updated
+// incrementally.
+type Index struct {
+	Version   int               `json:"version"`
+	MasterDir string            `json:"master_dir"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Files     map[string]*Entry `json:"files"`
+
+	// link -> set of files containing that link, built from Files.
+	referrers map[string]map[string]bool
+}
+
+// Entry is the indexed state of a single file. The file path is relative to master e.g. /economy/data.json
+type Entry struct {
+	ModTime int64    `json:"mod_time"`
+	Size    int64    `json:"size"`
+	Hash    string   `json:"hash"`
+	Links   []string `json:"links"`
+}
+
+// UpdateStats summarises the changes made to an index by Update.
+type UpdateStats struct {
+	Scanned   int `json:"scanned"`
+	Unchanged int `json:"unchanged"`
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Removed   int `json:"removed"`
+}
+
+// New returns an empty index of masterDir.
+func New(masterDir string) *Index {
+	return &Index{
+		Version:   indexVersion,
+		MasterDir: masterDir,
+		Files:     make(map[string]*Entry),
+		referrers: make(map[string]map[string]bool),
+	}
+}
+
+// Load reads an index from filename. If the file does not exist, or was built from a different master dir, a new
+// empty index is returned.
+func Load(filename string, masterDir string) (*Index, error) {
+	if !collections.Exists(filename) {
+		log.Event(nil, "no existing link index found, a new index will be built", log.Data{"index": filename})
+		return New(masterDir), nil
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errs.New("failed to read link index", err, log.Data{"index": filename})
+	}
+
+	var i Index
+	if err := json.Unmarshal(b, &i); err != nil {
+		return nil, errs.New("failed to unmarshal link index", err, log.Data{"index": filename})
+	}
+
+	if i.Version != indexVersion || i.MasterDir != masterDir {
+		log.Event(nil, "existing link index is out of date, a new index will be built", log.Data{
+			"index":        filename,
+			"index_master": i.MasterDir,
+			"master":       masterDir,
+		})
+		return New(masterDir), nil
+	}
+
+	if i.Files == nil {
+		i.Files = make(map[string]*Entry)
+	}
+	i.buildReferrers()
+	return &i, nil
+}
+
+// Open loads the index from filename, brings it up to date with master and saves it.
+func Open(filename string, masterDir string) (*Index, error) {
+	i, err := Load(filename, masterDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := i.Update(); err != nil {
+		return nil, err
+	}
+
+	if err := i.Save(filename); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// Save writes the index to filename.
+func (i *Index) Save(filename string) error {
+	b, err := json.Marshal(i)
+	if err != nil {
+		return errs.New("failed to marshal link index", err, log.Data{"index": filename})
+	}
+
+	// write to a temp file first so a failed save does not leave a corrupt index behind.
+	tmp := filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return errs.New("failed to write link index", err, log.Data{"index": filename})
+	}
+	return os.Rename(tmp, filename)
+}
+
+// Update brings the index up to date with master. Files whose modification time and size have not changed are not
+// read, files that have changed are only re-indexed if their content hash is different.
+func (i *Index) Update() (*UpdateStats, error) {
+	log.Event(nil, "updating link index", log.Data{"master": i.MasterDir})
+	stats := &UpdateStats{}
+	seen := make(map[string]bool)
+
+	err := filepath.Walk(i.MasterDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || filepath.Ext(p) != ".json" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(i.MasterDir, p)
+		if err != nil {
+			return err
+		}
+		uri := path.Join("/", filepath.ToSlash(rel))
+		seen[uri] = true
+		stats.Scanned++
+
+		existing, indexed := i.Files[uri]
+		if indexed && existing.ModTime == info.ModTime().UnixNano() && existing.Size == info.Size() {
+			stats.Unchanged++
+			return nil
+		}
+
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(b)
+		hash := hex.EncodeToString(sum[:])
+
+		if indexed && existing.Hash == hash {
+			existing.ModTime = info.ModTime().UnixNano()
+			existing.Size = info.Size()
+			stats.Unchanged++
+			return nil
+		}
+
+		links, err := collections.ExtractLinks(b)
+		if err != nil {
+			// a broken page should not stop the rest of master being indexed.
+			log.Event(nil, "failed to extract links from file, indexing with no links", log.Error(err), log.Data{"file": uri})
+			links = []string{}
+		}
+
+		if indexed {
+			i.removeReferrers(uri)
+			stats.Updated++
+		} else {
+			stats.Added++
+		}
+
+		i.Files[uri] = &Entry{
+			ModTime: info.ModTime().UnixNano(),
+			Size:    info.Size(),
+			Hash:    hash,
+			Links:   links,
+		}
+		i.addReferrers(uri)
+		return nil
+	})
+	if err != nil {
+		return nil, errs.New("failed to update link index", err, log.Data{"master": i.MasterDir})
+	}
+
+	for uri := range i.Files {
+		if !seen[uri] {
+			i.removeReferrers(uri)
+			delete(i.Files, uri)
+			stats.Removed++
+		}
+	}
+
+	i.UpdatedAt = time.Now()
+	log.Event(nil, "link index updated", log.Data{"stats": stats})
+	return stats, nil
+}
+
+// Referrers returns the master relative paths of the files linking to uri. If includeChildren is true files linking
+// to any uri beneath uri in the taxonomy are also returned.
+func (i *Index) Referrers(uri string, includeChildren bool) []string {
+	uri, ok := collections.NormaliseLink(uri)
+	if !ok {
+		return []string{}
+	}
+
+	files := make(map[string]bool)
+	for link, referrers := range i.referrers {
+		if link == uri || (includeChildren && isChild(link, uri)) {
+			for f := range referrers {
+				files[f] = true
+			}
+		}
+	}
+
+	results := make([]string, 0, len(files))
+	for f := range files {
+		results = append(results, f)
+	}
+	sort.Strings(results)
+	return results
+}
+
+// Links returns every distinct link in the index.
+func (i *Index) Links() []string {
+	links := make([]string, 0, len(i.referrers))
+	for l := range i.referrers {
+		links = append(links, l)
+	}
+	sort.Strings(links)
+	return links
+}
+
+// FindUses is a collections.UsesFinder that uses the index rather than scanning master. The index should be brought
+// up to date with Update before it is used.
+func (i *Index) FindUses(masterDir string, moves []collections.ContentMove) (map[string]map[string]string, error) {
+	if masterDir != i.MasterDir {
+		return nil, errs.New("link index was built from a different master dir", nil, log.Data{
+			"index_master": i.MasterDir,
+			"master":       masterDir,
+		})
+	}
+
+	uses := make(map[string]map[string]string)
+	for _, m := range moves {
+		log.Event(nil, "Querying link index for uses of uri", log.Data{"uri": m.MovingFromRel})
+		uses[m.MovingFromRel] = make(map[string]string)
+		for _, f := range i.Referrers(m.MovingFromRel, true) {
+			abs := filepath.Join(masterDir, f)
+			uses[m.MovingFromRel][abs] = abs
+		}
+	}
+	return uses, nil
+}
+
+func (i *Index) buildReferrers() {
+	i.referrers = make(map[string]map[string]bool)
+	for uri := range i.Files {
+		i.addReferrers(uri)
+	}
+}
+
+func (i *Index) addReferrers(uri string) {
+	for _, l := range i.Files[uri].Links {
+		if i.referrers[l] == nil {
+			i.referrers[l] = make(map[string]bool)
+		}
+		i.referrers[l][uri] = true
+	}
+}
+
+func (i *Index) removeReferrers(uri string) {
+	for _, l := range i.Files[uri].Links {
+		delete(i.referrers[l], uri)
+		if len(i.referrers[l]) == 0 {
+			delete(i.referrers, l)
+		}
+	}
+}
+
+func isChild(uri string, parent string) bool {
+	return strings.HasPrefix(uri, strings.TrimSuffix(parent, "/")+"/")
+}
@@ -0,0 +1,285 @@
+package linkindex
+
+import (
+	"github.com/ONSdigital/dp-zebedee-utils/collections"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testMaster creates a master dir with the files and returns the dir it is in.
+func testMaster(t *testing.T, files map[string]string) (string, string) {
+	root, err := ioutil.TempDir("", "linkindex")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	masterDir := path.Join(root, "master")
+	for uri, content := range files {
+		writeFile(t, path.Join(masterDir, uri), content)
+	}
+	return root, masterDir
+}
+
+func writeFile(t *testing.T, filename string, content string) {
+	if err := collections.WriteContent(filename, []byte(content)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setModTime sets the modification time of the file so changes are seen on file systems with a coarse mtime.
+func setModTime(t *testing.T, filename string, modTime time.Time) {
+	if err := os.Chtimes(filename, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectReferrers(t *testing.T, i *Index, uri string, expected ...string) {
+	if expected == nil {
+		expected = []string{}
+	}
+
+	if actual := i.Referrers(uri, true); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("%s: expected referrers %v, got %v", uri, expected, actual)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	root, masterDir := testMaster(t, map[string]string{
+		"/a/data.json": `{"uri":"/a","links":[{"uri":"/c"}]}`,
+		"/b/data.json": `{"uri":"/b","links":[{"uri":"/c/d"}]}`,
+	})
+	defer os.RemoveAll(root)
+
+	filename := path.Join(root, "index.json")
+	i, err := Open(filename, masterDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectReferrers(t, i, "/c", "/a/data.json", "/b/data.json")
+	if actual := i.Referrers("/c", false); !reflect.DeepEqual(actual, []string{"/a/data.json"}) {
+		t.Errorf("expected only the exact link without children, got %v", actual)
+	}
+
+	// adding, changing and deleting pages between opens.
+	old := time.Now().Add(-time.Hour)
+	writeFile(t, path.Join(masterDir, "/a/data.json"), `{"uri":"/a","links":[{"uri":"/e"}]}`)
+	setModTime(t, path.Join(masterDir, "/a/data.json"), old)
+	writeFile(t, path.Join(masterDir, "/f/data.json"), `{"uri":"/f","links":[{"uri":"/c/g"}]}`)
+	if err := os.RemoveAll(path.Join(masterDir, "/b")); err != nil {
+		t.Fatal(err)
+	}
+
+	if i, err = Open(filename, masterDir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectReferrers(t, i, "/c", "/f/data.json")
+	expectReferrers(t, i, "/e", "/a/data.json")
+	expectReferrers(t, i, "/c/d")
+
+	if _, ok := i.Files["/b/data.json"]; ok {
+		t.Error("expected the deleted page to be removed from the index")
+	}
+
+	loaded, err := Load(filename, masterDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(loaded.Files, i.Files) || !reflect.DeepEqual(loaded.Links(), i.Links()) {
+		t.Errorf("expected the saved index to be loaded, got %+v", loaded.Files)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	root, masterDir := testMaster(t, map[string]string{
+		"/a/data.json": `{"uri":"/a","links":[{"uri":"/c"}]}`,
+		"/b/data.json": `{"uri":"/b","links":[{"uri":"/d"}]}`,
+		"/b/chart.png": "not a page",
+	})
+	defer os.RemoveAll(root)
+
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, uri := range []string{"/a/data.json", "/b/data.json"} {
+		setModTime(t, path.Join(masterDir, uri), modTime)
+	}
+
+	i := New(masterDir)
+	stats, err := i.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *stats != (UpdateStats{Scanned: 2, Added: 2}) {
+		t.Errorf("expected 2 pages to be added, got %+v", stats)
+	}
+
+	cases := []struct {
+		name     string
+		change   func()
+		expected UpdateStats
+		links    map[string][]string
+	}{
+		{
+			name:     "unchanged",
+			change:   func() {},
+			expected: UpdateStats{Scanned: 2, Unchanged: 2},
+		},
+		{
+			name: "touched",
+			change: func() {
+				setModTime(t, path.Join(masterDir, "/a/data.json"), modTime.Add(time.Minute))
+			},
+			expected: UpdateStats{Scanned: 2, Unchanged: 2},
+		},
+		{
+			// the same size so only the new modification time shows the change, the hash shows it is different.
+			name: "changed content same size",
+			change: func() {
+				writeFile(t, path.Join(masterDir, "/a/data.json"), `{"uri":"/a","links":[{"uri":"/e"}]}`)
+				setModTime(t, path.Join(masterDir, "/a/data.json"), modTime.Add(2*time.Minute))
+			},
+			expected: UpdateStats{Scanned: 2, Unchanged: 1, Updated: 1},
+			links:    map[string][]string{"/c": nil, "/e": {"/a/data.json"}},
+		},
+		{
+			// the same modification time so only the new size shows the change.
+			name: "changed size",
+			change: func() {
+				writeFile(t, path.Join(masterDir, "/b/data.json"), `{"uri":"/b","links":[{"uri":"/d"},{"uri":"/e"}]}`)
+				setModTime(t, path.Join(masterDir, "/b/data.json"), modTime)
+			},
+			expected: UpdateStats{Scanned: 2, Unchanged: 1, Updated: 1},
+			links:    map[string][]string{"/d": {"/b/data.json"}, "/e": {"/a/data.json", "/b/data.json"}},
+		},
+		{
+			name: "added",
+			change: func() {
+				writeFile(t, path.Join(masterDir, "/a/c/data.json"), `{"uri":"/a/c","links":[{"uri":"/d/x"}]}`)
+			},
+			expected: UpdateStats{Scanned: 3, Unchanged: 2, Added: 1},
+			links:    map[string][]string{"/d": {"/a/c/data.json", "/b/data.json"}},
+		},
+		{
+			name: "deleted",
+			change: func() {
+				if err := os.Remove(path.Join(masterDir, "/b/data.json")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expected: UpdateStats{Scanned: 2, Unchanged: 2, Removed: 1},
+			links:    map[string][]string{"/d": {"/a/c/data.json"}, "/e": {"/a/data.json"}},
+		},
+		{
+			name: "invalid json",
+			change: func() {
+				writeFile(t, path.Join(masterDir, "/a/c/data.json"), `{"uri":`)
+			},
+			expected: UpdateStats{Scanned: 2, Unchanged: 1, Updated: 1},
+			links:    map[string][]string{"/d": nil},
+		},
+	}
+
+	for _, c := range cases {
+		c.change()
+		stats, err := i.Update()
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+
+		if *stats != c.expected {
+			t.Errorf("%s: expected %+v, got %+v", c.name, c.expected, *stats)
+		}
+
+		for uri, expected := range c.links {
+			expectReferrers(t, i, uri, expected...)
+		}
+
+		for uri, e := range i.Files {
+			info, err := os.Stat(filepath.Join(masterDir, uri))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if e.ModTime != info.ModTime().UnixNano() || e.Size != info.Size() {
+				t.Errorf("%s: expected the entry for %s to have the modification time and size of the file", c.name, uri)
+			}
+		}
+	}
+}
+
+func TestLoadDifferentMaster(t *testing.T) {
+	root, masterDir := testMaster(t, map[string]string{"/a/data.json": `{"uri":"/a","links":[{"uri":"/c"}]}`})
+	defer os.RemoveAll(root)
+
+	filename := path.Join(root, "index.json")
+	if _, err := Open(filename, masterDir); err != nil {
+		t.Fatal(err)
+	}
+
+	i, err := Load(filename, path.Join(root, "other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(i.Files) != 0 || i.MasterDir != path.Join(root, "other") {
+		t.Errorf("expected a new index of the other master, got %+v", i)
+	}
+}
+
+func TestFindUses(t *testing.T) {
+	root, masterDir := testMaster(t, map[string]string{
+		"/a/data.json": `{"uri":"/a","links":[{"uri":"/c"}]}`,
+		"/b/data.json": `{"uri":"/b","links":[{"uri":"/c/d"}]}`,
+		"/e/data.json": `{"uri":"/e","links":[{"uri":"/cd"}]}`,
+	})
+	defer os.RemoveAll(root)
+
+	i := New(masterDir)
+	if _, err := i.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	moves := []collections.ContentMove{{MovingFromRel: "/c"}, {MovingFromRel: "/b"}}
+	uses, err := i.FindUses(masterDir, moves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := filepath.Join(masterDir, "/a/data.json"), filepath.Join(masterDir, "/b/data.json")
+	expected := map[string]map[string]string{
+		"/c": {a: a, b: b},
+		"/b": {b: b},
+	}
+	if !reflect.DeepEqual(uses, expected) {
+		t.Errorf("expected %v, got %v", expected, uses)
+	}
+
+	// uses of a deleted page's links are gone after the next update.
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := i.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	if uses, err = i.FindUses(masterDir, moves); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = map[string]map[string]string{"/c": {a: a}, "/b": {}}
+	if !reflect.DeepEqual(uses, expected) {
+		t.Errorf("expected %v, got %v", expected, uses)
+	}
+
+	if _, err := i.FindUses(path.Join(root, "other"), moves); err == nil {
+		t.Error("expected an index of a different master to be rejected")
+	}
+}
@@ -0,0 +1,322 @@
+package pages
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"reflect"
+	"strings"
+)
+
+// Decode detects the type of the page json and decodes it into the matching page model. Pages of a type without a
+// typed model are decoded into a GenericPage.
+func Decode(b []byte) (Page, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(b, &header); err != nil {
+		return nil, errs.New("failed to unmarshal page json", err, nil)
+	}
+
+	p := newPage(header.Type)
+	if err := json.Unmarshal(b, p); err != nil {
+		return nil, errs.New("failed to unmarshal page into page model", err, log.Data{"type": header.Type})
+	}
+
+	p.base().original = append([]byte{}, b...)
+	return p, nil
+}
+
+// Encode returns the json of the page. The fields of the json the page was decoded from are written in their original
+// order with any fields unknown to the page model kept as they were, only the values of fields the model knows about
+// are replaced and values that are unchanged keep their original representation. Fields the model has set that were
+// not in the original json are added after them. If the original json was indented the result uses the same indent.
+func Encode(p Page) ([]byte, error) {
+	var typedBuf bytes.Buffer
+	enc := json.NewEncoder(&typedBuf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(p); err != nil {
+		return nil, errs.New("failed to marshal page", err, log.Data{"type": p.GetType()})
+	}
+
+	typed := bytes.TrimSpace(typedBuf.Bytes())
+
+	original := p.base().original
+	if len(original) == 0 {
+		return typed, nil
+	}
+
+	merged, err := merge(original, typed, reflect.TypeOf(p))
+	if err != nil {
+		return nil, errs.New("failed to merge page with original json", err, log.Data{"type": p.GetType(), "uri": p.GetURI()})
+	}
+
+	indent, ok := detectIndent(original)
+	if !ok {
+		return merged, nil
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, merged, "", indent); err != nil {
+		return nil, err
+	}
+
+	if bytes.HasSuffix(original, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// detectIndent returns the whitespace used to indent the first field of the json, false if it is not indented.
+func detectIndent(b []byte) (string, bool) {
+	b = bytes.TrimSpace(b)
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return "", false
+	}
+
+	line := b[i+1:]
+	end := 0
+	for end < len(line) && (line[end] == ' ' || line[end] == '\t') {
+		end++
+	}
+
+	if end == 0 {
+		return "", false
+	}
+	return string(line[:end]), true
+}
+
+func newPage(pageType string) Page {
+	switch pageType {
+	case TypeArticle:
+		return &Article{}
+	case TypeBulletin:
+		return &Bulletin{}
+	case TypeCompendiumLandingPage:
+		return &CompendiumLandingPage{}
+	case TypeCompendiumChapter:
+		return &CompendiumChapter{}
+	case TypeDatasetLandingPage:
+		return &DatasetLandingPage{}
+	case TypeDataset, TypeTimeseriesDataset:
+		return &Dataset{}
+	case TypeTimeseries:
+		return &Timeseries{}
+	case TypeStaticPage:
+		return &StaticPage{}
+	case TypeTaxonomyLandingPage:
+		return &TaxonomyLandingPage{}
+	default:
+		return &GenericPage{}
+	}
+}
+
+// field is a single member of a json object.
+type field struct {
+	key   string
+	value json.RawMessage
+}
+
+// merge returns the original json with the values known to the model type t replaced by those in typed, recursing
+// into objects and arrays so unknown fields are kept at every level.
+func merge(original json.RawMessage, typed json.RawMessage, t reflect.Type) (json.RawMessage, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	trimmedOriginal := bytes.TrimSpace(original)
+	trimmedTyped := bytes.TrimSpace(typed)
+
+	switch {
+	case t.Kind() == reflect.Struct && isObject(trimmedOriginal) && isObject(trimmedTyped):
+		return mergeObject(trimmedOriginal, trimmedTyped, t)
+	case t.Kind() == reflect.Slice && isArray(trimmedOriginal) && isArray(trimmedTyped):
+		return mergeArray(trimmedOriginal, trimmedTyped, t.Elem())
+	case isZero(trimmedTyped) && isZero(trimmedOriginal), equalValues(trimmedOriginal, trimmedTyped):
+		// keep the original representation of an unchanged value e.g. "" rather than null.
+		return trimmedOriginal, nil
+	default:
+		return trimmedTyped, nil
+	}
+}
+
+func mergeObject(original json.RawMessage, typed json.RawMessage, t reflect.Type) (json.RawMessage, error) {
+	originalFields, err := readObject(original)
+	if err != nil {
+		return nil, err
+	}
+
+	typedFields, err := readObject(typed)
+	if err != nil {
+		return nil, err
+	}
+
+	known := knownFields(t)
+	typedValues := make(map[string]json.RawMessage)
+	for _, f := range typedFields {
+		typedValues[f.key] = f.value
+	}
+
+	result := make([]field, 0, len(originalFields))
+	written := make(map[string]bool)
+	for _, f := range originalFields {
+		written[f.key] = true
+		fieldType, isKnown := known[f.key]
+		if !isKnown {
+			result = append(result, f)
+			continue
+		}
+
+		v, err := merge(f.value, typedValues[f.key], fieldType)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, field{key: f.key, value: v})
+	}
+
+	// fields set on the model that the original did not have.
+	for _, f := range typedFields {
+		if !written[f.key] && !isZero(f.value) {
+			result = append(result, f)
+		}
+	}
+	return writeObject(result)
+}
+
+func mergeArray(original json.RawMessage, typed json.RawMessage, elem reflect.Type) (json.RawMessage, error) {
+	var originalValues, typedValues []json.RawMessage
+	if err := json.Unmarshal(original, &originalValues); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(typed, &typedValues); err != nil {
+		return nil, err
+	}
+
+	// elements are matched by position, any added beyond the original length are written as they are.
+	result := make([]json.RawMessage, len(typedValues))
+	for i, v := range typedValues {
+		if i >= len(originalValues) {
+			result[i] = v
+			continue
+		}
+
+		merged, err := merge(originalValues[i], v, elem)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = merged
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, v := range result {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// knownFields returns the json field names of the struct type t, including those of embedded structs.
+func knownFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for k, v := range knownFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// readObject returns the fields of a json object in the order they appear.
+func readObject(b json.RawMessage) ([]field, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	fields := make([]field, 0)
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		fields = append(fields, field{key: t.(string), value: v})
+	}
+	return fields, nil
+}
+
+func writeObject(fields []field) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(f.value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func isObject(b []byte) bool {
+	return len(b) > 0 && b[0] == '{'
+}
+
+func isArray(b []byte) bool {
+	return len(b) > 0 && b[0] == '['
+}
+
+// equalValues returns true if the json values decode to the same value.
+func equalValues(a []byte, b []byte) bool {
+	var x, y interface{}
+	if err := json.Unmarshal(a, &x); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// isZero returns true if the json value is null, false, 0, an empty string, array or object.
+func isZero(b []byte) bool {
+	switch string(bytes.TrimSpace(b)) {
+	case "", "null", "false", "0", `""`, "[]", "{}":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package pages
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncodeKeepsUnknownFieldsAndOrder(t *testing.T) {
+	original := `{"uri":"/economy/a","unknownTop":{"x":1},"type":"article","description":{"unknownNested":"keep","title":"Old","releaseDate":"2020-01-01T00:00:00.000Z"},"sections":[{"title":"S","markdown":"m","extra":true}]}`
+
+	p, err := Decode([]byte(original))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, ok := p.(*Article)
+	if !ok {
+		t.Fatalf("expected an article, got %T", p)
+	}
+	a.Description.Title = "New"
+
+	b, err := Encode(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Replace(original, `"title":"Old"`, `"title":"New"`, 1)
+	if string(b) != expected {
+		t.Errorf("expected %s\ngot      %s", expected, b)
+	}
+}
+
+func TestEncodeUnchanged(t *testing.T) {
+	original := "{\n  \"type\": \"static_page\",\n  \"uri\": \"/about\",\n  \"description\": {\n    \"title\": \"About\",\n    \"unknown\": 1.50\n  }\n}\n"
+
+	p, err := Decode([]byte(original))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := Encode(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != original {
+		t.Errorf("expected unchanged page to be encoded as it was read\nexpected %q\ngot      %q", original, b)
+	}
+}
+
+func TestEncodeGenericPage(t *testing.T) {
+	original := `{"type":"unknown_type","uri":"/a","custom":[1,2,3]}`
+
+	p, err := Decode([]byte(original))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := p.(*GenericPage); !ok {
+		t.Fatalf("expected a generic page, got %T", p)
+	}
+
+	b, err := Encode(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != original {
+		t.Errorf("expected %s, got %s", original, b)
+	}
+}
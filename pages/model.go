@@ -0,0 +1,238 @@
+package pages
+
+// Zebedee page types.
+const (
+	TypeArticle               = "article"
+	TypeBulletin              = "bulletin"
+	TypeCompendiumLandingPage = "compendium_landing_page"
+	TypeCompendiumChapter     = "compendium_chapter"
+	TypeDatasetLandingPage    = "dataset_landing_page"
+	TypeDataset               = "dataset"
+	TypeTimeseriesDataset     = "timeseries_dataset"
+	TypeTimeseries            = "timeseries"
+	TypeStaticPage            = "static_page"
+	TypeTaxonomyLandingPage   = "taxonomy_landing_page"
+)
+
+// Page is implemented by every page type.
+type Page interface {
+	// GetType returns the Zebedee page type e.g. bulletin.
+	GetType() string
+	// GetURI returns the taxonomy uri of the page.
+	GetURI() string
+	// GetDescription returns the page description.
+	GetDescription() *Description
+
+	base() *Base
+}
+
+// Base is the fields common to every page. original is the json the page was decoded from, used by Encode to keep the
+// field order and any unknown fields.
+type Base struct {
+	Type        string       `json:"type"`
+	URI         string       `json:"uri"`
+	Description *Description `json:"description"`
+
+	original []byte
+}
+
+func (b *Base) GetType() string {
+	return b.Type
+}
+
+func (b *Base) GetURI() string {
+	return b.URI
+}
+
+func (b *Base) GetDescription() *Description {
+	return b.Description
+}
+
+func (b *Base) base() *Base {
+	return b
+}
+
+// Description is the page description.
+type Description struct {
+	Title             string   `json:"title"`
+	Edition           string   `json:"edition"`
+	Summary           string   `json:"summary"`
+	Keywords          []string `json:"keywords"`
+	MetaDescription   string   `json:"metaDescription"`
+	NationalStatistic bool     `json:"nationalStatistic"`
+	LatestRelease     bool     `json:"latestRelease"`
+	Contact           *Contact `json:"contact"`
+	ReleaseDate       string   `json:"releaseDate"`
+	NextRelease       string   `json:"nextRelease"`
+	DatasetID         string   `json:"datasetId"`
+	CDID              string   `json:"cdid"`
+	Unit              string   `json:"unit"`
+	PreUnit           string   `json:"preUnit"`
+	Source            string   `json:"source"`
+	Language          string   `json:"language"`
+}
+
+// Contact is the contact details of a page.
+type Contact struct {
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Telephone string `json:"telephone"`
+}
+
+// Link is a link to another page.
+type Link struct {
+	Title string `json:"title"`
+	URI   string `json:"uri"`
+}
+
+// MarkdownSection is a titled block of markdown.
+type MarkdownSection struct {
+	Title    string `json:"title"`
+	Markdown string `json:"markdown"`
+}
+
+// Figure is a chart, table, image or equation belonging to a page.
+type Figure struct {
+	Title    string `json:"title"`
+	Filename string `json:"filename"`
+	URI      string `json:"uri"`
+}
+
+// Alert is a notice or correction displayed on a page.
+type Alert struct {
+	Date     string `json:"date"`
+	Markdown string `json:"markdown"`
+	Type     string `json:"type"`
+}
+
+// Version is a previous version of a page.
+type Version struct {
+	URI              string `json:"uri"`
+	UpdateDate       string `json:"updateDate"`
+	CorrectionNotice string `json:"correctionNotice"`
+	Label            string `json:"label"`
+}
+
+// Download is a file that can be downloaded from a page.
+type Download struct {
+	Title string `json:"title"`
+	File  string `json:"file"`
+}
+
+// Content is the fields shared by the statistical content pages - articles, bulletins and compendium chapters.
+type Content struct {
+	Sections                  []MarkdownSection `json:"sections"`
+	Accordion                 []MarkdownSection `json:"accordion"`
+	RelatedBulletins          []Link            `json:"relatedBulletins"`
+	RelatedData               []Link            `json:"relatedData"`
+	RelatedMethodology        []Link            `json:"relatedMethodology"`
+	RelatedMethodologyArticle []Link            `json:"relatedMethodologyArticle"`
+	Links                     []Link            `json:"links"`
+	Charts                    []Figure          `json:"charts"`
+	Tables                    []Figure          `json:"tables"`
+	Images                    []Figure          `json:"images"`
+	Equations                 []Figure          `json:"equations"`
+	Alerts                    []Alert           `json:"alerts"`
+	Versions                  []Version         `json:"versions"`
+}
+
+// Article is an article page.
+type Article struct {
+	Base
+	Content
+	IsPrototypeArticle bool   `json:"isPrototypeArticle"`
+	ImageURI           string `json:"imageUri"`
+}
+
+// Bulletin is a statistical bulletin page.
+type Bulletin struct {
+	Base
+	Content
+	RelatedArticles []Link `json:"relatedArticles"`
+}
+
+// CompendiumLandingPage is the landing page of a compendium.
+type CompendiumLandingPage struct {
+	Base
+	Chapters           []Link `json:"chapters"`
+	Datasets           []Link `json:"datasets"`
+	RelatedMethodology []Link `json:"relatedMethodology"`
+}
+
+// CompendiumChapter is a chapter of a compendium.
+type CompendiumChapter struct {
+	Base
+	Content
+}
+
+// DatasetLandingPage is the landing page of a dataset, the dataset pages it lists hold the download files.
+type DatasetLandingPage struct {
+	Base
+	Section                   *MarkdownSection `json:"section"`
+	Notes                     *MarkdownSection `json:"notes"`
+	Datasets                  []Link           `json:"datasets"`
+	Links                     []Link           `json:"links"`
+	RelatedDatasets           []Link           `json:"relatedDatasets"`
+	RelatedDocuments          []Link           `json:"relatedDocuments"`
+	RelatedMethodology        []Link           `json:"relatedMethodology"`
+	RelatedMethodologyArticle []Link           `json:"relatedMethodologyArticle"`
+	Alerts                    []Alert          `json:"alerts"`
+	Timeseries                bool             `json:"timeseries"`
+}
+
+// Dataset is an edition of a dataset holding the download files.
+type Dataset struct {
+	Base
+	Downloads          []Download `json:"downloads"`
+	SupplementaryFiles []Download `json:"supplementaryFiles"`
+	Versions           []Version  `json:"versions"`
+}
+
+// Timeseries is a single timeseries page.
+type Timeseries struct {
+	Base
+	Section          *MarkdownSection  `json:"section"`
+	Years            []TimeseriesValue `json:"years"`
+	Quarters         []TimeseriesValue `json:"quarters"`
+	Months           []TimeseriesValue `json:"months"`
+	SourceDatasets   []Link            `json:"sourceDatasets"`
+	RelatedDatasets  []Link            `json:"relatedDatasets"`
+	RelatedDocuments []Link            `json:"relatedDocuments"`
+	RelatedData      []Link            `json:"relatedData"`
+	Notes            []string          `json:"notes"`
+	Alerts           []Alert           `json:"alerts"`
+	Versions         []Version         `json:"versions"`
+}
+
+// TimeseriesValue is a single data point of a timeseries. Values are strings as stored by Zebedee.
+type TimeseriesValue struct {
+	Date          string `json:"date"`
+	Value         string `json:"value"`
+	Year          string `json:"year"`
+	Month         string `json:"month"`
+	Quarter       string `json:"quarter"`
+	SourceDataset string `json:"sourceDataset"`
+	UpdateDate    string `json:"updateDate"`
+	Label         string `json:"label"`
+}
+
+// StaticPage is a static markdown page.
+type StaticPage struct {
+	Base
+	Markdown  []string   `json:"markdown"`
+	Links     []Link     `json:"links"`
+	Downloads []Download `json:"downloads"`
+}
+
+// TaxonomyLandingPage is the landing page of a taxonomy node e.g. /economy.
+type TaxonomyLandingPage struct {
+	Base
+	Sections         []Link `json:"sections"`
+	HighlightedLinks []Link `json:"highlightedLinks"`
+}
+
+// GenericPage is any page type without a typed model. Only the common fields are typed, the rest of the page is kept
+// as is when encoded.
+type GenericPage struct {
+	Base
+}
@@ -0,0 +1,360 @@
+package pages
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Problem severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// date formats accepted by Zebedee/Babbage for page dates.
+var dateFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000Z0700",
+	"2006-01-02T15:04:05Z0700",
+}
+
+var uriRegex = regexp.MustCompile(`^(/[a-z0-9][a-z0-9\-_.]*)+$`)
+
+// linkTypes is the page types each link field is expected to point at, keyed by page type then field.
+var linkTypes = map[string]map[string][]string{
+	TypeArticle:           contentLinkTypes,
+	TypeBulletin:          contentLinkTypes,
+	TypeCompendiumChapter: contentLinkTypes,
+	TypeCompendiumLandingPage: {
+		"chapters":           {TypeCompendiumChapter},
+		"datasets":           {"compendium_data"},
+		"relatedMethodology": methodologyTypes,
+	},
+	TypeDatasetLandingPage: {
+		"datasets":                  {TypeDataset, TypeTimeseriesDataset},
+		"relatedDatasets":           {TypeDatasetLandingPage, "compendium_data"},
+		"relatedMethodology":        methodologyTypes,
+		"relatedMethodologyArticle": methodologyTypes,
+	},
+	TypeTimeseries: {
+		"sourceDatasets":  {TypeDatasetLandingPage, "compendium_data"},
+		"relatedDatasets": {TypeDatasetLandingPage, "compendium_data"},
+	},
+	TypeTaxonomyLandingPage: {
+		"sections": {TypeTaxonomyLandingPage, "product_page"},
+	},
+}
+
+var contentLinkTypes = map[string][]string{
+	"relatedBulletins":          {TypeBulletin},
+	"relatedArticles":           {TypeArticle, "article_download", TypeCompendiumLandingPage},
+	"relatedMethodology":        methodologyTypes,
+	"relatedMethodologyArticle": methodologyTypes,
+}
+
+var methodologyTypes = []string{"static_methodology", "static_methodology_download", "static_qmi"}
+
+// Problem is a single validation failure of a page.
+type Problem struct {
+	Page     string `json:"page"`
+	Field    string `json:"field"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// TypeResolver returns the page type of the page at uri, false if there is no page at uri.
+type TypeResolver func(uri string) (string, bool)
+
+// DirResolver returns a TypeResolver reading the data.json of pages from the content dirs, the first dir containing
+// the page wins. Results are cached.
+func DirResolver(dirs ...string) TypeResolver {
+	cache := make(map[string]string)
+	return func(uri string) (string, bool) {
+		if t, ok := cache[uri]; ok {
+			return t, t != ""
+		}
+
+		for _, dir := range dirs {
+			b, err := ioutil.ReadFile(path.Join(dir, uri, "data.json"))
+			if err != nil {
+				continue
+			}
+
+			var header struct {
+				Type string `json:"type"`
+			}
+			if err := json.Unmarshal(b, &header); err == nil {
+				cache[uri] = header.Type
+				return header.Type, header.Type != ""
+			}
+		}
+
+		cache[uri] = ""
+		return "", false
+	}
+}
+
+// Validator checks pages for problems that would break rendering in Babbage.
+type Validator struct {
+	resolve TypeResolver
+}
+
+// NewValidator returns a validator checking links with resolve, if resolve is nil links are not checked.
+func NewValidator(resolve TypeResolver) *Validator {
+	return &Validator{resolve: resolve}
+}
+
+// Validate checks the page json b of the page at location - the taxonomy uri of the dir containing the file. If
+// location is empty the page uri is not checked against it.
+func (v *Validator) Validate(location string, b []byte) []Problem {
+	c := &check{page: path.Join(location, "data.json"), problems: make([]Problem, 0)}
+	if location == "" {
+		c.page = ""
+	}
+
+	p, err := Decode(b)
+	if err != nil {
+		c.errorf("", "invalid page json: %s", cause(err))
+		return c.problems
+	}
+
+	if c.page == "" {
+		c.page = path.Join(p.GetURI(), "data.json")
+	}
+
+	c.required("type", p.GetType())
+	if _, generic := p.(*GenericPage); generic && p.GetType() != "" {
+		c.warnf("type", "page type %q has no typed model so only the common fields are checked", p.GetType())
+	}
+
+	c.uri("uri", p.GetURI())
+	if location != "" && p.GetURI() != "" && p.GetURI() != location {
+		c.errorf("uri", "uri %q does not match the location of the page %q", p.GetURI(), location)
+	}
+
+	d := p.GetDescription()
+	if d == nil {
+		// report the missing description once rather than every required description field.
+		c.errorf("description", "missing required field")
+		c.noDescription = true
+		d = &Description{}
+	}
+	c.required("description.title", d.Title)
+	c.date("description.releaseDate", d.ReleaseDate)
+
+	switch page := p.(type) {
+	case *Article:
+		c.required("description.releaseDate", d.ReleaseDate)
+		c.content(page.Content)
+	case *Bulletin:
+		c.required("description.releaseDate", d.ReleaseDate)
+		c.required("description.edition", d.Edition)
+		c.content(page.Content)
+		c.links("relatedArticles", page.RelatedArticles)
+	case *CompendiumChapter:
+		c.content(page.Content)
+	case *CompendiumLandingPage:
+		c.required("description.releaseDate", d.ReleaseDate)
+		c.links("chapters", page.Chapters)
+		c.links("datasets", page.Datasets)
+		c.links("relatedMethodology", page.RelatedMethodology)
+	case *DatasetLandingPage:
+		c.required("description.releaseDate", d.ReleaseDate)
+		c.links("datasets", page.Datasets)
+		c.links("links", page.Links)
+		c.links("relatedDatasets", page.RelatedDatasets)
+		c.links("relatedDocuments", page.RelatedDocuments)
+		c.links("relatedMethodology", page.RelatedMethodology)
+		c.links("relatedMethodologyArticle", page.RelatedMethodologyArticle)
+		c.alerts(page.Alerts)
+	case *Dataset:
+		for i, dl := range page.Downloads {
+			c.required(fmt.Sprintf("downloads[%d].file", i), dl.File)
+		}
+		c.versions(page.Versions)
+	case *Timeseries:
+		c.required("description.cdid", d.CDID)
+		c.links("sourceDatasets", page.SourceDatasets)
+		c.links("relatedDatasets", page.RelatedDatasets)
+		c.links("relatedDocuments", page.RelatedDocuments)
+		c.links("relatedData", page.RelatedData)
+		c.alerts(page.Alerts)
+		c.versions(page.Versions)
+	case *StaticPage:
+		c.links("links", page.Links)
+	case *TaxonomyLandingPage:
+		c.links("sections", page.Sections)
+		c.links("highlightedLinks", page.HighlightedLinks)
+	}
+
+	if v.resolve != nil {
+		v.checkLinkTypes(c, p.GetType(), b)
+	}
+	return c.problems
+}
+
+// checkLinkTypes checks that the links in the link fields of the page point at pages of the expected type.
+func (v *Validator) checkLinkTypes(c *check, pageType string, b []byte) {
+	fields := linkTypes[pageType]
+	if len(fields) == 0 {
+		return
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return
+	}
+
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	for _, field := range names {
+		var links []Link
+		if _, ok := raw[field]; !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw[field], &links); err != nil {
+			continue
+		}
+
+		expected := fields[field]
+		for i, l := range links {
+			if l.URI == "" || !strings.HasPrefix(l.URI, "/") {
+				continue
+			}
+
+			name := fmt.Sprintf("%s[%d].uri", field, i)
+			t, found := v.resolve(strings.TrimSuffix(l.URI, "/"))
+			if !found {
+				c.warnf(name, "link target %q not found", l.URI)
+				continue
+			}
+
+			if !contains(expected, t) {
+				c.errorf(name, "link target %q is a %s page, expected %s", l.URI, t, strings.Join(expected, " or "))
+			}
+		}
+	}
+}
+
+// check accumulates the problems found with a single page.
+type check struct {
+	page          string
+	problems      []Problem
+	noDescription bool
+}
+
+func (c *check) errorf(field string, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{Page: c.page, Field: field, Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *check) warnf(field string, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{Page: c.page, Field: field, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *check) required(field string, value string) {
+	if c.noDescription && strings.HasPrefix(field, "description.") {
+		return
+	}
+
+	if strings.TrimSpace(value) == "" {
+		c.errorf(field, "missing required field")
+	}
+}
+
+func (c *check) uri(field string, uri string) {
+	if uri == "" {
+		c.errorf(field, "missing required field")
+		return
+	}
+
+	// the home page is the only page at the root.
+	if uri != "/" && !uriRegex.MatchString(uri) {
+		c.errorf(field, "invalid uri %q: expected a lowercase absolute path with no trailing slash", uri)
+	}
+}
+
+func (c *check) date(field string, value string) {
+	if value == "" {
+		return
+	}
+
+	for _, f := range dateFormats {
+		if _, err := time.Parse(f, value); err == nil {
+			return
+		}
+	}
+	c.errorf(field, "invalid date %q: expected an ISO 8601 date e.g. 2019-11-12T09:30:00.000Z", value)
+}
+
+func (c *check) links(field string, links []Link) {
+	for i, l := range links {
+		name := fmt.Sprintf("%s[%d].uri", field, i)
+		if l.URI == "" {
+			c.errorf(name, "missing required field")
+			continue
+		}
+
+		// external links are allowed in some link fields, only internal uris have to be well formed.
+		if strings.HasPrefix(l.URI, "/") {
+			uri := strings.Split(l.URI, "#")[0]
+			if uri != "/" {
+				uri = strings.TrimSuffix(uri, "/")
+			}
+			c.uri(name, uri)
+		}
+	}
+}
+
+func (c *check) alerts(alerts []Alert) {
+	for i, a := range alerts {
+		c.date(fmt.Sprintf("alerts[%d].date", i), a.Date)
+	}
+}
+
+func (c *check) versions(versions []Version) {
+	for i, v := range versions {
+		c.uri(fmt.Sprintf("versions[%d].uri", i), v.URI)
+		c.date(fmt.Sprintf("versions[%d].updateDate", i), v.UpdateDate)
+	}
+}
+
+func (c *check) content(content Content) {
+	c.links("relatedBulletins", content.RelatedBulletins)
+	c.links("relatedData", content.RelatedData)
+	c.links("relatedMethodology", content.RelatedMethodology)
+	c.links("relatedMethodologyArticle", content.RelatedMethodologyArticle)
+	c.alerts(content.Alerts)
+	c.versions(content.Versions)
+
+	for i, s := range content.Sections {
+		c.required(fmt.Sprintf("sections[%d].title", i), s.Title)
+	}
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// cause returns the message of the underlying error of a decode error.
+func cause(err error) string {
+	if e, ok := err.(errs.Error); ok && e.OriginalErr != nil {
+		return e.OriginalErr.Error()
+	}
+	return err.Error()
+}
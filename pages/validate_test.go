@@ -0,0 +1,30 @@
+package pages
+
+import (
+	"testing"
+)
+
+func errorProblems(problems []Problem) []Problem {
+	errors := make([]Problem, 0)
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			errors = append(errors, p)
+		}
+	}
+	return errors
+}
+
+func TestValidateRootURI(t *testing.T) {
+	home := `{"type":"home_page","uri":"/","description":{"title":"Home"}}`
+	if problems := errorProblems(NewValidator(nil).Validate("/", []byte(home))); len(problems) != 0 {
+		t.Errorf("expected the home page uri to be valid, got %+v", problems)
+	}
+
+	landing := `{"type":"taxonomy_landing_page","uri":"/economy","description":{"title":"Economy"},` +
+		`"sections":[{"uri":"/"},{"uri":"/#top"},{"uri":"/economy/gdp/"}],"highlightedLinks":[{"uri":"/Economy"}]}`
+
+	problems := errorProblems(NewValidator(nil).Validate("/economy", []byte(landing)))
+	if len(problems) != 1 || problems[0].Field != "highlightedLinks[0].uri" {
+		t.Errorf("expected only the uppercase link to be invalid, got %+v", problems)
+	}
+}
@@ -0,0 +1,199 @@
+package publishlog
+
+import (
+	"encoding/json"
+	"github.com/ONSdigital/dp-zebedee-utils/errs"
+	"github.com/ONSdigital/log.go/log"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the date formats used for publish-log dates, the Zebedee (Gson) formats and the format written by
+// collections.Publish.
+var dateFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000Z0700",
+	"2006-01-02T15:04:05Z0700",
+	"Jan 2, 2006 3:04:05 PM",
+	"Jan 2, 2006, 3:04:05 PM",
+}
+
+// the format of the date prefix of publish-log entry file names.
+const fileDateFormat = "2006-01-02-15-04"
+
+// Record is a single publish-log entry.
+type Record struct {
+	Collection   string    `json:"collection"`
+	CollectionID string    `json:"collection_id"`
+	PublishDate  time.Time `json:"publish_date"`
+	URIs         []string  `json:"uris"`
+	File         string    `json:"file"`
+}
+
+// entry is the subset of the publish-log json needed to build a Record. Dates are read as strings as the format
+// depends on which version of Zebedee wrote the entry.
+type entry struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	PublishDate      string `json:"publishDate"`
+	PublishStartDate string `json:"publishStartDate"`
+	PublishResults   []struct {
+		Transaction struct {
+			StartDate string `json:"startDate"`
+			URIInfos  []struct {
+				URI string `json:"uri"`
+			} `json:"uriInfos"`
+		} `json:"transaction"`
+	} `json:"publishResults"`
+}
+
+// Read parses every entry in the publish-log dir. Records are returned oldest first. Entries that can't be parsed are
+// logged and skipped so one bad file does not hide the rest of the log.
+func Read(publishLogDir string) ([]*Record, error) {
+	infos, err := ioutil.ReadDir(publishLogDir)
+	if err != nil {
+		return nil, errs.New("failed to read publish-log dir", err, log.Data{"dir": publishLogDir})
+	}
+
+	records := make([]*Record, 0)
+	for _, info := range infos {
+		if info.IsDir() || filepath.Ext(info.Name()) != ".json" {
+			continue
+		}
+
+		r, err := ReadEntry(path.Join(publishLogDir, info.Name()))
+		if err != nil {
+			log.Event(nil, "skipping publish-log entry that could not be read", log.Error(err), log.Data{"file": info.Name()})
+			continue
+		}
+		records = append(records, r)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].PublishDate.Before(records[j].PublishDate)
+	})
+	return records, nil
+}
+
+// ReadEntry parses a single publish-log entry json file.
+func ReadEntry(filename string) (*Record, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errs.New("failed to read publish-log entry", err, log.Data{"file": filename})
+	}
+
+	var e entry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, errs.New("failed to unmarshal publish-log entry", err, log.Data{"file": filename})
+	}
+
+	r := &Record{
+		Collection:   e.Name,
+		CollectionID: e.ID,
+		URIs:         make([]string, 0),
+		File:         filename,
+	}
+
+	dates := []string{e.PublishDate, e.PublishStartDate}
+	seen := make(map[string]bool)
+	for _, result := range e.PublishResults {
+		dates = append(dates, result.Transaction.StartDate)
+		for _, info := range result.Transaction.URIInfos {
+			if !seen[info.URI] {
+				seen[info.URI] = true
+				r.URIs = append(r.URIs, info.URI)
+			}
+		}
+	}
+	sort.Strings(r.URIs)
+
+	r.PublishDate, err = publishDate(filepath.Base(filename), dates)
+	if err != nil {
+		return nil, errs.New("failed to parse publish-log entry date", err, log.Data{"file": filename})
+	}
+	return r, nil
+}
+
+// publishDate returns the first of the dates that can be parsed, falling back to the date in the file name.
+func publishDate(filename string, dates []string) (time.Time, error) {
+	for _, d := range dates {
+		if d == "" {
+			continue
+		}
+
+		for _, format := range dateFormats {
+			if t, err := time.Parse(format, d); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Parse(fileDateFormat, filename[:min(len(filename), len(fileDateFormat))])
+}
+
+// Published returns true if the record published uri. The uri of a page matches its data.json.
+func (r *Record) Published(uri string) bool {
+	uri = path.Join("/", uri)
+	for _, u := range r.URIs {
+		if u == uri || (path.Base(u) == "data.json" && path.Dir(u) == uri) {
+			return true
+		}
+	}
+	return false
+}
+
+// LastPublished returns the most recent record that published uri, nil if uri has not been published.
+func LastPublished(records []*Record, uri string) *Record {
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Published(uri) {
+			return records[i]
+		}
+	}
+	return nil
+}
+
+// PublishedBy returns every record that published uri, oldest first.
+func PublishedBy(records []*Record, uri string) []*Record {
+	results := make([]*Record, 0)
+	for _, r := range records {
+		if r.Published(uri) {
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// Between returns the records published in the range from (inclusive) to (exclusive). A zero from or to is
+// unbounded.
+func Between(records []*Record, from time.Time, to time.Time) []*Record {
+	results := make([]*Record, 0)
+	for _, r := range records {
+		if !from.IsZero() && r.PublishDate.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !r.PublishDate.Before(to) {
+			continue
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// ParseDate parses a query date, either a date (2006-01-02) or a date time (RFC3339).
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,63 @@
+package publishlog
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+var testEntries = map[string]string{
+	"2020-01-02-09-30-b.json": `{"id":"b-1","name":"b","publishStartDate":"2020-01-02T09:30:00.000Z",
+		"publishResults":[{"transaction":{"uriInfos":[{"uri":"/economy/data.json"}]}}]}`,
+	"2020-01-01-09-30-a.json": `{"id":"a-1","name":"a","publishDate":"Jan 1, 2020 9:30:00 AM",
+		"publishResults":[{"transaction":{"uriInfos":[{"uri":"/economy/data.json"},{"uri":"/a/data.json"}]}}]}`,
+	"2020-01-03-09-30-broken.json": `{"id":`,
+	"notes.txt":                    "not an entry",
+}
+
+func writeEntries(t *testing.T, entries map[string]string) string {
+	dir, err := ioutil.TempDir("", "publishlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range entries {
+		if err := ioutil.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadSkipsMalformedEntries(t *testing.T) {
+	dir := writeEntries(t, testEntries)
+	defer os.RemoveAll(dir)
+
+	records, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	if records[0].Collection != "a" || records[1].Collection != "b" {
+		t.Errorf("expected records oldest first, got %s then %s", records[0].Collection, records[1].Collection)
+	}
+
+	if last := LastPublished(records, "/economy"); last == nil || last.Collection != "b" {
+		t.Errorf("expected /economy to be last published by b, got %+v", last)
+	}
+
+	if published := PublishedBy(records, "/a/data.json"); len(published) != 1 || published[0].Collection != "a" {
+		t.Errorf("expected /a/data.json to be published by a only, got %+v", published)
+	}
+}
+
+func TestReadMissingDir(t *testing.T) {
+	if _, err := Read("/does/not/exist"); err == nil {
+		t.Error("expected a missing publish-log dir to be an error")
+	}
+}